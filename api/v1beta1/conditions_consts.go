@@ -0,0 +1,39 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+const (
+	// MigratedCondition reports on the live migration of the vm to spec.node
+	MigratedCondition clusterv1.ConditionType = "Migrated"
+
+	// MigratingReason (Severity=Info) documents a vm being migrated to another node.
+	// The condition's message holds the UPID of the migration task
+	MigratingReason = "Migrating"
+
+	// MigrationFailedReason (Severity=Warning) documents a vm which failed to be migrated
+	MigrationFailedReason = "MigrationFailed"
+
+	// ConfigSyncedCondition reports on whether the vm config matches the spec.
+	// Mutable parameters are updated in place. Others are only reported
+	ConfigSyncedCondition clusterv1.ConditionType = "ConfigSynced"
+
+	// ImmutableConfigDriftedReason (Severity=Warning) documents a vm whose config differs from the spec
+	// in parameters which cannot be updated in place. The machine needs to be recreated to apply them
+	ImmutableConfigDriftedReason = "ImmutableConfigDrifted"
+)
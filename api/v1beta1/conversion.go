@@ -0,0 +1,297 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"regexp"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apiconversion "k8s.io/apimachinery/pkg/conversion"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+// v1beta1 disk size e.g. 50G, +10G
+var diskSizeRegexp = regexp.MustCompile(`^\+?(\d+(?:\.\d+)?)([KMGT]?)$`)
+
+// ConvertTo converts this ProxmoxCluster to the Hub version (v1beta2).
+func (src *ProxmoxCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxCluster)
+	if err := Convert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(src, dst, nil); err != nil {
+		return err
+	}
+
+	// restore v1beta2 data which v1beta1 cannot hold
+	restored := &v1beta2.ProxmoxCluster{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	if ok {
+		dst.Spec.GarbageCollection = restored.Spec.GarbageCollection
+		dst.Spec.ImageCache = restored.Spec.ImageCache
+		dst.Spec.FailureDomains = restored.Spec.FailureDomains
+		dst.Spec.SchedulerProfile = restored.Spec.SchedulerProfile
+		dst.Spec.ServerRef.TLS = restored.Spec.ServerRef.TLS
+		dst.Spec.ServerRef.IdentityRef = restored.Spec.ServerRef.IdentityRef
+		dst.Spec.ServerRef.FailoverEndpoints = restored.Spec.ServerRef.FailoverEndpoints
+		dst.Status.ActiveEndpoint = restored.Status.ActiveEndpoint
+		dst.Status.LastGarbageCollectionTime = restored.Status.LastGarbageCollectionTime
+		dst.Status.OrphanedResources = restored.Status.OrphanedResources
+		dst.Status.LastImageEvictionTime = restored.Status.LastImageEvictionTime
+		dst.Status.ImportImagesLastUsed = restored.Status.ImportImagesLastUsed
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxCluster)
+	if err := Convert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(src, dst, nil); err != nil {
+		return err
+	}
+
+	// preserve v1beta2 data which v1beta1 cannot hold
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this ProxmoxClusterList to the Hub version (v1beta2).
+func (src *ProxmoxClusterList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxClusterList)
+	return Convert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxClusterList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxClusterList)
+	return Convert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList(src, dst, nil)
+}
+
+// ConvertTo converts this ProxmoxMachine to the Hub version (v1beta2).
+func (src *ProxmoxMachine) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxMachine)
+	if err := Convert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(src, dst, nil); err != nil {
+		return err
+	}
+
+	// restore v1beta2 data which v1beta1 cannot hold
+	restored := &v1beta2.ProxmoxMachine{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	if ok {
+		restoreHubMachineSpec(&restored.Spec, &dst.Spec)
+		dst.Status.ProvisioningPhase = restored.Status.ProvisioningPhase
+		dst.Status.Task = restored.Status.Task
+	}
+
+	// preserve v1beta1 data which v1beta2 cannot hold
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxMachine) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxMachine)
+	if err := Convert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(src, dst, nil); err != nil {
+		return err
+	}
+
+	// restore v1beta1 data which v1beta2 cannot hold
+	restored := &ProxmoxMachine{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	if ok {
+		restoreSpokeMachineSpec(&restored.Spec, &dst.Spec, &src.Spec)
+	}
+
+	// preserve v1beta2 data which v1beta1 cannot hold
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this ProxmoxMachineList to the Hub version (v1beta2).
+func (src *ProxmoxMachineList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxMachineList)
+	return Convert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxMachineList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxMachineList)
+	return Convert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList(src, dst, nil)
+}
+
+// ConvertTo converts this ProxmoxMachineTemplate to the Hub version (v1beta2).
+func (src *ProxmoxMachineTemplate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxMachineTemplate)
+	if err := Convert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	restored := &v1beta2.ProxmoxMachineTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	if ok {
+		restoreHubMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec)
+	}
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxMachineTemplate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxMachineTemplate)
+	if err := Convert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(src, dst, nil); err != nil {
+		return err
+	}
+
+	restored := &ProxmoxMachineTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+	if ok {
+		restoreSpokeMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec, &src.Spec.Template.Spec)
+	}
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this ProxmoxMachineTemplateList to the Hub version (v1beta2).
+func (src *ProxmoxMachineTemplateList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta2.ProxmoxMachineTemplateList)
+	return Convert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList(src, dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1beta2) to this version.
+func (dst *ProxmoxMachineTemplateList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta2.ProxmoxMachineTemplateList)
+	return Convert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList(src, dst, nil)
+}
+
+// restore network devices other than the first one, no network device, exact disk size
+// unless they are changed through v1beta1, shutdown timeout and scheduler profile
+func restoreHubMachineSpec(restored, dst *v1beta2.ProxmoxMachineSpec) {
+	dst.ShutdownTimeout = restored.ShutdownTimeout
+	dst.SchedulerProfile = restored.SchedulerProfile
+	switch devices := restored.Hardware.NetworkDevices; {
+	case len(devices) > 1:
+		dst.Hardware.NetworkDevices = append(dst.Hardware.NetworkDevices[:1], devices[1:]...)
+	case len(devices) == 0 && apiequality.Semantic.DeepEqual(dst.Hardware.NetworkDevices, []v1beta2.NetworkDevice{{}}):
+		dst.Hardware.NetworkDevices = nil
+	}
+	if restored.Hardware.Disk != nil && dst.Hardware.Disk != nil && restored.Hardware.Disk.Cmp(*dst.Hardware.Disk) == 0 {
+		dst.Hardware.Disk = restored.Hardware.Disk
+	}
+}
+
+// restore lock and original disk size notation (e.g. relative size)
+// unless they are changed through v1beta2
+func restoreSpokeMachineSpec(restored, dst *ProxmoxMachineSpec, src *v1beta2.ProxmoxMachineSpec) {
+	dst.Options.Lock = restored.Options.Lock
+	disk, err := diskSizeToQuantity(restored.Hardware.Disk)
+	if err != nil {
+		// disk size not following the format was converted to nil
+		if src.Hardware.Disk == nil {
+			dst.Hardware.Disk = restored.Hardware.Disk
+		}
+		return
+	}
+	if (disk == nil) != (src.Hardware.Disk == nil) || (disk != nil && disk.Cmp(*src.Hardware.Disk) != 0) {
+		return
+	}
+	dst.Hardware.Disk = restored.Hardware.Disk
+}
+
+func Convert_v1beta1_Hardware_To_v1beta2_Hardware(in *Hardware, out *v1beta2.Hardware, s apiconversion.Scope) error {
+	if err := autoConvert_v1beta1_Hardware_To_v1beta2_Hardware(in, out, s); err != nil {
+		return err
+	}
+	// disk size not following the format is left empty.
+	// the original value is restored when converted back
+	out.Disk, _ = diskSizeToQuantity(in.Disk)
+	out.NetworkDevices = make([]v1beta2.NetworkDevice, 1)
+	return Convert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice(&in.NetworkDevice, &out.NetworkDevices[0], s)
+}
+
+func Convert_v1beta2_Hardware_To_v1beta1_Hardware(in *v1beta2.Hardware, out *Hardware, s apiconversion.Scope) error {
+	if err := autoConvert_v1beta2_Hardware_To_v1beta1_Hardware(in, out, s); err != nil {
+		return err
+	}
+	out.Disk = in.DiskSize()
+	if len(in.NetworkDevices) == 0 {
+		return nil
+	}
+	return Convert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice(&in.NetworkDevices[0], &out.NetworkDevice, s)
+}
+
+func Convert_v1beta2_ProxmoxClusterSpec_To_v1beta1_ProxmoxClusterSpec(in *v1beta2.ProxmoxClusterSpec, out *ProxmoxClusterSpec, s apiconversion.Scope) error {
+	// GarbageCollection is restored from the annotation when converted back
+	return autoConvert_v1beta2_ProxmoxClusterSpec_To_v1beta1_ProxmoxClusterSpec(in, out, s)
+}
+
+func Convert_v1beta2_ServerRef_To_v1beta1_ServerRef(in *v1beta2.ServerRef, out *ServerRef, s apiconversion.Scope) error {
+	// TLS is restored from the annotation when converted back
+	return autoConvert_v1beta2_ServerRef_To_v1beta1_ServerRef(in, out, s)
+}
+
+func Convert_v1beta2_ProxmoxClusterStatus_To_v1beta1_ProxmoxClusterStatus(in *v1beta2.ProxmoxClusterStatus, out *ProxmoxClusterStatus, s apiconversion.Scope) error {
+	// garbage collection status is restored from the annotation when converted back
+	return autoConvert_v1beta2_ProxmoxClusterStatus_To_v1beta1_ProxmoxClusterStatus(in, out, s)
+}
+
+func Convert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(in *v1beta2.ProxmoxMachineSpec, out *ProxmoxMachineSpec, s apiconversion.Scope) error {
+	// ShutdownTimeout is restored from the annotation when converted back
+	return autoConvert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(in, out, s)
+}
+
+func Convert_v1beta2_ProxmoxMachineStatus_To_v1beta1_ProxmoxMachineStatus(in *v1beta2.ProxmoxMachineStatus, out *ProxmoxMachineStatus, s apiconversion.Scope) error {
+	// ProvisioningPhase and Task are restored from the annotation when converted back
+	return autoConvert_v1beta2_ProxmoxMachineStatus_To_v1beta1_ProxmoxMachineStatus(in, out, s)
+}
+
+func Convert_v1beta1_Options_To_v1beta2_Options(in *Options, out *v1beta2.Options, s apiconversion.Scope) error {
+	// Lock is dropped since it is runtime state of the vm
+	return autoConvert_v1beta1_Options_To_v1beta2_Options(in, out, s)
+}
+
+// convert v1beta1 disk size to quantity. K/M/G/T are binary units in proxmox.
+// relative size (e.g. +10G) is converted to absolute one
+func diskSizeToQuantity(size string) (*resource.Quantity, error) {
+	if size == "" {
+		return nil, nil
+	}
+	match := diskSizeRegexp.FindStringSubmatch(size)
+	if match == nil {
+		return nil, fmt.Errorf("invalid disk size: %s", size)
+	}
+	suffix := match[2]
+	if suffix != "" {
+		suffix += "i"
+	}
+	q, err := resource.ParseQuantity(match[1] + suffix)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
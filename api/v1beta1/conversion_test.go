@@ -0,0 +1,221 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("ProxmoxMachine conversion", Label("unit", "api"), func() {
+	It("should convert v1beta1 to v1beta2", func() {
+		src := &v1beta1.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: v1beta1.ProxmoxMachineSpec{
+				Hardware: v1beta1.Hardware{
+					CPU:           2,
+					Memory:        4096,
+					Disk:          "50G",
+					NetworkDevice: v1beta1.NetworkDevice{Model: "virtio", Bridge: "vmbr0"},
+				},
+			},
+		}
+		dst := &v1beta2.ProxmoxMachine{}
+		Expect(src.ConvertTo(dst)).To(Succeed())
+		Expect(dst.Spec.Hardware.Disk.Cmp(resource.MustParse("50Gi"))).To(Equal(0))
+		Expect(dst.Spec.Hardware.NetworkDevices).To(Equal([]v1beta2.NetworkDevice{{Model: "virtio", Bridge: "vmbr0"}}))
+	})
+
+	It("should round trip v1beta1 -> v1beta2 -> v1beta1", func() {
+		src := &v1beta1.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: v1beta1.ProxmoxMachineSpec{
+				Hardware: v1beta1.Hardware{Disk: "+10G"},
+				Options:  v1beta1.Options{Lock: "backup"},
+			},
+		}
+		hub := &v1beta2.ProxmoxMachine{}
+		Expect(src.ConvertTo(hub)).To(Succeed())
+		dst := &v1beta1.ProxmoxMachine{}
+		Expect(dst.ConvertFrom(hub)).To(Succeed())
+		Expect(dst.Spec.Hardware.Disk).To(Equal("+10G"))
+		Expect(dst.Spec.Options.Lock).To(Equal(v1beta1.Lock("backup")))
+	})
+
+	It("should round trip v1beta2 -> v1beta1 -> v1beta2", func() {
+		disk := resource.MustParse("1.5Gi")
+		src := &v1beta2.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: v1beta2.ProxmoxMachineSpec{
+				Hardware: v1beta2.Hardware{
+					Disk: &disk,
+					NetworkDevices: []v1beta2.NetworkDevice{
+						{Model: "virtio", Bridge: "vmbr0"},
+						{Model: "e1000", Bridge: "vmbr1"},
+					},
+				},
+			},
+			Status: v1beta2.ProxmoxMachineStatus{ProvisioningPhase: v1beta2.ProvisioningPhaseCreated},
+		}
+		src.Spec.ShutdownTimeout = &metav1.Duration{Duration: 0}
+		src.Spec.SchedulerProfile = "production"
+		src.Status.Task = &v1beta2.ProxmoxTask{UPID: "UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmcreate:100:root@pam:", Operation: v1beta2.TaskOperationCreate}
+		spoke := &v1beta1.ProxmoxMachine{}
+		Expect(spoke.ConvertFrom(src)).To(Succeed())
+		Expect(spoke.Spec.Hardware.Disk).To(Equal("1536M"))
+		Expect(spoke.Spec.Hardware.NetworkDevice).To(Equal(v1beta1.NetworkDevice{Model: "virtio", Bridge: "vmbr0"}))
+
+		dst := &v1beta2.ProxmoxMachine{}
+		Expect(spoke.ConvertTo(dst)).To(Succeed())
+		Expect(dst.Spec.Hardware.Disk.String()).To(Equal("1536Mi"))
+		Expect(dst.Spec.Hardware.Disk.Cmp(disk)).To(Equal(0))
+		Expect(dst.Spec.Hardware.NetworkDevices).To(Equal(src.Spec.Hardware.NetworkDevices))
+		Expect(dst.Status.ProvisioningPhase).To(Equal(v1beta2.ProvisioningPhaseCreated))
+		Expect(dst.Spec.ShutdownTimeout).To(Equal(src.Spec.ShutdownTimeout))
+		Expect(dst.Spec.SchedulerProfile).To(Equal(src.Spec.SchedulerProfile))
+		Expect(dst.Status.Task.UPID).To(Equal(src.Status.Task.UPID))
+	})
+
+	It("should not restore disk size changed through v1beta1", func() {
+		disk := resource.MustParse("50Gi")
+		src := &v1beta2.ProxmoxMachine{Spec: v1beta2.ProxmoxMachineSpec{Hardware: v1beta2.Hardware{Disk: &disk}}}
+		spoke := &v1beta1.ProxmoxMachine{}
+		Expect(spoke.ConvertFrom(src)).To(Succeed())
+		spoke.Spec.Hardware.Disk = "100G"
+		dst := &v1beta2.ProxmoxMachine{}
+		Expect(spoke.ConvertTo(dst)).To(Succeed())
+		Expect(dst.Spec.Hardware.Disk.Cmp(resource.MustParse("100Gi"))).To(Equal(0))
+	})
+})
+
+var _ = Describe("ProxmoxMachineTemplate conversion", Label("unit", "api"), func() {
+	It("should round trip v1beta2 -> v1beta1 -> v1beta2", func() {
+		src := &v1beta2.ProxmoxMachineTemplate{
+			Spec: v1beta2.ProxmoxMachineTemplateSpec{
+				Template: v1beta2.ProxmoxMachineTemplateResource{
+					Spec: v1beta2.ProxmoxMachineSpec{
+						Hardware: v1beta2.Hardware{
+							NetworkDevices: []v1beta2.NetworkDevice{{Bridge: "vmbr0"}, {Bridge: "vmbr1"}},
+						},
+					},
+				},
+			},
+		}
+		spoke := &v1beta1.ProxmoxMachineTemplate{}
+		Expect(spoke.ConvertFrom(src)).To(Succeed())
+		dst := &v1beta2.ProxmoxMachineTemplate{}
+		Expect(spoke.ConvertTo(dst)).To(Succeed())
+		Expect(dst.Spec.Template.Spec.Hardware.NetworkDevices).To(Equal(src.Spec.Template.Spec.Hardware.NetworkDevices))
+	})
+})
+
+var _ = Describe("ProxmoxCluster conversion", Label("unit", "api"), func() {
+	It("should round trip v1beta2 -> v1beta1 -> v1beta2", func() {
+		vmid := 100
+		src := &v1beta2.ProxmoxCluster{
+			Spec: v1beta2.ProxmoxClusterSpec{
+				GarbageCollection: &v1beta2.GarbageCollectionPolicy{Mode: v1beta2.GarbageCollectionModeDelete},
+				FailureDomains:    []v1beta2.FailureDomain{{Name: "site-a", ControlPlane: true}},
+				SchedulerProfile:  "production",
+				ServerRef: v1beta2.ServerRef{
+					Endpoint:          "https://pve.example.com:8006/api2/json",
+					IdentityRef:       &v1beta2.ProxmoxClusterIdentityReference{Name: "pve"},
+					FailoverEndpoints: []string{"https://pve2.example.com:8006/api2/json"},
+					TLS:               &v1beta2.ServerTLSConfig{Fingerprints: []v1beta2.CertificateFingerprint{"E3:B0:C4:42:98:FC:1C:14:9A:FB:F4:C8:99:6F:B9:24:27:AE:41:E4:64:9B:93:4C:A4:95:99:1B:78:52:B8:55"}},
+				},
+			},
+			Status: v1beta2.ProxmoxClusterStatus{
+				ActiveEndpoint: "https://pve2.example.com:8006/api2/json",
+				OrphanedResources: []v1beta2.OrphanedResource{
+					{Kind: v1beta2.OrphanedResourceKindQEMU, Node: "node1", Name: "foo", VMID: &vmid},
+				},
+			},
+		}
+		spoke := &v1beta1.ProxmoxCluster{}
+		Expect(spoke.ConvertFrom(src)).To(Succeed())
+		dst := &v1beta2.ProxmoxCluster{}
+		Expect(spoke.ConvertTo(dst)).To(Succeed())
+		Expect(dst.Spec.GarbageCollection).To(Equal(src.Spec.GarbageCollection))
+		Expect(dst.Spec.FailureDomains).To(Equal(src.Spec.FailureDomains))
+		Expect(dst.Spec.SchedulerProfile).To(Equal(src.Spec.SchedulerProfile))
+		Expect(dst.Status.OrphanedResources).To(Equal(src.Status.OrphanedResources))
+		Expect(dst.Status.ActiveEndpoint).To(Equal(src.Status.ActiveEndpoint))
+		Expect(dst.Spec.ServerRef).To(Equal(src.Spec.ServerRef))
+	})
+})
+
+func TestFuzzyConversion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("for ProxmoxCluster", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:      scheme,
+		Hub:         &v1beta2.ProxmoxCluster{},
+		Spoke:       &v1beta1.ProxmoxCluster{},
+		FuzzerFuncs: []fuzzer.FuzzerFuncs{fuzzFuncs},
+	}))
+	t.Run("for ProxmoxMachine", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:      scheme,
+		Hub:         &v1beta2.ProxmoxMachine{},
+		Spoke:       &v1beta1.ProxmoxMachine{},
+		FuzzerFuncs: []fuzzer.FuzzerFuncs{fuzzFuncs},
+		// v1beta1 only data (e.g. relative disk size) is preserved in the hub
+		HubAfterMutation: dropConversionData,
+	}))
+	t.Run("for ProxmoxMachineTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:      scheme,
+		Hub:         &v1beta2.ProxmoxMachineTemplate{},
+		Spoke:       &v1beta1.ProxmoxMachineTemplate{},
+		FuzzerFuncs: []fuzzer.FuzzerFuncs{fuzzFuncs},
+		// v1beta1 only data (e.g. relative disk size) is preserved in the hub
+		HubAfterMutation: dropConversionData,
+	}))
+}
+
+func dropConversionData(hub conversion.Hub) {
+	delete(hub.(metav1.Object).GetAnnotations(), utilconversion.DataAnnotation)
+}
+
+func fuzzFuncs(_ runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		// json.Number of vm config status must be a number to be marshalled into the annotation
+		func(in *json.Number, c fuzz.Continue) {
+			*in = json.Number(strconv.FormatInt(c.Int63(), 10))
+		},
+	}
+}
@@ -37,6 +37,34 @@ type ProxmoxClusterSpec struct {
 
 	// storage is used for storing cloud init snippet
 	Storage Storage `json:"storage,omitempty"`
+
+	// Rebalance configures periodic rebalancing of ProxmoxMachines across proxmox nodes.
+	// Rebalancing is disabled if empty.
+	// +optional
+	Rebalance *RebalancePolicy `json:"rebalance,omitempty"`
+}
+
+// RebalancePolicy configures how ProxmoxMachines are moved
+// from heavily loaded proxmox nodes to less loaded ones
+type RebalancePolicy struct {
+	// Interval is the period of evaluating node load
+	// +kubebuilder:default:="10m"
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// MaxUnavailable is the maximum number of ProxmoxMachines which
+	// can be migrated or remediated at the same time
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=1
+	// +optional
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+
+	// Threshold is the minimum score improvement (in percent) of the best node
+	// against the current node required to move a ProxmoxMachine
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=20
+	// +optional
+	Threshold int `json:"threshold,omitempty"`
 }
 
 // ProxmoxClusterStatus defines the observed state of ProxmoxCluster
@@ -49,6 +77,10 @@ type ProxmoxClusterStatus struct {
 
 	// Conditions
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// LastRebalanceTime is the last time rebalancing was evaluated
+	// +optional
+	LastRebalanceTime *metav1.Time `json:"lastRebalanceTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
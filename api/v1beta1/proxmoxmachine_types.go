@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"github.com/k8s-proxmox/proxmox-go/api"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/errors"
@@ -33,7 +34,8 @@ type ProxmoxMachineSpec struct {
 	// ProviderID
 	ProviderID *string `json:"providerID,omitempty"`
 
-	// Node is proxmox node hosting vm instance which used for ProxmoxMachine
+	// Node is proxmox node hosting vm instance which used for ProxmoxMachine.
+	// Changing it after the vm is created live-migrates the vm to the new node.
 	Node string `json:"node,omitempty"`
 
 	// Storage is name of proxmox storage used by this node.
@@ -64,6 +66,16 @@ type ProxmoxMachineSpec struct {
 
 	// FailureDomain is the failure domain unique identifier this Machine should be attached to, as defined in Cluster API.
 	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// NodeSelector is a selector which must match a proxmox node's labels for the vm to be scheduled on that node.
+	// Proxmox node labels are read from the node's notes and qemu-scheduler's config.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// NodeAffinity describes node affinity scheduling rules for the vm.
+	// Only "metadata.name" (proxmox node name) is supported as a key of matchFields.
+	// +optional
+	NodeAffinity *corev1.NodeAffinity `json:"nodeAffinity,omitempty"`
 }
 
 // ProxmoxMachineStatus defines the observed state of ProxmoxMachine
@@ -84,6 +96,11 @@ type ProxmoxMachineStatus struct {
 	// Conditions
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 
+	// Node is proxmox node currently hosting vm instance.
+	// It differs from spec.node while the vm is being migrated.
+	// +optional
+	Node string `json:"node,omitempty"`
+
 	// Configuration
 	Config api.VirtualMachineConfig `json:"config,omitempty"`
 
@@ -112,6 +129,16 @@ type ProxmoxMachine struct {
 	Status ProxmoxMachineStatus `json:"status,omitempty"`
 }
 
+// GetConditions returns the set of conditions for this object.
+func (r *ProxmoxMachine) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (r *ProxmoxMachine) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
 //+kubebuilder:object:root=true
 
 // ProxmoxMachineList contains a list of ProxmoxMachine
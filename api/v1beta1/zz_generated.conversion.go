@@ -0,0 +1,1344 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	unsafe "unsafe"
+
+	v1beta2 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	errors "sigs.k8s.io/cluster-api/errors"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*CACert)(nil), (*v1beta2.CACert)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CACert_To_v1beta2_CACert(a.(*CACert), b.(*v1beta2.CACert), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.CACert)(nil), (*CACert)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_CACert_To_v1beta1_CACert(a.(*v1beta2.CACert), b.(*CACert), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ChPasswd)(nil), (*v1beta2.ChPasswd)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ChPasswd_To_v1beta2_ChPasswd(a.(*ChPasswd), b.(*v1beta2.ChPasswd), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ChPasswd)(nil), (*ChPasswd)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ChPasswd_To_v1beta1_ChPasswd(a.(*v1beta2.ChPasswd), b.(*ChPasswd), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CloudInit)(nil), (*v1beta2.CloudInit)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CloudInit_To_v1beta2_CloudInit(a.(*CloudInit), b.(*v1beta2.CloudInit), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.CloudInit)(nil), (*CloudInit)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_CloudInit_To_v1beta1_CloudInit(a.(*v1beta2.CloudInit), b.(*CloudInit), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*IPConfig)(nil), (*v1beta2.IPConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_IPConfig_To_v1beta2_IPConfig(a.(*IPConfig), b.(*v1beta2.IPConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.IPConfig)(nil), (*IPConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_IPConfig_To_v1beta1_IPConfig(a.(*v1beta2.IPConfig), b.(*IPConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Image)(nil), (*v1beta2.Image)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Image_To_v1beta2_Image(a.(*Image), b.(*v1beta2.Image), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.Image)(nil), (*Image)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_Image_To_v1beta1_Image(a.(*v1beta2.Image), b.(*Image), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Network)(nil), (*v1beta2.Network)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Network_To_v1beta2_Network(a.(*Network), b.(*v1beta2.Network), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.Network)(nil), (*Network)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_Network_To_v1beta1_Network(a.(*v1beta2.Network), b.(*Network), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkDevice)(nil), (*v1beta2.NetworkDevice)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice(a.(*NetworkDevice), b.(*v1beta2.NetworkDevice), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.NetworkDevice)(nil), (*NetworkDevice)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice(a.(*v1beta2.NetworkDevice), b.(*NetworkDevice), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ObjectReference)(nil), (*v1beta2.ObjectReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ObjectReference_To_v1beta2_ObjectReference(a.(*ObjectReference), b.(*v1beta2.ObjectReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ObjectReference)(nil), (*ObjectReference)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ObjectReference_To_v1beta1_ObjectReference(a.(*v1beta2.ObjectReference), b.(*ObjectReference), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.Options)(nil), (*Options)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_Options_To_v1beta1_Options(a.(*v1beta2.Options), b.(*Options), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxCluster)(nil), (*v1beta2.ProxmoxCluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(a.(*ProxmoxCluster), b.(*v1beta2.ProxmoxCluster), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxCluster)(nil), (*ProxmoxCluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(a.(*v1beta2.ProxmoxCluster), b.(*ProxmoxCluster), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxClusterList)(nil), (*v1beta2.ProxmoxClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList(a.(*ProxmoxClusterList), b.(*v1beta2.ProxmoxClusterList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxClusterList)(nil), (*ProxmoxClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList(a.(*v1beta2.ProxmoxClusterList), b.(*ProxmoxClusterList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxClusterSpec)(nil), (*v1beta2.ProxmoxClusterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec(a.(*ProxmoxClusterSpec), b.(*v1beta2.ProxmoxClusterSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxClusterStatus)(nil), (*v1beta2.ProxmoxClusterStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus(a.(*ProxmoxClusterStatus), b.(*v1beta2.ProxmoxClusterStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachine)(nil), (*v1beta2.ProxmoxMachine)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(a.(*ProxmoxMachine), b.(*v1beta2.ProxmoxMachine), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachine)(nil), (*ProxmoxMachine)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(a.(*v1beta2.ProxmoxMachine), b.(*ProxmoxMachine), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineList)(nil), (*v1beta2.ProxmoxMachineList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList(a.(*ProxmoxMachineList), b.(*v1beta2.ProxmoxMachineList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineList)(nil), (*ProxmoxMachineList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList(a.(*v1beta2.ProxmoxMachineList), b.(*ProxmoxMachineList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineSpec)(nil), (*v1beta2.ProxmoxMachineSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(a.(*ProxmoxMachineSpec), b.(*v1beta2.ProxmoxMachineSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineStatus)(nil), (*v1beta2.ProxmoxMachineStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus(a.(*ProxmoxMachineStatus), b.(*v1beta2.ProxmoxMachineStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineTemplate)(nil), (*v1beta2.ProxmoxMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(a.(*ProxmoxMachineTemplate), b.(*v1beta2.ProxmoxMachineTemplate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineTemplate)(nil), (*ProxmoxMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(a.(*v1beta2.ProxmoxMachineTemplate), b.(*ProxmoxMachineTemplate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineTemplateList)(nil), (*v1beta2.ProxmoxMachineTemplateList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList(a.(*ProxmoxMachineTemplateList), b.(*v1beta2.ProxmoxMachineTemplateList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineTemplateList)(nil), (*ProxmoxMachineTemplateList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList(a.(*v1beta2.ProxmoxMachineTemplateList), b.(*ProxmoxMachineTemplateList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineTemplateResource)(nil), (*v1beta2.ProxmoxMachineTemplateResource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource(a.(*ProxmoxMachineTemplateResource), b.(*v1beta2.ProxmoxMachineTemplateResource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineTemplateResource)(nil), (*ProxmoxMachineTemplateResource)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource(a.(*v1beta2.ProxmoxMachineTemplateResource), b.(*ProxmoxMachineTemplateResource), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineTemplateSpec)(nil), (*v1beta2.ProxmoxMachineTemplateSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec(a.(*ProxmoxMachineTemplateSpec), b.(*v1beta2.ProxmoxMachineTemplateSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineTemplateSpec)(nil), (*ProxmoxMachineTemplateSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec(a.(*v1beta2.ProxmoxMachineTemplateSpec), b.(*ProxmoxMachineTemplateSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ProxmoxMachineTemplateStatus)(nil), (*v1beta2.ProxmoxMachineTemplateStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus(a.(*ProxmoxMachineTemplateStatus), b.(*v1beta2.ProxmoxMachineTemplateStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.ProxmoxMachineTemplateStatus)(nil), (*ProxmoxMachineTemplateStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus(a.(*v1beta2.ProxmoxMachineTemplateStatus), b.(*ProxmoxMachineTemplateStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RebalancePolicy)(nil), (*v1beta2.RebalancePolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RebalancePolicy_To_v1beta2_RebalancePolicy(a.(*RebalancePolicy), b.(*v1beta2.RebalancePolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.RebalancePolicy)(nil), (*RebalancePolicy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_RebalancePolicy_To_v1beta1_RebalancePolicy(a.(*v1beta2.RebalancePolicy), b.(*RebalancePolicy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SSH)(nil), (*v1beta2.SSH)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SSH_To_v1beta2_SSH(a.(*SSH), b.(*v1beta2.SSH), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.SSH)(nil), (*SSH)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_SSH_To_v1beta1_SSH(a.(*v1beta2.SSH), b.(*SSH), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SSHKeys)(nil), (*v1beta2.SSHKeys)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SSHKeys_To_v1beta2_SSHKeys(a.(*SSHKeys), b.(*v1beta2.SSHKeys), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.SSHKeys)(nil), (*SSHKeys)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_SSHKeys_To_v1beta1_SSHKeys(a.(*v1beta2.SSHKeys), b.(*SSHKeys), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ServerRef)(nil), (*v1beta2.ServerRef)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ServerRef_To_v1beta2_ServerRef(a.(*ServerRef), b.(*v1beta2.ServerRef), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Storage)(nil), (*v1beta2.Storage)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Storage_To_v1beta2_Storage(a.(*Storage), b.(*v1beta2.Storage), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.Storage)(nil), (*Storage)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_Storage_To_v1beta1_Storage(a.(*v1beta2.Storage), b.(*Storage), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*User)(nil), (*v1beta2.User)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_User_To_v1beta2_User(a.(*User), b.(*v1beta2.User), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.User)(nil), (*User)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_User_To_v1beta1_User(a.(*v1beta2.User), b.(*User), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*UserData)(nil), (*v1beta2.UserData)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_UserData_To_v1beta2_UserData(a.(*UserData), b.(*v1beta2.UserData), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.UserData)(nil), (*UserData)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_UserData_To_v1beta1_UserData(a.(*v1beta2.UserData), b.(*UserData), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WriteFiles)(nil), (*v1beta2.WriteFiles)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_WriteFiles_To_v1beta2_WriteFiles(a.(*WriteFiles), b.(*v1beta2.WriteFiles), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta2.WriteFiles)(nil), (*WriteFiles)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_WriteFiles_To_v1beta1_WriteFiles(a.(*v1beta2.WriteFiles), b.(*WriteFiles), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*Hardware)(nil), (*v1beta2.Hardware)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Hardware_To_v1beta2_Hardware(a.(*Hardware), b.(*v1beta2.Hardware), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*Options)(nil), (*v1beta2.Options)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Options_To_v1beta2_Options(a.(*Options), b.(*v1beta2.Options), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.Hardware)(nil), (*Hardware)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_Hardware_To_v1beta1_Hardware(a.(*v1beta2.Hardware), b.(*Hardware), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.ProxmoxClusterSpec)(nil), (*ProxmoxClusterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxClusterSpec_To_v1beta1_ProxmoxClusterSpec(a.(*v1beta2.ProxmoxClusterSpec), b.(*ProxmoxClusterSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.ProxmoxClusterStatus)(nil), (*ProxmoxClusterStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxClusterStatus_To_v1beta1_ProxmoxClusterStatus(a.(*v1beta2.ProxmoxClusterStatus), b.(*ProxmoxClusterStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.ProxmoxMachineSpec)(nil), (*ProxmoxMachineSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(a.(*v1beta2.ProxmoxMachineSpec), b.(*ProxmoxMachineSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.ProxmoxMachineStatus)(nil), (*ProxmoxMachineStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ProxmoxMachineStatus_To_v1beta1_ProxmoxMachineStatus(a.(*v1beta2.ProxmoxMachineStatus), b.(*ProxmoxMachineStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta2.ServerRef)(nil), (*ServerRef)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta2_ServerRef_To_v1beta1_ServerRef(a.(*v1beta2.ServerRef), b.(*ServerRef), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1beta1_CACert_To_v1beta2_CACert(in *CACert, out *v1beta2.CACert, s conversion.Scope) error {
+	out.RemoveDefaults = in.RemoveDefaults
+	out.Trusted = *(*[]string)(unsafe.Pointer(&in.Trusted))
+	return nil
+}
+
+// Convert_v1beta1_CACert_To_v1beta2_CACert is an autogenerated conversion function.
+func Convert_v1beta1_CACert_To_v1beta2_CACert(in *CACert, out *v1beta2.CACert, s conversion.Scope) error {
+	return autoConvert_v1beta1_CACert_To_v1beta2_CACert(in, out, s)
+}
+
+func autoConvert_v1beta2_CACert_To_v1beta1_CACert(in *v1beta2.CACert, out *CACert, s conversion.Scope) error {
+	out.RemoveDefaults = in.RemoveDefaults
+	out.Trusted = *(*[]string)(unsafe.Pointer(&in.Trusted))
+	return nil
+}
+
+// Convert_v1beta2_CACert_To_v1beta1_CACert is an autogenerated conversion function.
+func Convert_v1beta2_CACert_To_v1beta1_CACert(in *v1beta2.CACert, out *CACert, s conversion.Scope) error {
+	return autoConvert_v1beta2_CACert_To_v1beta1_CACert(in, out, s)
+}
+
+func autoConvert_v1beta1_ChPasswd_To_v1beta2_ChPasswd(in *ChPasswd, out *v1beta2.ChPasswd, s conversion.Scope) error {
+	out.Expire = in.Expire
+	return nil
+}
+
+// Convert_v1beta1_ChPasswd_To_v1beta2_ChPasswd is an autogenerated conversion function.
+func Convert_v1beta1_ChPasswd_To_v1beta2_ChPasswd(in *ChPasswd, out *v1beta2.ChPasswd, s conversion.Scope) error {
+	return autoConvert_v1beta1_ChPasswd_To_v1beta2_ChPasswd(in, out, s)
+}
+
+func autoConvert_v1beta2_ChPasswd_To_v1beta1_ChPasswd(in *v1beta2.ChPasswd, out *ChPasswd, s conversion.Scope) error {
+	out.Expire = in.Expire
+	return nil
+}
+
+// Convert_v1beta2_ChPasswd_To_v1beta1_ChPasswd is an autogenerated conversion function.
+func Convert_v1beta2_ChPasswd_To_v1beta1_ChPasswd(in *v1beta2.ChPasswd, out *ChPasswd, s conversion.Scope) error {
+	return autoConvert_v1beta2_ChPasswd_To_v1beta1_ChPasswd(in, out, s)
+}
+
+func autoConvert_v1beta1_CloudInit_To_v1beta2_CloudInit(in *CloudInit, out *v1beta2.CloudInit, s conversion.Scope) error {
+	out.UserData = (*v1beta2.UserData)(unsafe.Pointer(in.UserData))
+	return nil
+}
+
+// Convert_v1beta1_CloudInit_To_v1beta2_CloudInit is an autogenerated conversion function.
+func Convert_v1beta1_CloudInit_To_v1beta2_CloudInit(in *CloudInit, out *v1beta2.CloudInit, s conversion.Scope) error {
+	return autoConvert_v1beta1_CloudInit_To_v1beta2_CloudInit(in, out, s)
+}
+
+func autoConvert_v1beta2_CloudInit_To_v1beta1_CloudInit(in *v1beta2.CloudInit, out *CloudInit, s conversion.Scope) error {
+	out.UserData = (*UserData)(unsafe.Pointer(in.UserData))
+	return nil
+}
+
+// Convert_v1beta2_CloudInit_To_v1beta1_CloudInit is an autogenerated conversion function.
+func Convert_v1beta2_CloudInit_To_v1beta1_CloudInit(in *v1beta2.CloudInit, out *CloudInit, s conversion.Scope) error {
+	return autoConvert_v1beta2_CloudInit_To_v1beta1_CloudInit(in, out, s)
+}
+
+func autoConvert_v1beta1_Hardware_To_v1beta2_Hardware(in *Hardware, out *v1beta2.Hardware, s conversion.Scope) error {
+	out.Memory = in.Memory
+	out.CPU = in.CPU
+	out.CPUType = in.CPUType
+	out.Sockets = in.Sockets
+	out.CPULimit = in.CPULimit
+	out.BIOS = v1beta2.BIOS(in.BIOS)
+	// WARNING: in.Disk requires manual conversion: inconvertible types (string vs *k8s.io/apimachinery/pkg/api/resource.Quantity)
+	// WARNING: in.NetworkDevice requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta2_Hardware_To_v1beta1_Hardware(in *v1beta2.Hardware, out *Hardware, s conversion.Scope) error {
+	out.Memory = in.Memory
+	out.CPU = in.CPU
+	out.CPUType = in.CPUType
+	out.Sockets = in.Sockets
+	out.CPULimit = in.CPULimit
+	out.BIOS = BIOS(in.BIOS)
+	// WARNING: in.Disk requires manual conversion: inconvertible types (*k8s.io/apimachinery/pkg/api/resource.Quantity vs string)
+	// WARNING: in.NetworkDevices requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_IPConfig_To_v1beta2_IPConfig(in *IPConfig, out *v1beta2.IPConfig, s conversion.Scope) error {
+	out.IP = in.IP
+	out.Gateway = in.Gateway
+	out.IP6 = in.IP6
+	out.Gateway6 = in.Gateway6
+	return nil
+}
+
+// Convert_v1beta1_IPConfig_To_v1beta2_IPConfig is an autogenerated conversion function.
+func Convert_v1beta1_IPConfig_To_v1beta2_IPConfig(in *IPConfig, out *v1beta2.IPConfig, s conversion.Scope) error {
+	return autoConvert_v1beta1_IPConfig_To_v1beta2_IPConfig(in, out, s)
+}
+
+func autoConvert_v1beta2_IPConfig_To_v1beta1_IPConfig(in *v1beta2.IPConfig, out *IPConfig, s conversion.Scope) error {
+	out.IP = in.IP
+	out.Gateway = in.Gateway
+	out.IP6 = in.IP6
+	out.Gateway6 = in.Gateway6
+	return nil
+}
+
+// Convert_v1beta2_IPConfig_To_v1beta1_IPConfig is an autogenerated conversion function.
+func Convert_v1beta2_IPConfig_To_v1beta1_IPConfig(in *v1beta2.IPConfig, out *IPConfig, s conversion.Scope) error {
+	return autoConvert_v1beta2_IPConfig_To_v1beta1_IPConfig(in, out, s)
+}
+
+func autoConvert_v1beta1_Image_To_v1beta2_Image(in *Image, out *v1beta2.Image, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Checksum = in.Checksum
+	out.ChecksumType = (*string)(unsafe.Pointer(in.ChecksumType))
+	return nil
+}
+
+// Convert_v1beta1_Image_To_v1beta2_Image is an autogenerated conversion function.
+func Convert_v1beta1_Image_To_v1beta2_Image(in *Image, out *v1beta2.Image, s conversion.Scope) error {
+	return autoConvert_v1beta1_Image_To_v1beta2_Image(in, out, s)
+}
+
+func autoConvert_v1beta2_Image_To_v1beta1_Image(in *v1beta2.Image, out *Image, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Checksum = in.Checksum
+	out.ChecksumType = (*string)(unsafe.Pointer(in.ChecksumType))
+	return nil
+}
+
+// Convert_v1beta2_Image_To_v1beta1_Image is an autogenerated conversion function.
+func Convert_v1beta2_Image_To_v1beta1_Image(in *v1beta2.Image, out *Image, s conversion.Scope) error {
+	return autoConvert_v1beta2_Image_To_v1beta1_Image(in, out, s)
+}
+
+func autoConvert_v1beta1_Network_To_v1beta2_Network(in *Network, out *v1beta2.Network, s conversion.Scope) error {
+	if err := Convert_v1beta1_IPConfig_To_v1beta2_IPConfig(&in.IPConfig, &out.IPConfig, s); err != nil {
+		return err
+	}
+	out.NameServer = in.NameServer
+	out.SearchDomain = in.SearchDomain
+	return nil
+}
+
+// Convert_v1beta1_Network_To_v1beta2_Network is an autogenerated conversion function.
+func Convert_v1beta1_Network_To_v1beta2_Network(in *Network, out *v1beta2.Network, s conversion.Scope) error {
+	return autoConvert_v1beta1_Network_To_v1beta2_Network(in, out, s)
+}
+
+func autoConvert_v1beta2_Network_To_v1beta1_Network(in *v1beta2.Network, out *Network, s conversion.Scope) error {
+	if err := Convert_v1beta2_IPConfig_To_v1beta1_IPConfig(&in.IPConfig, &out.IPConfig, s); err != nil {
+		return err
+	}
+	out.NameServer = in.NameServer
+	out.SearchDomain = in.SearchDomain
+	return nil
+}
+
+// Convert_v1beta2_Network_To_v1beta1_Network is an autogenerated conversion function.
+func Convert_v1beta2_Network_To_v1beta1_Network(in *v1beta2.Network, out *Network, s conversion.Scope) error {
+	return autoConvert_v1beta2_Network_To_v1beta1_Network(in, out, s)
+}
+
+func autoConvert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice(in *NetworkDevice, out *v1beta2.NetworkDevice, s conversion.Scope) error {
+	out.Model = v1beta2.NetworkDeviceModel(in.Model)
+	out.Bridge = v1beta2.NetworkDeviceBridge(in.Bridge)
+	out.Firewall = in.Firewall
+	out.LinkDown = in.LinkDown
+	out.MacAddr = in.MacAddr
+	out.MTU = in.MTU
+	out.Queues = in.Queues
+	out.Rate = in.Rate
+	out.Tag = in.Tag
+	out.Trunks = *(*[]int)(unsafe.Pointer(&in.Trunks))
+	return nil
+}
+
+// Convert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice is an autogenerated conversion function.
+func Convert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice(in *NetworkDevice, out *v1beta2.NetworkDevice, s conversion.Scope) error {
+	return autoConvert_v1beta1_NetworkDevice_To_v1beta2_NetworkDevice(in, out, s)
+}
+
+func autoConvert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice(in *v1beta2.NetworkDevice, out *NetworkDevice, s conversion.Scope) error {
+	out.Model = NetworkDeviceModel(in.Model)
+	out.Bridge = NetworkDeviceBridge(in.Bridge)
+	out.Firewall = in.Firewall
+	out.LinkDown = in.LinkDown
+	out.MacAddr = in.MacAddr
+	out.MTU = in.MTU
+	out.Queues = in.Queues
+	out.Rate = in.Rate
+	out.Tag = in.Tag
+	out.Trunks = *(*[]int)(unsafe.Pointer(&in.Trunks))
+	return nil
+}
+
+// Convert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice is an autogenerated conversion function.
+func Convert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice(in *v1beta2.NetworkDevice, out *NetworkDevice, s conversion.Scope) error {
+	return autoConvert_v1beta2_NetworkDevice_To_v1beta1_NetworkDevice(in, out, s)
+}
+
+func autoConvert_v1beta1_ObjectReference_To_v1beta2_ObjectReference(in *ObjectReference, out *v1beta2.ObjectReference, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1beta1_ObjectReference_To_v1beta2_ObjectReference is an autogenerated conversion function.
+func Convert_v1beta1_ObjectReference_To_v1beta2_ObjectReference(in *ObjectReference, out *v1beta2.ObjectReference, s conversion.Scope) error {
+	return autoConvert_v1beta1_ObjectReference_To_v1beta2_ObjectReference(in, out, s)
+}
+
+func autoConvert_v1beta2_ObjectReference_To_v1beta1_ObjectReference(in *v1beta2.ObjectReference, out *ObjectReference, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	return nil
+}
+
+// Convert_v1beta2_ObjectReference_To_v1beta1_ObjectReference is an autogenerated conversion function.
+func Convert_v1beta2_ObjectReference_To_v1beta1_ObjectReference(in *v1beta2.ObjectReference, out *ObjectReference, s conversion.Scope) error {
+	return autoConvert_v1beta2_ObjectReference_To_v1beta1_ObjectReference(in, out, s)
+}
+
+func autoConvert_v1beta1_Options_To_v1beta2_Options(in *Options, out *v1beta2.Options, s conversion.Scope) error {
+	out.ACPI = in.ACPI
+	out.Arch = v1beta2.Arch(in.Arch)
+	out.Balloon = in.Balloon
+	out.Description = in.Description
+	out.HugePages = (*v1beta2.HugePages)(unsafe.Pointer(in.HugePages))
+	out.KeepHugePages = in.KeepHugePages
+	out.KVM = in.KVM
+	out.LocalTime = in.LocalTime
+	// WARNING: in.Lock requires manual conversion: does not exist in peer-type
+	out.NUMA = in.NUMA
+	out.OnBoot = in.OnBoot
+	out.OSType = v1beta2.OSType(in.OSType)
+	out.Protection = in.Protection
+	out.Reboot = in.Reboot
+	out.Shares = in.Shares
+	out.Tablet = in.Tablet
+	out.Tags = *(*v1beta2.Tags)(unsafe.Pointer(&in.Tags))
+	out.TimeDriftFix = in.TimeDriftFix
+	out.Template = in.Template
+	out.VCPUs = in.VCPUs
+	out.VMGenerationID = in.VMGenerationID
+	return nil
+}
+
+func autoConvert_v1beta2_Options_To_v1beta1_Options(in *v1beta2.Options, out *Options, s conversion.Scope) error {
+	out.ACPI = in.ACPI
+	out.Arch = Arch(in.Arch)
+	out.Balloon = in.Balloon
+	out.Description = in.Description
+	out.HugePages = (*HugePages)(unsafe.Pointer(in.HugePages))
+	out.KeepHugePages = in.KeepHugePages
+	out.KVM = in.KVM
+	out.LocalTime = in.LocalTime
+	out.NUMA = in.NUMA
+	out.OnBoot = in.OnBoot
+	out.OSType = OSType(in.OSType)
+	out.Protection = in.Protection
+	out.Reboot = in.Reboot
+	out.Shares = in.Shares
+	out.Tablet = in.Tablet
+	out.Tags = *(*Tags)(unsafe.Pointer(&in.Tags))
+	out.TimeDriftFix = in.TimeDriftFix
+	out.Template = in.Template
+	out.VCPUs = in.VCPUs
+	out.VMGenerationID = in.VMGenerationID
+	return nil
+}
+
+// Convert_v1beta2_Options_To_v1beta1_Options is an autogenerated conversion function.
+func Convert_v1beta2_Options_To_v1beta1_Options(in *v1beta2.Options, out *Options, s conversion.Scope) error {
+	return autoConvert_v1beta2_Options_To_v1beta1_Options(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(in *ProxmoxCluster, out *v1beta2.ProxmoxCluster, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(in *ProxmoxCluster, out *v1beta2.ProxmoxCluster, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(in *v1beta2.ProxmoxCluster, out *ProxmoxCluster, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta2_ProxmoxClusterSpec_To_v1beta1_ProxmoxClusterSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_ProxmoxClusterStatus_To_v1beta1_ProxmoxClusterStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(in *v1beta2.ProxmoxCluster, out *ProxmoxCluster, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList(in *ProxmoxClusterList, out *v1beta2.ProxmoxClusterList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta2.ProxmoxCluster, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_ProxmoxCluster_To_v1beta2_ProxmoxCluster(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList(in *ProxmoxClusterList, out *v1beta2.ProxmoxClusterList, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxClusterList_To_v1beta2_ProxmoxClusterList(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList(in *v1beta2.ProxmoxClusterList, out *ProxmoxClusterList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProxmoxCluster, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta2_ProxmoxCluster_To_v1beta1_ProxmoxCluster(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList(in *v1beta2.ProxmoxClusterList, out *ProxmoxClusterList, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxClusterList_To_v1beta1_ProxmoxClusterList(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec(in *ProxmoxClusterSpec, out *v1beta2.ProxmoxClusterSpec, s conversion.Scope) error {
+	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if err := Convert_v1beta1_ServerRef_To_v1beta2_ServerRef(&in.ServerRef, &out.ServerRef, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_Storage_To_v1beta2_Storage(&in.Storage, &out.Storage, s); err != nil {
+		return err
+	}
+	out.Rebalance = (*v1beta2.RebalancePolicy)(unsafe.Pointer(in.Rebalance))
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec(in *ProxmoxClusterSpec, out *v1beta2.ProxmoxClusterSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxClusterSpec_To_v1beta2_ProxmoxClusterSpec(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxClusterSpec_To_v1beta1_ProxmoxClusterSpec(in *v1beta2.ProxmoxClusterSpec, out *ProxmoxClusterSpec, s conversion.Scope) error {
+	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if err := Convert_v1beta2_ServerRef_To_v1beta1_ServerRef(&in.ServerRef, &out.ServerRef, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_Storage_To_v1beta1_Storage(&in.Storage, &out.Storage, s); err != nil {
+		return err
+	}
+	out.Rebalance = (*RebalancePolicy)(unsafe.Pointer(in.Rebalance))
+	// WARNING: in.GarbageCollection requires manual conversion: does not exist in peer-type
+	// WARNING: in.ImageCache requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailureDomains requires manual conversion: does not exist in peer-type
+	// WARNING: in.SchedulerProfile requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus(in *ProxmoxClusterStatus, out *v1beta2.ProxmoxClusterStatus, s conversion.Scope) error {
+	out.Ready = in.Ready
+	out.FailureDomains = *(*apiv1beta1.FailureDomains)(unsafe.Pointer(&in.FailureDomains))
+	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
+	out.LastRebalanceTime = (*v1.Time)(unsafe.Pointer(in.LastRebalanceTime))
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus(in *ProxmoxClusterStatus, out *v1beta2.ProxmoxClusterStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxClusterStatus_To_v1beta2_ProxmoxClusterStatus(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxClusterStatus_To_v1beta1_ProxmoxClusterStatus(in *v1beta2.ProxmoxClusterStatus, out *ProxmoxClusterStatus, s conversion.Scope) error {
+	out.Ready = in.Ready
+	out.FailureDomains = *(*apiv1beta1.FailureDomains)(unsafe.Pointer(&in.FailureDomains))
+	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.ActiveEndpoint requires manual conversion: does not exist in peer-type
+	out.LastRebalanceTime = (*v1.Time)(unsafe.Pointer(in.LastRebalanceTime))
+	// WARNING: in.LastGarbageCollectionTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.OrphanedResources requires manual conversion: does not exist in peer-type
+	// WARNING: in.LastImageEvictionTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.ImportImagesLastUsed requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(in *ProxmoxMachine, out *v1beta2.ProxmoxMachine, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(in *ProxmoxMachine, out *v1beta2.ProxmoxMachine, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(in *v1beta2.ProxmoxMachine, out *ProxmoxMachine, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_ProxmoxMachineStatus_To_v1beta1_ProxmoxMachineStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(in *v1beta2.ProxmoxMachine, out *ProxmoxMachine, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList(in *ProxmoxMachineList, out *v1beta2.ProxmoxMachineList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta2.ProxmoxMachine, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_ProxmoxMachine_To_v1beta2_ProxmoxMachine(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList(in *ProxmoxMachineList, out *v1beta2.ProxmoxMachineList, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineList_To_v1beta2_ProxmoxMachineList(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList(in *v1beta2.ProxmoxMachineList, out *ProxmoxMachineList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProxmoxMachine, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta2_ProxmoxMachine_To_v1beta1_ProxmoxMachine(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList(in *v1beta2.ProxmoxMachineList, out *ProxmoxMachineList, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineList_To_v1beta1_ProxmoxMachineList(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(in *ProxmoxMachineSpec, out *v1beta2.ProxmoxMachineSpec, s conversion.Scope) error {
+	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
+	out.Node = in.Node
+	out.Storage = in.Storage
+	out.VMID = (*int)(unsafe.Pointer(in.VMID))
+	if err := Convert_v1beta1_Image_To_v1beta2_Image(&in.Image, &out.Image, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_CloudInit_To_v1beta2_CloudInit(&in.CloudInit, &out.CloudInit, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_Hardware_To_v1beta2_Hardware(&in.Hardware, &out.Hardware, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_Network_To_v1beta2_Network(&in.Network, &out.Network, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_Options_To_v1beta2_Options(&in.Options, &out.Options, s); err != nil {
+		return err
+	}
+	out.FailureDomain = (*string)(unsafe.Pointer(in.FailureDomain))
+	out.NodeSelector = *(*map[string]string)(unsafe.Pointer(&in.NodeSelector))
+	out.NodeAffinity = (*corev1.NodeAffinity)(unsafe.Pointer(in.NodeAffinity))
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(in *ProxmoxMachineSpec, out *v1beta2.ProxmoxMachineSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(in *v1beta2.ProxmoxMachineSpec, out *ProxmoxMachineSpec, s conversion.Scope) error {
+	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
+	out.Node = in.Node
+	out.Storage = in.Storage
+	out.VMID = (*int)(unsafe.Pointer(in.VMID))
+	if err := Convert_v1beta2_Image_To_v1beta1_Image(&in.Image, &out.Image, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_CloudInit_To_v1beta1_CloudInit(&in.CloudInit, &out.CloudInit, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_Hardware_To_v1beta1_Hardware(&in.Hardware, &out.Hardware, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_Network_To_v1beta1_Network(&in.Network, &out.Network, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_Options_To_v1beta1_Options(&in.Options, &out.Options, s); err != nil {
+		return err
+	}
+	out.FailureDomain = (*string)(unsafe.Pointer(in.FailureDomain))
+	out.NodeSelector = *(*map[string]string)(unsafe.Pointer(&in.NodeSelector))
+	out.NodeAffinity = (*corev1.NodeAffinity)(unsafe.Pointer(in.NodeAffinity))
+	// WARNING: in.SchedulerProfile requires manual conversion: does not exist in peer-type
+	// WARNING: in.ShutdownTimeout requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus(in *ProxmoxMachineStatus, out *v1beta2.ProxmoxMachineStatus, s conversion.Scope) error {
+	out.Ready = in.Ready
+	out.FailureReason = (*errors.MachineStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Addresses = *(*[]apiv1beta1.MachineAddress)(unsafe.Pointer(&in.Addresses))
+	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
+	out.Node = in.Node
+	out.Config = in.Config
+	out.InstanceStatus = (*v1beta2.InstanceStatus)(unsafe.Pointer(in.InstanceStatus))
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus(in *ProxmoxMachineStatus, out *v1beta2.ProxmoxMachineStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineStatus_To_v1beta2_ProxmoxMachineStatus(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineStatus_To_v1beta1_ProxmoxMachineStatus(in *v1beta2.ProxmoxMachineStatus, out *ProxmoxMachineStatus, s conversion.Scope) error {
+	out.Ready = in.Ready
+	out.FailureReason = (*errors.MachineStatusError)(unsafe.Pointer(in.FailureReason))
+	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
+	out.Addresses = *(*[]apiv1beta1.MachineAddress)(unsafe.Pointer(&in.Addresses))
+	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
+	out.Node = in.Node
+	out.Config = in.Config
+	out.InstanceStatus = (*InstanceStatus)(unsafe.Pointer(in.InstanceStatus))
+	// WARNING: in.ProvisioningPhase requires manual conversion: does not exist in peer-type
+	// WARNING: in.Task requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(in *ProxmoxMachineTemplate, out *v1beta2.ProxmoxMachineTemplate, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(in *ProxmoxMachineTemplate, out *v1beta2.ProxmoxMachineTemplate, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(in *v1beta2.ProxmoxMachineTemplate, out *ProxmoxMachineTemplate, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(in *v1beta2.ProxmoxMachineTemplate, out *ProxmoxMachineTemplate, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList(in *ProxmoxMachineTemplateList, out *v1beta2.ProxmoxMachineTemplateList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta2.ProxmoxMachineTemplate, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_ProxmoxMachineTemplate_To_v1beta2_ProxmoxMachineTemplate(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList(in *ProxmoxMachineTemplateList, out *v1beta2.ProxmoxMachineTemplateList, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineTemplateList_To_v1beta2_ProxmoxMachineTemplateList(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList(in *v1beta2.ProxmoxMachineTemplateList, out *ProxmoxMachineTemplateList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProxmoxMachineTemplate, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta2_ProxmoxMachineTemplate_To_v1beta1_ProxmoxMachineTemplate(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList(in *v1beta2.ProxmoxMachineTemplateList, out *ProxmoxMachineTemplateList, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineTemplateList_To_v1beta1_ProxmoxMachineTemplateList(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource(in *ProxmoxMachineTemplateResource, out *v1beta2.ProxmoxMachineTemplateResource, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_ProxmoxMachineSpec_To_v1beta2_ProxmoxMachineSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource(in *ProxmoxMachineTemplateResource, out *v1beta2.ProxmoxMachineTemplateResource, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource(in *v1beta2.ProxmoxMachineTemplateResource, out *ProxmoxMachineTemplateResource, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta2_ProxmoxMachineSpec_To_v1beta1_ProxmoxMachineSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource(in *v1beta2.ProxmoxMachineTemplateResource, out *ProxmoxMachineTemplateResource, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec(in *ProxmoxMachineTemplateSpec, out *v1beta2.ProxmoxMachineTemplateSpec, s conversion.Scope) error {
+	if err := Convert_v1beta1_ProxmoxMachineTemplateResource_To_v1beta2_ProxmoxMachineTemplateResource(&in.Template, &out.Template, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec(in *ProxmoxMachineTemplateSpec, out *v1beta2.ProxmoxMachineTemplateSpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineTemplateSpec_To_v1beta2_ProxmoxMachineTemplateSpec(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec(in *v1beta2.ProxmoxMachineTemplateSpec, out *ProxmoxMachineTemplateSpec, s conversion.Scope) error {
+	if err := Convert_v1beta2_ProxmoxMachineTemplateResource_To_v1beta1_ProxmoxMachineTemplateResource(&in.Template, &out.Template, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec(in *v1beta2.ProxmoxMachineTemplateSpec, out *ProxmoxMachineTemplateSpec, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineTemplateSpec_To_v1beta1_ProxmoxMachineTemplateSpec(in, out, s)
+}
+
+func autoConvert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus(in *ProxmoxMachineTemplateStatus, out *v1beta2.ProxmoxMachineTemplateStatus, s conversion.Scope) error {
+	return nil
+}
+
+// Convert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus is an autogenerated conversion function.
+func Convert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus(in *ProxmoxMachineTemplateStatus, out *v1beta2.ProxmoxMachineTemplateStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_ProxmoxMachineTemplateStatus_To_v1beta2_ProxmoxMachineTemplateStatus(in, out, s)
+}
+
+func autoConvert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus(in *v1beta2.ProxmoxMachineTemplateStatus, out *ProxmoxMachineTemplateStatus, s conversion.Scope) error {
+	return nil
+}
+
+// Convert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus is an autogenerated conversion function.
+func Convert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus(in *v1beta2.ProxmoxMachineTemplateStatus, out *ProxmoxMachineTemplateStatus, s conversion.Scope) error {
+	return autoConvert_v1beta2_ProxmoxMachineTemplateStatus_To_v1beta1_ProxmoxMachineTemplateStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_RebalancePolicy_To_v1beta2_RebalancePolicy(in *RebalancePolicy, out *v1beta2.RebalancePolicy, s conversion.Scope) error {
+	out.Interval = in.Interval
+	out.MaxUnavailable = in.MaxUnavailable
+	out.Threshold = in.Threshold
+	return nil
+}
+
+// Convert_v1beta1_RebalancePolicy_To_v1beta2_RebalancePolicy is an autogenerated conversion function.
+func Convert_v1beta1_RebalancePolicy_To_v1beta2_RebalancePolicy(in *RebalancePolicy, out *v1beta2.RebalancePolicy, s conversion.Scope) error {
+	return autoConvert_v1beta1_RebalancePolicy_To_v1beta2_RebalancePolicy(in, out, s)
+}
+
+func autoConvert_v1beta2_RebalancePolicy_To_v1beta1_RebalancePolicy(in *v1beta2.RebalancePolicy, out *RebalancePolicy, s conversion.Scope) error {
+	out.Interval = in.Interval
+	out.MaxUnavailable = in.MaxUnavailable
+	out.Threshold = in.Threshold
+	return nil
+}
+
+// Convert_v1beta2_RebalancePolicy_To_v1beta1_RebalancePolicy is an autogenerated conversion function.
+func Convert_v1beta2_RebalancePolicy_To_v1beta1_RebalancePolicy(in *v1beta2.RebalancePolicy, out *RebalancePolicy, s conversion.Scope) error {
+	return autoConvert_v1beta2_RebalancePolicy_To_v1beta1_RebalancePolicy(in, out, s)
+}
+
+func autoConvert_v1beta1_SSH_To_v1beta2_SSH(in *SSH, out *v1beta2.SSH, s conversion.Scope) error {
+	out.EmitKeysToConsole = in.EmitKeysToConsole
+	return nil
+}
+
+// Convert_v1beta1_SSH_To_v1beta2_SSH is an autogenerated conversion function.
+func Convert_v1beta1_SSH_To_v1beta2_SSH(in *SSH, out *v1beta2.SSH, s conversion.Scope) error {
+	return autoConvert_v1beta1_SSH_To_v1beta2_SSH(in, out, s)
+}
+
+func autoConvert_v1beta2_SSH_To_v1beta1_SSH(in *v1beta2.SSH, out *SSH, s conversion.Scope) error {
+	out.EmitKeysToConsole = in.EmitKeysToConsole
+	return nil
+}
+
+// Convert_v1beta2_SSH_To_v1beta1_SSH is an autogenerated conversion function.
+func Convert_v1beta2_SSH_To_v1beta1_SSH(in *v1beta2.SSH, out *SSH, s conversion.Scope) error {
+	return autoConvert_v1beta2_SSH_To_v1beta1_SSH(in, out, s)
+}
+
+func autoConvert_v1beta1_SSHKeys_To_v1beta2_SSHKeys(in *SSHKeys, out *v1beta2.SSHKeys, s conversion.Scope) error {
+	out.RSAPrivate = in.RSAPrivate
+	out.RSAPublic = in.RSAPublic
+	out.DSAPrivate = in.DSAPrivate
+	out.DSAPublic = in.DSAPublic
+	out.ECDSAPrivate = in.ECDSAPrivate
+	out.EDSCAPublic = in.EDSCAPublic
+	return nil
+}
+
+// Convert_v1beta1_SSHKeys_To_v1beta2_SSHKeys is an autogenerated conversion function.
+func Convert_v1beta1_SSHKeys_To_v1beta2_SSHKeys(in *SSHKeys, out *v1beta2.SSHKeys, s conversion.Scope) error {
+	return autoConvert_v1beta1_SSHKeys_To_v1beta2_SSHKeys(in, out, s)
+}
+
+func autoConvert_v1beta2_SSHKeys_To_v1beta1_SSHKeys(in *v1beta2.SSHKeys, out *SSHKeys, s conversion.Scope) error {
+	out.RSAPrivate = in.RSAPrivate
+	out.RSAPublic = in.RSAPublic
+	out.DSAPrivate = in.DSAPrivate
+	out.DSAPublic = in.DSAPublic
+	out.ECDSAPrivate = in.ECDSAPrivate
+	out.EDSCAPublic = in.EDSCAPublic
+	return nil
+}
+
+// Convert_v1beta2_SSHKeys_To_v1beta1_SSHKeys is an autogenerated conversion function.
+func Convert_v1beta2_SSHKeys_To_v1beta1_SSHKeys(in *v1beta2.SSHKeys, out *SSHKeys, s conversion.Scope) error {
+	return autoConvert_v1beta2_SSHKeys_To_v1beta1_SSHKeys(in, out, s)
+}
+
+func autoConvert_v1beta1_ServerRef_To_v1beta2_ServerRef(in *ServerRef, out *v1beta2.ServerRef, s conversion.Scope) error {
+	out.Endpoint = in.Endpoint
+	out.SecretRef = (*v1beta2.ObjectReference)(unsafe.Pointer(in.SecretRef))
+	return nil
+}
+
+// Convert_v1beta1_ServerRef_To_v1beta2_ServerRef is an autogenerated conversion function.
+func Convert_v1beta1_ServerRef_To_v1beta2_ServerRef(in *ServerRef, out *v1beta2.ServerRef, s conversion.Scope) error {
+	return autoConvert_v1beta1_ServerRef_To_v1beta2_ServerRef(in, out, s)
+}
+
+func autoConvert_v1beta2_ServerRef_To_v1beta1_ServerRef(in *v1beta2.ServerRef, out *ServerRef, s conversion.Scope) error {
+	out.Endpoint = in.Endpoint
+	// WARNING: in.FailoverEndpoints requires manual conversion: does not exist in peer-type
+	out.SecretRef = (*ObjectReference)(unsafe.Pointer(in.SecretRef))
+	// WARNING: in.IdentityRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.TLS requires manual conversion: does not exist in peer-type
+	return nil
+}
+
+func autoConvert_v1beta1_Storage_To_v1beta2_Storage(in *Storage, out *v1beta2.Storage, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_v1beta1_Storage_To_v1beta2_Storage is an autogenerated conversion function.
+func Convert_v1beta1_Storage_To_v1beta2_Storage(in *Storage, out *v1beta2.Storage, s conversion.Scope) error {
+	return autoConvert_v1beta1_Storage_To_v1beta2_Storage(in, out, s)
+}
+
+func autoConvert_v1beta2_Storage_To_v1beta1_Storage(in *v1beta2.Storage, out *Storage, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_v1beta2_Storage_To_v1beta1_Storage is an autogenerated conversion function.
+func Convert_v1beta2_Storage_To_v1beta1_Storage(in *v1beta2.Storage, out *Storage, s conversion.Scope) error {
+	return autoConvert_v1beta2_Storage_To_v1beta1_Storage(in, out, s)
+}
+
+func autoConvert_v1beta1_User_To_v1beta2_User(in *User, out *v1beta2.User, s conversion.Scope) error {
+	out.Name = in.Name
+	out.ExpireDate = in.ExpireDate
+	out.GECOS = in.GECOS
+	out.HomeDir = in.HomeDir
+	out.PrimaryGroup = in.PrimaryGroup
+	out.Groups = *(*[]string)(unsafe.Pointer(&in.Groups))
+	out.SELinuxUser = in.SELinuxUser
+	out.LockPasswd = (*bool)(unsafe.Pointer(in.LockPasswd))
+	out.Inactive = in.Inactive
+	out.Passwd = in.Passwd
+	out.NoCreateHome = in.NoCreateHome
+	out.NoUserGroup = in.NoUserGroup
+	out.NoLogInit = in.NoLogInit
+	out.SSHImportID = *(*[]string)(unsafe.Pointer(&in.SSHImportID))
+	out.SSHAuthorizedKeys = *(*[]string)(unsafe.Pointer(&in.SSHAuthorizedKeys))
+	out.SSHRedirectUser = in.SSHRedirectUser
+	out.Sudo = *(*[]string)(unsafe.Pointer(&in.Sudo))
+	out.System = in.System
+	out.SnapUser = in.SnapUser
+	out.Shell = in.Shell
+	return nil
+}
+
+// Convert_v1beta1_User_To_v1beta2_User is an autogenerated conversion function.
+func Convert_v1beta1_User_To_v1beta2_User(in *User, out *v1beta2.User, s conversion.Scope) error {
+	return autoConvert_v1beta1_User_To_v1beta2_User(in, out, s)
+}
+
+func autoConvert_v1beta2_User_To_v1beta1_User(in *v1beta2.User, out *User, s conversion.Scope) error {
+	out.Name = in.Name
+	out.ExpireDate = in.ExpireDate
+	out.GECOS = in.GECOS
+	out.HomeDir = in.HomeDir
+	out.PrimaryGroup = in.PrimaryGroup
+	out.Groups = *(*[]string)(unsafe.Pointer(&in.Groups))
+	out.SELinuxUser = in.SELinuxUser
+	out.LockPasswd = (*bool)(unsafe.Pointer(in.LockPasswd))
+	out.Inactive = in.Inactive
+	out.Passwd = in.Passwd
+	out.NoCreateHome = in.NoCreateHome
+	out.NoUserGroup = in.NoUserGroup
+	out.NoLogInit = in.NoLogInit
+	out.SSHImportID = *(*[]string)(unsafe.Pointer(&in.SSHImportID))
+	out.SSHAuthorizedKeys = *(*[]string)(unsafe.Pointer(&in.SSHAuthorizedKeys))
+	out.SSHRedirectUser = in.SSHRedirectUser
+	out.Sudo = *(*[]string)(unsafe.Pointer(&in.Sudo))
+	out.System = in.System
+	out.SnapUser = in.SnapUser
+	out.Shell = in.Shell
+	return nil
+}
+
+// Convert_v1beta2_User_To_v1beta1_User is an autogenerated conversion function.
+func Convert_v1beta2_User_To_v1beta1_User(in *v1beta2.User, out *User, s conversion.Scope) error {
+	return autoConvert_v1beta2_User_To_v1beta1_User(in, out, s)
+}
+
+func autoConvert_v1beta1_UserData_To_v1beta2_UserData(in *UserData, out *v1beta2.UserData, s conversion.Scope) error {
+	out.BootCmd = *(*[]string)(unsafe.Pointer(&in.BootCmd))
+	if err := Convert_v1beta1_CACert_To_v1beta2_CACert(&in.CACerts, &out.CACerts, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_ChPasswd_To_v1beta2_ChPasswd(&in.ChPasswd, &out.ChPasswd, s); err != nil {
+		return err
+	}
+	out.HostName = in.HostName
+	out.ManageEtcHosts = in.ManageEtcHosts
+	out.NoSSHFingerprints = in.NoSSHFingerprints
+	out.Packages = *(*[]string)(unsafe.Pointer(&in.Packages))
+	out.PackageUpdate = in.PackageUpdate
+	out.PackageUpgrade = in.PackageUpgrade
+	out.Password = in.Password
+	out.RunCmd = *(*[]string)(unsafe.Pointer(&in.RunCmd))
+	if err := Convert_v1beta1_SSH_To_v1beta2_SSH(&in.SSH, &out.SSH, s); err != nil {
+		return err
+	}
+	out.SSHAuthorizedKeys = *(*[]string)(unsafe.Pointer(&in.SSHAuthorizedKeys))
+	if err := Convert_v1beta1_SSHKeys_To_v1beta2_SSHKeys(&in.SSHKeys, &out.SSHKeys, s); err != nil {
+		return err
+	}
+	out.SSHPWAuth = in.SSHPWAuth
+	out.User = in.User
+	out.Users = *(*[]v1beta2.User)(unsafe.Pointer(&in.Users))
+	out.WriteFiles = *(*[]v1beta2.WriteFiles)(unsafe.Pointer(&in.WriteFiles))
+	return nil
+}
+
+// Convert_v1beta1_UserData_To_v1beta2_UserData is an autogenerated conversion function.
+func Convert_v1beta1_UserData_To_v1beta2_UserData(in *UserData, out *v1beta2.UserData, s conversion.Scope) error {
+	return autoConvert_v1beta1_UserData_To_v1beta2_UserData(in, out, s)
+}
+
+func autoConvert_v1beta2_UserData_To_v1beta1_UserData(in *v1beta2.UserData, out *UserData, s conversion.Scope) error {
+	out.BootCmd = *(*[]string)(unsafe.Pointer(&in.BootCmd))
+	if err := Convert_v1beta2_CACert_To_v1beta1_CACert(&in.CACerts, &out.CACerts, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta2_ChPasswd_To_v1beta1_ChPasswd(&in.ChPasswd, &out.ChPasswd, s); err != nil {
+		return err
+	}
+	out.HostName = in.HostName
+	out.ManageEtcHosts = in.ManageEtcHosts
+	out.NoSSHFingerprints = in.NoSSHFingerprints
+	out.Packages = *(*[]string)(unsafe.Pointer(&in.Packages))
+	out.PackageUpdate = in.PackageUpdate
+	out.PackageUpgrade = in.PackageUpgrade
+	out.Password = in.Password
+	out.RunCmd = *(*[]string)(unsafe.Pointer(&in.RunCmd))
+	if err := Convert_v1beta2_SSH_To_v1beta1_SSH(&in.SSH, &out.SSH, s); err != nil {
+		return err
+	}
+	out.SSHAuthorizedKeys = *(*[]string)(unsafe.Pointer(&in.SSHAuthorizedKeys))
+	if err := Convert_v1beta2_SSHKeys_To_v1beta1_SSHKeys(&in.SSHKeys, &out.SSHKeys, s); err != nil {
+		return err
+	}
+	out.SSHPWAuth = in.SSHPWAuth
+	out.User = in.User
+	out.Users = *(*[]User)(unsafe.Pointer(&in.Users))
+	out.WriteFiles = *(*[]WriteFiles)(unsafe.Pointer(&in.WriteFiles))
+	return nil
+}
+
+// Convert_v1beta2_UserData_To_v1beta1_UserData is an autogenerated conversion function.
+func Convert_v1beta2_UserData_To_v1beta1_UserData(in *v1beta2.UserData, out *UserData, s conversion.Scope) error {
+	return autoConvert_v1beta2_UserData_To_v1beta1_UserData(in, out, s)
+}
+
+func autoConvert_v1beta1_WriteFiles_To_v1beta2_WriteFiles(in *WriteFiles, out *v1beta2.WriteFiles, s conversion.Scope) error {
+	out.Encoding = in.Encoding
+	out.Path = in.Path
+	out.Owner = in.Owner
+	out.Permissions = in.Permissions
+	out.Defer = in.Defer
+	out.Content = in.Content
+	return nil
+}
+
+// Convert_v1beta1_WriteFiles_To_v1beta2_WriteFiles is an autogenerated conversion function.
+func Convert_v1beta1_WriteFiles_To_v1beta2_WriteFiles(in *WriteFiles, out *v1beta2.WriteFiles, s conversion.Scope) error {
+	return autoConvert_v1beta1_WriteFiles_To_v1beta2_WriteFiles(in, out, s)
+}
+
+func autoConvert_v1beta2_WriteFiles_To_v1beta1_WriteFiles(in *v1beta2.WriteFiles, out *WriteFiles, s conversion.Scope) error {
+	out.Encoding = in.Encoding
+	out.Path = in.Path
+	out.Owner = in.Owner
+	out.Permissions = in.Permissions
+	out.Defer = in.Defer
+	out.Content = in.Content
+	return nil
+}
+
+// Convert_v1beta2_WriteFiles_To_v1beta1_WriteFiles is an autogenerated conversion function.
+func Convert_v1beta2_WriteFiles_To_v1beta1_WriteFiles(in *v1beta2.WriteFiles, out *WriteFiles, s conversion.Scope) error {
+	return autoConvert_v1beta2_WriteFiles_To_v1beta1_WriteFiles(in, out, s)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+const (
+	// MigratedCondition reports on the live migration of the vm to spec.node
+	MigratedCondition clusterv1.ConditionType = "Migrated"
+
+	// MigratingReason (Severity=Info) documents a vm being migrated to another node.
+	// The condition's message holds the UPID of the migration task
+	MigratingReason = "Migrating"
+
+	// MigrationFailedReason (Severity=Warning) documents a vm which failed to be migrated
+	MigrationFailedReason = "MigrationFailed"
+
+	// ConfigSyncedCondition reports on whether the vm config matches the spec.
+	// Mutable parameters are updated in place. Others are only reported
+	ConfigSyncedCondition clusterv1.ConditionType = "ConfigSynced"
+
+	// ImmutableConfigDriftedReason (Severity=Warning) documents a vm whose config differs from the spec
+	// in parameters which cannot be updated in place. The machine needs to be recreated to apply them
+	ImmutableConfigDriftedReason = "ImmutableConfigDrifted"
+)
+
+// ProxmoxMachine conditions composing the Ready summary condition
+const (
+	// ScheduledCondition reports on whether a proxmox node and vmid are assigned to the vm by qemu-scheduler
+	ScheduledCondition clusterv1.ConditionType = "Scheduled"
+
+	// SchedulingFailedReason (Severity=Warning) documents a vm which qemu-scheduler failed to find a node for
+	SchedulingFailedReason = "SchedulingFailed"
+
+	// ImageReadyCondition reports on whether the os image is downloaded to the proxmox node
+	ImageReadyCondition clusterv1.ConditionType = "ImageReady"
+
+	// ImageDownloadFailedReason (Severity=Warning) documents a failure in downloading
+	// or validating the checksum of the os image
+	ImageDownloadFailedReason = "ImageDownloadFailed"
+
+	// CloudInitReadyCondition reports on whether the cloud-init snippet is uploaded to the proxmox node
+	CloudInitReadyCondition clusterv1.ConditionType = "CloudInitReady"
+
+	// CloudInitFailedReason (Severity=Warning) documents a failure in generating or uploading cloud-init snippet
+	CloudInitFailedReason = "CloudInitFailed"
+
+	// InstanceProvisionedCondition reports on whether the vm is created and running
+	InstanceProvisionedCondition clusterv1.ConditionType = "InstanceProvisioned"
+
+	// WaitingForBootstrapDataReason (Severity=Info) documents a vm waiting for the bootstrap
+	// data secret of the Machine before being created
+	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
+
+	// InstanceProvisioningFailedReason (Severity=Warning) documents a failure in creating or starting the vm
+	InstanceProvisioningFailedReason = "InstanceProvisioningFailed"
+
+	// InstanceStoppedReason (Severity=Warning) documents a vm which is stopped
+	InstanceStoppedReason = "InstanceStopped"
+
+	// InstancePausedReason (Severity=Warning) documents a vm which is paused
+	InstancePausedReason = "InstancePaused"
+
+	// ShuttingDownReason (Severity=Info) documents a vm being shut down gracefully before deletion.
+	// The condition's message holds the UPID of the shutdown task
+	ShuttingDownReason = "ShuttingDown"
+
+	// WaitingForTaskReason (Severity=Info) documents a vm waiting for a proxmox task
+	// e.g. creating, resizing or starting the vm. The condition's message holds the operation and UPID of the task
+	WaitingForTaskReason = "WaitingForTask"
+)
+
+// ProxmoxCluster conditions composing the Ready summary condition
+const (
+	// ProxmoxReachableCondition reports on whether proxmox api is reachable with the given credentials
+	ProxmoxReachableCondition clusterv1.ConditionType = "ProxmoxReachable"
+
+	// ProxmoxClientFailedReason (Severity=Error) documents a failure in creating proxmox client
+	// e.g. missing secret or invalid credentials
+	ProxmoxClientFailedReason = "ProxmoxClientFailed"
+
+	// ProxmoxUnreachableReason (Severity=Error) documents a failure in requesting proxmox api
+	ProxmoxUnreachableReason = "ProxmoxUnreachable"
+
+	// ProxmoxAuthenticationFailedReason (Severity=Error) documents that proxmox api rejected the credentials
+	// e.g. wrong password, revoked API token or expired ticket. the client is recreated on next reconciliation
+	ProxmoxAuthenticationFailedReason = "ProxmoxAuthenticationFailed"
+
+	// StorageReadyCondition reports on whether the storage for cloud-init snippets exists
+	StorageReadyCondition clusterv1.ConditionType = "StorageReady"
+
+	// StorageReconcileFailedReason (Severity=Warning) documents a failure in getting or creating the storage
+	StorageReconcileFailedReason = "StorageReconcileFailed"
+
+	// FailureDomainsReadyCondition reports on whether the Proxmox-VE clusters of the failure domains
+	// are reachable and have the storage for cloud-init snippets
+	FailureDomainsReadyCondition clusterv1.ConditionType = "FailureDomainsReady"
+
+	// FailureDomainUnavailableReason (Severity=Warning) documents that some failure domains are not available.
+	// the condition's message holds the failure domains and errors
+	FailureDomainUnavailableReason = "FailureDomainUnavailable"
+
+	// CertificateVerifiedCondition reports on whether the certificates of the proxmox endpoints
+	// of spec.serverRef and the failure domains are verified. it is not summarized into Ready
+	CertificateVerifiedCondition clusterv1.ConditionType = "CertificateVerified"
+
+	// CertificateVerificationDisabledReason (Severity=Warning) documents that some certificates are not verified,
+	// e.g. neither serverRef.tls nor PROXMOX_CA_CERT is given. the condition's message holds the reasons
+	CertificateVerificationDisabledReason = "CertificateVerificationDisabled"
+)
+
+var (
+	// MachineReadyConditions are summarized into the Ready condition of ProxmoxMachine
+	MachineReadyConditions = []clusterv1.ConditionType{
+		ScheduledCondition,
+		ImageReadyCondition,
+		CloudInitReadyCondition,
+		InstanceProvisionedCondition,
+	}
+
+	// ClusterReadyConditions are summarized into the Ready condition of ProxmoxCluster
+	ClusterReadyConditions = []clusterv1.ConditionType{
+		ProxmoxReachableCondition,
+		StorageReadyCondition,
+		FailureDomainsReadyCondition,
+	}
+)
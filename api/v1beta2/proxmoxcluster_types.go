@@ -0,0 +1,308 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// ClusterFinalizer
+	ClusterFinalizer = "proxmoxcluster.infrastructure.cluster.x-k8s.io"
+)
+
+// ProxmoxClusterSpec defines the desired state of ProxmoxCluster
+type ProxmoxClusterSpec struct {
+	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// +optional
+	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// ServerRef is used for configuring Proxmox client
+	ServerRef ServerRef `json:"serverRef"`
+
+	// storage is used for storing cloud init snippet
+	Storage Storage `json:"storage,omitempty"`
+
+	// Rebalance configures periodic rebalancing of ProxmoxMachines across proxmox nodes.
+	// Rebalancing is disabled if empty.
+	// +optional
+	Rebalance *RebalancePolicy `json:"rebalance,omitempty"`
+
+	// GarbageCollection configures periodic cleanup of qemus and cloud-init snippets
+	// left on proxmox after their ProxmoxMachines are gone.
+	// Garbage collection is disabled if empty.
+	// +optional
+	GarbageCollection *GarbageCollectionPolicy `json:"garbageCollection,omitempty"`
+
+	// ImageCache configures eviction of OS images cached on proxmox nodes.
+	// Cached images are never evicted if empty.
+	// +optional
+	ImageCache *ImageCachePolicy `json:"imageCache,omitempty"`
+
+	// FailureDomains of the cluster e.g. sites which have separate Proxmox-VE clusters.
+	// Cluster API spreads machines across them, and each machine is created
+	// on the Proxmox-VE cluster of its failure domain
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	FailureDomains []FailureDomain `json:"failureDomains,omitempty"`
+
+	// SchedulerProfile is the name of qemu-scheduler profile in the plugin config
+	// used for scheduling ProxmoxMachines of the cluster.
+	// The default plugin config is used if empty
+	// +optional
+	SchedulerProfile string `json:"schedulerProfile,omitempty"`
+}
+
+// FailureDomain is a failure domain of the cluster
+type FailureDomain struct {
+	// Name of the failure domain which Machine.spec.failureDomain refers to
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ControlPlane determines if the failure domain is suitable for control plane machines
+	// +optional
+	ControlPlane bool `json:"controlPlane,omitempty"`
+
+	// ServerRef is used for configuring Proxmox client of the Proxmox-VE cluster of the failure domain.
+	// spec.serverRef is used if empty
+	// +optional
+	ServerRef *ServerRef `json:"serverRef,omitempty"`
+}
+
+// GetFailureDomain returns the failure domain of the name or nil if it is not defined
+func (c *ProxmoxCluster) GetFailureDomain(name string) *FailureDomain {
+	for i := range c.Spec.FailureDomains {
+		if c.Spec.FailureDomains[i].Name == name {
+			return &c.Spec.FailureDomains[i]
+		}
+	}
+	return nil
+}
+
+// RebalancePolicy configures how ProxmoxMachines are moved
+// from heavily loaded proxmox nodes to less loaded ones
+type RebalancePolicy struct {
+	// Interval is the period of evaluating node load
+	// +kubebuilder:default:="10m"
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// MaxUnavailable is the maximum number of ProxmoxMachines which
+	// can be migrated or remediated at the same time
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=1
+	// +optional
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+
+	// Threshold is the minimum score improvement (in percent) of the best node
+	// against the current node required to move a ProxmoxMachine
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=20
+	// +optional
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// GarbageCollectionMode is what garbage collector does with orphaned resources
+// +kubebuilder:validation:Enum:=Report;Delete
+type GarbageCollectionMode string
+
+const (
+	// GarbageCollectionModeReport only reports orphaned resources with events and status
+	GarbageCollectionModeReport = GarbageCollectionMode("Report")
+
+	// GarbageCollectionModeDelete reports and deletes orphaned resources
+	GarbageCollectionModeDelete = GarbageCollectionMode("Delete")
+)
+
+// GarbageCollectionPolicy configures how orphaned qemus and cloud-init snippets
+// of the ProxmoxCluster are collected
+type GarbageCollectionPolicy struct {
+	// Interval is the period of looking for orphaned resources
+	// +kubebuilder:default:="1h"
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Mode is either Report or Delete
+	// +kubebuilder:default:=Report
+	// +optional
+	Mode GarbageCollectionMode `json:"mode,omitempty"`
+}
+
+// ImageCachePolicy configures how OS images downloaded into proxmox nodes are evicted.
+// Images referenced by ProxmoxMachines or ProxmoxMachineTemplates are never evicted
+type ImageCachePolicy struct {
+	// Interval is the period of evicting images
+	// +kubebuilder:default:="1h"
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// MaxImages is the number of most recently used images kept on each node
+	// in addition to the referenced ones
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=3
+	// +optional
+	MaxImages *int32 `json:"maxImages,omitempty"`
+
+	// MaxSize is the total size of images kept on each node.
+	// Least recently used images which are not referenced are evicted
+	// until the images fit in it. Unlimited if empty
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// Nodes overrides the limits for specific proxmox nodes
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Nodes []NodeImageCacheLimits `json:"nodes,omitempty"`
+}
+
+// NodeImageCacheLimits overrides image cache limits for a proxmox node
+type NodeImageCacheLimits struct {
+	// Name of the proxmox node
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// MaxImages of the node. spec.imageCache.maxImages is used if empty
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MaxImages *int32 `json:"maxImages,omitempty"`
+
+	// MaxSize of the node. spec.imageCache.maxSize is used if empty
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+}
+
+// OrphanedResourceKind is a kind of resource left on proxmox
+type OrphanedResourceKind string
+
+const (
+	OrphanedResourceKindQEMU    = OrphanedResourceKind("qemu")
+	OrphanedResourceKindSnippet = OrphanedResourceKind("snippet")
+)
+
+// OrphanedResource is a qemu or cloud-init snippet on proxmox
+// whose ProxmoxMachine no longer exists
+type OrphanedResource struct {
+	// Kind is either qemu or snippet
+	Kind OrphanedResourceKind `json:"kind"`
+
+	// Node is the proxmox node having the resource
+	Node string `json:"node"`
+
+	// Name is the qemu name or the ProxmoxMachine name of the snippet
+	Name string `json:"name"`
+
+	// VMID of the qemu
+	// +optional
+	VMID *int `json:"vmid,omitempty"`
+
+	// VolumeID of the snippet
+	// +optional
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// FailureDomain whose Proxmox-VE cluster has the resource.
+	// empty for the Proxmox-VE cluster of spec.serverRef
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// Task is the proxmox task in flight stopping or deleting the qemu in Delete mode
+	// +optional
+	Task *ProxmoxTask `json:"task,omitempty"`
+}
+
+// ProxmoxClusterStatus defines the observed state of ProxmoxCluster
+type ProxmoxClusterStatus struct {
+	// Ready
+	Ready bool `json:"ready"`
+
+	// FailureDomains
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+
+	// Conditions
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// ActiveEndpoint is the endpoint of serverRef which is currently used
+	// +optional
+	ActiveEndpoint string `json:"activeEndpoint,omitempty"`
+
+	// LastRebalanceTime is the last time rebalancing was evaluated
+	// +optional
+	LastRebalanceTime *metav1.Time `json:"lastRebalanceTime,omitempty"`
+
+	// LastGarbageCollectionTime is the last time orphaned resources were looked for
+	// +optional
+	LastGarbageCollectionTime *metav1.Time `json:"lastGarbageCollectionTime,omitempty"`
+
+	// OrphanedResources found by the last garbage collection and not deleted yet
+	// +optional
+	OrphanedResources []OrphanedResource `json:"orphanedResources,omitempty"`
+
+	// LastImageEvictionTime is the last time cached images were evicted
+	// +optional
+	LastImageEvictionTime *metav1.Time `json:"lastImageEvictionTime,omitempty"`
+
+	// ImportImagesLastUsed is the last time each OS image in import content,
+	// keyed by its volume id, was found referenced on image eviction.
+	// unlike images downloaded via node shell, the time of import content is never updated
+	// +optional
+	ImportImagesLastUsed map[string]metav1.Time `json:"importImagesLastUsed,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Cluster infrastructure is ready for ProxmoxMachine"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason",priority=1
+// +kubebuilder:printcolumn:name="Proxmox-Server",type="string",JSONPath=".spec.serverRef.endpoint",description="Server is the address of the Proxmox API endpoint."
+// +kubebuilder:printcolumn:name="Active-Endpoint",type="string",JSONPath=".status.activeEndpoint",description="Endpoint of the Proxmox API currently used",priority=1
+// +kubebuilder:printcolumn:name="ControlPlane",type="string",JSONPath=".spec.controlPlaneEndpoint.host",description="kube-apiserver Endpoint"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Machine"
+
+// ProxmoxCluster is the Schema for the proxmoxclusters API
+type ProxmoxCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProxmoxClusterSpec   `json:"spec,omitempty"`
+	Status ProxmoxClusterStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (c *ProxmoxCluster) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (c *ProxmoxCluster) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxClusterList contains a list of ProxmoxCluster
+type ProxmoxClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProxmoxCluster{}, &ProxmoxClusterList{})
+}
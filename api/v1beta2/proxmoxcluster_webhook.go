@@ -0,0 +1,255 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (r *ProxmoxCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w := &proxmoxClusterWebhook{}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxcluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=create;update,versions=v1beta2,name=default.proxmoxcluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxcluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=create;update,versions=v1beta2,name=validation.proxmoxcluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+type proxmoxClusterWebhook struct{}
+
+var (
+	_ webhook.CustomDefaulter = &proxmoxClusterWebhook{}
+	_ webhook.CustomValidator = &proxmoxClusterWebhook{}
+)
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (*proxmoxClusterWebhook) Default(_ context.Context, obj runtime.Object) error {
+	c, ok := obj.(*ProxmoxCluster)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got a %T", obj))
+	}
+	defaultServerRef(&c.Spec.ServerRef, c.Namespace)
+	for _, fd := range c.Spec.FailureDomains {
+		if fd.ServerRef != nil {
+			defaultServerRef(fd.ServerRef, c.Namespace)
+		}
+	}
+	if policy := c.Spec.Rebalance; policy != nil {
+		if policy.Interval.Duration == 0 {
+			policy.Interval = metav1.Duration{Duration: 10 * time.Minute}
+		}
+		if policy.MaxUnavailable == 0 {
+			policy.MaxUnavailable = 1
+		}
+	}
+	if policy := c.Spec.GarbageCollection; policy != nil {
+		if policy.Interval.Duration == 0 {
+			policy.Interval = metav1.Duration{Duration: time.Hour}
+		}
+		if policy.Mode == "" {
+			policy.Mode = GarbageCollectionModeReport
+		}
+	}
+	if policy := c.Spec.ImageCache; policy != nil {
+		if policy.Interval.Duration == 0 {
+			policy.Interval = metav1.Duration{Duration: time.Hour}
+		}
+		if policy.MaxImages == nil {
+			policy.MaxImages = ptr.To[int32](3)
+		}
+	}
+	return nil
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxClusterWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	c, ok := obj.(*ProxmoxCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got a %T", obj))
+	}
+	return nil, c.validate(nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxClusterWebhook) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	c, ok := newObj.(*ProxmoxCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got a %T", newObj))
+	}
+	old, ok := oldObj.(*ProxmoxCluster)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got a %T", oldObj))
+	}
+	// do not block removing finalizer
+	if !c.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	return nil, c.validate(old)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxClusterWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *ProxmoxCluster) validate(old *ProxmoxCluster) error {
+	specPath := field.NewPath("spec")
+	allErrs := validateServerRef(&r.Spec.ServerRef, specPath.Child("serverRef"))
+	names := map[string]bool{}
+	for i, fd := range r.Spec.FailureDomains {
+		fdPath := specPath.Child("failureDomains").Index(i)
+		if fd.Name == "" {
+			allErrs = append(allErrs, field.Required(fdPath.Child("name"), "must be specified"))
+		} else if names[fd.Name] {
+			allErrs = append(allErrs, field.Duplicate(fdPath.Child("name"), fd.Name))
+		}
+		names[fd.Name] = true
+		if fd.ServerRef != nil {
+			allErrs = append(allErrs, validateServerRef(fd.ServerRef, fdPath.Child("serverRef"))...)
+		}
+	}
+
+	endpoint := r.Spec.ControlPlaneEndpoint
+	if endpoint.Host != "" && (endpoint.Port < 1 || endpoint.Port > 65535) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("controlPlaneEndpoint", "port"), endpoint.Port, "must be in the range 1-65535"))
+	}
+	if old != nil && old.Spec.ControlPlaneEndpoint.IsValid() && old.Spec.ControlPlaneEndpoint != endpoint {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("controlPlaneEndpoint"), "cannot be changed once set"))
+	}
+
+	if policy := r.Spec.Rebalance; policy != nil {
+		rebalancePath := specPath.Child("rebalance")
+		if policy.Interval.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(rebalancePath.Child("interval"), policy.Interval.Duration.String(), "must not be negative"))
+		}
+		if policy.MaxUnavailable < 1 {
+			allErrs = append(allErrs, field.Invalid(rebalancePath.Child("maxUnavailable"), policy.MaxUnavailable, "must be greater than 0"))
+		}
+		if policy.Threshold < 0 {
+			allErrs = append(allErrs, field.Invalid(rebalancePath.Child("threshold"), policy.Threshold, "must not be negative"))
+		}
+	}
+
+	if policy := r.Spec.GarbageCollection; policy != nil {
+		gcPath := specPath.Child("garbageCollection")
+		if policy.Interval.Duration < time.Minute {
+			allErrs = append(allErrs, field.Invalid(gcPath.Child("interval"), policy.Interval.Duration.String(), "must be at least 1m"))
+		}
+		if policy.Mode != GarbageCollectionModeReport && policy.Mode != GarbageCollectionModeDelete {
+			allErrs = append(allErrs, field.NotSupported(gcPath.Child("mode"), policy.Mode, []GarbageCollectionMode{GarbageCollectionModeReport, GarbageCollectionModeDelete}))
+		}
+	}
+
+	if policy := r.Spec.ImageCache; policy != nil {
+		cachePath := specPath.Child("imageCache")
+		if policy.Interval.Duration < time.Minute {
+			allErrs = append(allErrs, field.Invalid(cachePath.Child("interval"), policy.Interval.Duration.String(), "must be at least 1m"))
+		}
+		allErrs = append(allErrs, validateImageCacheLimits(cachePath, policy.MaxImages, policy.MaxSize)...)
+		for i, node := range policy.Nodes {
+			allErrs = append(allErrs, validateImageCacheLimits(cachePath.Child("nodes").Index(i), node.MaxImages, node.MaxSize)...)
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ProxmoxCluster").GroupKind(), r.Name, allErrs)
+}
+
+func validateImageCacheLimits(path *field.Path, maxImages *int32, maxSize *resource.Quantity) field.ErrorList {
+	var allErrs field.ErrorList
+	if maxImages != nil && *maxImages < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxImages"), *maxImages, "must not be negative"))
+	}
+	if maxSize != nil && maxSize.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxSize"), maxSize.String(), "must be greater than 0"))
+	}
+	return allErrs
+}
+
+// secret and configmap are looked up in the same namespace by default
+func defaultServerRef(ref *ServerRef, namespace string) {
+	if ref.SecretRef != nil && ref.SecretRef.Namespace == "" {
+		ref.SecretRef.Namespace = namespace
+	}
+	if tls := ref.TLS; tls != nil && tls.CAConfigMapRef != nil {
+		if tls.CAConfigMapRef.Namespace == "" {
+			tls.CAConfigMapRef.Namespace = namespace
+		}
+		if tls.CAConfigMapRef.Key == "" {
+			tls.CAConfigMapRef.Key = "ca.crt"
+		}
+	}
+}
+
+func validateServerRef(ref *ServerRef, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !isEndpointURL(ref.Endpoint) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint"), ref.Endpoint, "must be an http(s) URL e.g. https://X.X.X.X:8006/api2/json"))
+	}
+	seen := map[string]bool{ref.Endpoint: true}
+	for i, endpoint := range ref.FailoverEndpoints {
+		if !isEndpointURL(endpoint) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("failoverEndpoints").Index(i), endpoint, "must be an http(s) URL e.g. https://X.X.X.X:8006/api2/json"))
+		} else if seen[endpoint] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("failoverEndpoints").Index(i), endpoint))
+		}
+		seen[endpoint] = true
+	}
+	switch {
+	case ref.SecretRef == nil && ref.IdentityRef == nil:
+		allErrs = append(allErrs, field.Required(fldPath.Child("secretRef"), "either secretRef or identityRef must be specified"))
+	case ref.SecretRef != nil && ref.IdentityRef != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("identityRef"), "cannot be set with secretRef"))
+	case ref.SecretRef != nil && ref.SecretRef.Name == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("secretRef", "name"), "must be specified"))
+	case ref.IdentityRef != nil && ref.IdentityRef.Name == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("identityRef", "name"), "must be specified"))
+	}
+	if tls := ref.TLS; tls != nil {
+		tlsPath := fldPath.Child("tls")
+		if tls.InsecureSkipVerify && (tls.CAConfigMapRef != nil || len(tls.Fingerprints) > 0) {
+			allErrs = append(allErrs, field.Forbidden(tlsPath.Child("insecureSkipVerify"), "cannot be set with caConfigMapRef or fingerprints"))
+		}
+		if tls.CAConfigMapRef != nil && tls.CAConfigMapRef.Name == "" {
+			allErrs = append(allErrs, field.Required(tlsPath.Child("caConfigMapRef", "name"), "must be specified"))
+		}
+	}
+	return allErrs
+}
+
+func isEndpointURL(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("ProxmoxCluster validation", Label("unit", "webhook"), func() {
+	ctx := context.Background()
+	validator := infrav1.NewProxmoxClusterWebhook()
+
+	newCluster := func() *infrav1.ProxmoxCluster {
+		c := &infrav1.ProxmoxCluster{}
+		c.Name = "foo"
+		c.Namespace = "default"
+		c.Spec.ServerRef = infrav1.ServerRef{
+			Endpoint:  "https://192.168.0.10:8006/api2/json",
+			SecretRef: &infrav1.ObjectReference{Name: "foo"},
+		}
+		return c
+	}
+
+	It("should default secret namespace and rebalance policy", func() {
+		c := newCluster()
+		c.Spec.Rebalance = &infrav1.RebalancePolicy{}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		Expect(c.Spec.ServerRef.SecretRef.Namespace).To(Equal("default"))
+		Expect(c.Spec.Rebalance.MaxUnavailable).To(Equal(1))
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should default garbage collection policy", func() {
+		c := newCluster()
+		c.Spec.GarbageCollection = &infrav1.GarbageCollectionPolicy{}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		Expect(c.Spec.GarbageCollection.Interval.Duration).To(Equal(time.Hour))
+		Expect(c.Spec.GarbageCollection.Mode).To(Equal(infrav1.GarbageCollectionModeReport))
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject too short garbage collection interval", func() {
+		c := newCluster()
+		c.Spec.GarbageCollection = &infrav1.GarbageCollectionPolicy{
+			Interval: metav1.Duration{Duration: time.Second},
+			Mode:     infrav1.GarbageCollectionModeDelete,
+		}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should default image cache policy", func() {
+		c := newCluster()
+		c.Spec.ImageCache = &infrav1.ImageCachePolicy{}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		Expect(c.Spec.ImageCache.Interval.Duration).To(Equal(time.Hour))
+		Expect(c.Spec.ImageCache.MaxImages).To(Equal(ptr.To[int32](3)))
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject invalid image cache limits", func() {
+		c := newCluster()
+		c.Spec.ImageCache = &infrav1.ImageCachePolicy{
+			Interval: metav1.Duration{Duration: time.Hour},
+			Nodes: []infrav1.NodeImageCacheLimits{
+				{Name: "node1", MaxSize: ptr.To(resource.MustParse("10Gi"))},
+			},
+		}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+
+		c.Spec.ImageCache.Nodes[0].MaxImages = ptr.To[int32](-1)
+		_, err = validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+
+		c.Spec.ImageCache.Nodes[0].MaxImages = nil
+		c.Spec.ImageCache.MaxSize = ptr.To(resource.MustParse("0"))
+		_, err = validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject invalid endpoint", func() {
+		c := newCluster()
+		c.Spec.ServerRef.Endpoint = "192.168.0.10:8006"
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject invalid or duplicate failover endpoints", func() {
+		c := newCluster()
+		c.Spec.ServerRef.FailoverEndpoints = []string{"https://192.168.0.11:8006/api2/json"}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+
+		c.Spec.ServerRef.FailoverEndpoints = []string{"192.168.0.11:8006"}
+		_, err = validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+
+		c.Spec.ServerRef.FailoverEndpoints = []string{c.Spec.ServerRef.Endpoint}
+		_, err = validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject missing secretRef", func() {
+		c := newCluster()
+		c.Spec.ServerRef.SecretRef = nil
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should accept identityRef instead of secretRef", func() {
+		c := newCluster()
+		c.Spec.ServerRef.SecretRef = nil
+		c.Spec.ServerRef.IdentityRef = &infrav1.ProxmoxClusterIdentityReference{Name: "pve"}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject both secretRef and identityRef", func() {
+		c := newCluster()
+		c.Spec.ServerRef.IdentityRef = &infrav1.ProxmoxClusterIdentityReference{Name: "pve"}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should default CA configmap reference", func() {
+		c := newCluster()
+		c.Spec.ServerRef.TLS = &infrav1.ServerTLSConfig{
+			CAConfigMapRef: &infrav1.ConfigMapKeyReference{ObjectReference: infrav1.ObjectReference{Name: "pve-ca"}},
+		}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		Expect(c.Spec.ServerRef.TLS.CAConfigMapRef.Namespace).To(Equal("default"))
+		Expect(c.Spec.ServerRef.TLS.CAConfigMapRef.Key).To(Equal("ca.crt"))
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject insecureSkipVerify with pinned fingerprints", func() {
+		c := newCluster()
+		c.Spec.ServerRef.TLS = &infrav1.ServerTLSConfig{
+			InsecureSkipVerify: true,
+			Fingerprints:       []infrav1.CertificateFingerprint{"E3:B0:C4:42:98:FC:1C:14:9A:FB:F4:C8:99:6F:B9:24:27:AE:41:E4:64:9B:93:4C:A4:95:99:1B:78:52:B8:55"},
+		}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should default and validate serverRef of failure domains", func() {
+		c := newCluster()
+		c.Spec.FailureDomains = []infrav1.FailureDomain{
+			{Name: "site-a", ControlPlane: true},
+			{Name: "site-b", ServerRef: &infrav1.ServerRef{
+				Endpoint:  "https://192.168.1.10:8006/api2/json",
+				SecretRef: &infrav1.ObjectReference{Name: "site-b"},
+			}},
+		}
+		Expect(validator.Default(ctx, c)).To(Succeed())
+		Expect(c.Spec.FailureDomains[1].ServerRef.SecretRef.Namespace).To(Equal("default"))
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).NotTo(HaveOccurred())
+
+		c.Spec.FailureDomains[1].ServerRef.Endpoint = "192.168.1.10:8006"
+		_, err = validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject duplicate failure domains", func() {
+		c := newCluster()
+		c.Spec.FailureDomains = []infrav1.FailureDomain{{Name: "site-a"}, {Name: "site-a"}}
+		_, err := validator.ValidateCreate(ctx, c)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject changing control plane endpoint", func() {
+		old := newCluster()
+		old.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "192.168.0.100", Port: 6443}
+		c := newCluster()
+		c.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "192.168.0.101", Port: 6443}
+		_, err := validator.ValidateUpdate(ctx, old, c)
+		Expect(err).To(HaveOccurred())
+	})
+})
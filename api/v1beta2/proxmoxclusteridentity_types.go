@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProxmoxClusterIdentitySpec defines the desired state of ProxmoxClusterIdentity
+type ProxmoxClusterIdentitySpec struct {
+	// SecretName is the name of the secret in the namespace of the controller
+	// which contains proxmox login secrets. the secret has the same keys as ServerRef.SecretRef
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// AllowedNamespaces is used to identify which namespaces are allowed to use this identity.
+	// no namespaces are allowed if empty, while allowedNamespaces: {} allows all namespaces
+	// +optional
+	AllowedNamespaces *AllowedNamespaces `json:"allowedNamespaces,omitempty"`
+}
+
+// AllowedNamespaces selects namespaces by name or labels.
+// a namespace is allowed if it matches either of them
+type AllowedNamespaces struct {
+	// NamespaceList is a list of namespace names
+	// +optional
+	NamespaceList []string `json:"list,omitempty"`
+
+	// Selector is a label selector of namespaces
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=proxmoxclusteridentities,scope=Cluster,categories=cluster-api
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".spec.secretName",description="Secret in the namespace of the controller"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of ProxmoxClusterIdentity"
+
+// ProxmoxClusterIdentity is the Schema for the proxmoxclusteridentities API.
+// it lets ProxmoxClusters in the allowed namespaces use the credentials kept in the namespace of the controller
+type ProxmoxClusterIdentity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProxmoxClusterIdentitySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxClusterIdentityList contains a list of ProxmoxClusterIdentity
+type ProxmoxClusterIdentityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxClusterIdentity `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProxmoxClusterIdentity{}, &ProxmoxClusterIdentityList{})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"github.com/k8s-proxmox/proxmox-go/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/errors"
+)
+
+const (
+	// MachineFinalizer
+	MachineFinalizer = "proxmoxmachine.infrastructure.cluster.x-k8s.io"
+)
+
+// ProxmoxMachineSpec defines the desired state of ProxmoxMachine
+type ProxmoxMachineSpec struct {
+	// ProviderID
+	ProviderID *string `json:"providerID,omitempty"`
+
+	// Node is proxmox node hosting vm instance which used for ProxmoxMachine.
+	// Changing it after the vm is created live-migrates the vm to the new node.
+	Node string `json:"node,omitempty"`
+
+	// Storage is name of proxmox storage used by this node.
+	// The storage must support "images(VM Disks)" type of content.
+	// cappx will use random storage if empty
+	Storage string `json:"storage,omitempty"`
+
+	// +kubebuilder:validation:Minimum:=0
+	// VMID is proxmox qemu's id
+	VMID *int `json:"vmID,omitempty"`
+
+	// Image is the image to be provisioned
+	Image Image `json:"image"`
+
+	// CloudInit defines options related to the bootstrapping systems where
+	// CloudInit is used.
+	CloudInit CloudInit `json:"cloudInit,omitempty"`
+
+	// Hardware
+	// +kubebuilder:default:={cpu:2,disk:"50Gi",memory:4096,networkDevices:{{model:virtio,bridge:vmbr0,firewall:true}}}
+	Hardware Hardware `json:"hardware,omitempty"`
+
+	// Network
+	Network Network `json:"network,omitempty"`
+
+	// Options for QEMU instance
+	Options Options `json:"options,omitempty"`
+
+	// FailureDomain is the failure domain unique identifier this Machine should be attached to, as defined in Cluster API.
+	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// NodeSelector is a selector which must match a proxmox node's labels for the vm to be scheduled on that node.
+	// Proxmox node labels are read from the node's notes and qemu-scheduler's config.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// NodeAffinity describes node affinity scheduling rules for the vm.
+	// Only "metadata.name" (proxmox node name) is supported as a key of matchFields.
+	// +optional
+	NodeAffinity *corev1.NodeAffinity `json:"nodeAffinity,omitempty"`
+
+	// SchedulerProfile is the name of qemu-scheduler profile in the plugin config
+	// used for scheduling the vm. ProxmoxCluster's spec.schedulerProfile is used if empty
+	// +optional
+	SchedulerProfile string `json:"schedulerProfile,omitempty"`
+
+	// ShutdownTimeout is the grace period of ACPI/guest agent shutdown on deletion.
+	// The vm is powered off if it does not stop within the period.
+	// "0s" powers off the vm immediately. Defaults to 5m.
+	// +optional
+	ShutdownTimeout *metav1.Duration `json:"shutdownTimeout,omitempty"`
+}
+
+// ProxmoxMachineStatus defines the observed state of ProxmoxMachine
+type ProxmoxMachineStatus struct {
+	// Ready is true when the provider resource is ready.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// FailureReason
+	FailureReason *errors.MachineStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Addresses
+	Addresses []clusterv1.MachineAddress `json:"addresses,omitempty"`
+
+	// Conditions
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// Node is proxmox node currently hosting vm instance.
+	// It differs from spec.node while the vm is being migrated.
+	// +optional
+	Node string `json:"node,omitempty"`
+
+	// Configuration
+	Config api.VirtualMachineConfig `json:"config,omitempty"`
+
+	// InstanceStatus is the status of the proxmox instance for this machine.
+	// +optional
+	InstanceStatus *InstanceStatus `json:"instanceStatus,omitempty"` // InstanceStatus
+
+	// ProvisioningPhase is the last completed step of creating the vm instance.
+	// +optional
+	ProvisioningPhase ProvisioningPhase `json:"provisioningPhase,omitempty"`
+
+	// Task is the proxmox task in flight for the vm.
+	// It is polled on subsequent reconciliations until finished.
+	// +optional
+	Task *ProxmoxTask `json:"task,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels.cluster\\.x-k8s\\.io/cluster-name",description="Cluster to which this VSphereMachine belongs"
+// +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.ownerReferences[?(@.kind==\"Machine\")].name",description="Machine object which owns with this ProxmoxMachine",priority=1
+// +kubebuilder:printcolumn:name="VMID",type=string,JSONPath=`.spec.vmID`,priority=1
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.node`,priority=1
+// +kubebuilder:printcolumn:name="Storage",type=string,JSONPath=`.spec.storage`,priority=1
+// +kubebuilder:printcolumn:name="ProviderID",type=string,JSONPath=`.spec.providerID`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.instanceStatus`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.provisioningPhase`,priority=1
+// +kubebuilder:printcolumn:name="Task",type=string,JSONPath=`.status.task.operation`,priority=1
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Machine"
+
+// ProxmoxMachine is the Schema for the proxmoxmachines API
+type ProxmoxMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProxmoxMachineSpec   `json:"spec,omitempty"`
+	Status ProxmoxMachineStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (r *ProxmoxMachine) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (r *ProxmoxMachine) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxMachineList contains a list of ProxmoxMachine
+type ProxmoxMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxMachine `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProxmoxMachine{}, &ProxmoxMachineList{})
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// only supported key of NodeAffinity's matchFields
+	NodeFieldSelectorKeyNodeName = "metadata.name"
+
+	// VMIDRangeAnnotation specifies the range of vmid assigned by qemu-scheduler e.g. "100-200".
+	// It must be the same key as qemu-scheduler's idrange plugin uses
+	VMIDRangeAnnotation = "vmid.qemu-scheduler/range"
+
+	// SchedulingDecisionAnnotation holds the last scheduling decision of the ProxmoxMachine in JSON
+	// e.g. candidate nodes, their filter outcomes and scores. it is set only if enabled in the controller
+	SchedulingDecisionAnnotation = "qemu-scheduler/last-decision"
+
+	// range of vmid proxmox accepts
+	minVMID = 100
+	maxVMID = 999999999
+)
+
+var (
+	hexRegexp = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+	// checksum types and their checksum lengths
+	checksumLengths = map[string]int{
+		"sha256":    64,
+		"sha256sum": 64,
+		"md5":       32,
+		"md5sum":    32,
+	}
+)
+
+func (r *ProxmoxMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w := &proxmoxMachineWebhook{}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxmachine,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=create;update,versions=v1beta2,name=default.proxmoxmachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxmachine,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=create;update,versions=v1beta2,name=validation.proxmoxmachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+type proxmoxMachineWebhook struct{}
+
+var (
+	_ webhook.CustomDefaulter = &proxmoxMachineWebhook{}
+	_ webhook.CustomValidator = &proxmoxMachineWebhook{}
+)
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (*proxmoxMachineWebhook) Default(_ context.Context, obj runtime.Object) error {
+	m, ok := obj.(*ProxmoxMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got a %T", obj))
+	}
+	defaultProxmoxMachineSpec(&m.Spec)
+	return nil
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	m, ok := obj.(*ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got a %T", obj))
+	}
+	return nil, m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineWebhook) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	m, ok := newObj.(*ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got a %T", newObj))
+	}
+	old, ok := oldObj.(*ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got a %T", oldObj))
+	}
+	// do not block removing finalizer
+	if !m.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	return nil, m.validate(old)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *ProxmoxMachine) validate(old *ProxmoxMachine) error {
+	allErrs := validateProxmoxMachineSpec(&r.Spec, field.NewPath("spec"))
+	allErrs = append(allErrs, validateVMIDRangeAnnotation(r.Annotations, field.NewPath("metadata", "annotations"))...)
+	if old != nil {
+		allErrs = append(allErrs, validateProxmoxMachineSpecUpdate(&old.Spec, &r.Spec, field.NewPath("spec"))...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ProxmoxMachine").GroupKind(), r.Name, allErrs)
+}
+
+// infer checksum type from the length of checksum
+func defaultProxmoxMachineSpec(spec *ProxmoxMachineSpec) {
+	image := &spec.Image
+	if image.Checksum != "" && image.ChecksumType == nil {
+		switch len(image.Checksum) {
+		case checksumLengths["sha256"]:
+			image.ChecksumType = ptr.To("sha256")
+		case checksumLengths["md5"]:
+			image.ChecksumType = ptr.To("md5")
+		}
+	}
+}
+
+func validateProxmoxMachineSpec(spec *ProxmoxMachineSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := validateImage(&spec.Image, fldPath.Child("image"))
+	if spec.VMID != nil && (*spec.VMID < minVMID || *spec.VMID > maxVMID) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("vmID"), *spec.VMID, fmt.Sprintf("must be in the range %d-%d", minVMID, maxVMID)))
+	}
+	if disk := spec.Hardware.Disk; disk != nil && disk.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("hardware", "disk"), disk.String(), "must be greater than 0"))
+	}
+	if timeout := spec.ShutdownTimeout; timeout != nil && timeout.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("shutdownTimeout"), timeout.Duration.String(), "must not be negative"))
+	}
+	allErrs = append(allErrs, validateNodePlacement(spec, fldPath)...)
+	return allErrs
+}
+
+func validateImage(image *Image, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if u, err := url.Parse(image.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), image.URL, "must be an absolute URL"))
+	}
+	if image.Checksum == "" {
+		return allErrs
+	}
+	if image.ChecksumType == nil {
+		return append(allErrs, field.Required(fldPath.Child("checksumType"), "must be specified when `checksum` is specified"))
+	}
+	length, ok := checksumLengths[strings.ToLower(*image.ChecksumType)]
+	if !ok {
+		return append(allErrs, field.NotSupported(fldPath.Child("checksumType"), *image.ChecksumType, []string{"sha256", "sha256sum", "md5", "md5sum"}))
+	}
+	if len(image.Checksum) != length || !hexRegexp.MatchString(image.Checksum) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("checksum"), image.Checksum, fmt.Sprintf("must be %d hex characters for %s", length, *image.ChecksumType)))
+	}
+	return allErrs
+}
+
+// validate vmid range annotation used by qemu-scheduler e.g. "100-200"
+func validateVMIDRangeAnnotation(annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	value, ok := annotations[VMIDRangeAnnotation]
+	if !ok {
+		return nil
+	}
+	rangePath := fldPath.Key(VMIDRangeAnnotation)
+	start, end, found := strings.Cut(value, "-")
+	if !found {
+		return field.ErrorList{field.Invalid(rangePath, value, "must be in the form of start-end")}
+	}
+	startID, err1 := strconv.Atoi(start)
+	endID, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil {
+		return field.ErrorList{field.Invalid(rangePath, value, "start and end must be integers")}
+	}
+	if startID < minVMID || endID > maxVMID || startID > endID {
+		return field.ErrorList{field.Invalid(rangePath, value, fmt.Sprintf("must be a range within %d-%d", minVMID, maxVMID))}
+	}
+	return nil
+}
+
+// fields set by the controller on creation cannot be changed afterwards
+func validateProxmoxMachineSpecUpdate(old, spec *ProxmoxMachineSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old.ProviderID != nil && !reflect.DeepEqual(old.ProviderID, spec.ProviderID) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("providerID"), "cannot be changed once set"))
+	}
+	if old.VMID != nil && !reflect.DeepEqual(old.VMID, spec.VMID) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("vmID"), "cannot be changed once set"))
+	}
+	if old.Storage != "" && old.Storage != spec.Storage {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("storage"), "cannot be changed once set"))
+	}
+	if old.FailureDomain != nil && !reflect.DeepEqual(old.FailureDomain, spec.FailureDomain) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("failureDomain"), "cannot be changed once set"))
+	}
+	return allErrs
+}
+
+// validate NodeSelector and NodeAffinity
+func validateNodePlacement(spec *ProxmoxMachineSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := metav1validation.ValidateLabels(spec.NodeSelector, fldPath.Child("nodeSelector"))
+	affinity := spec.NodeAffinity
+	if affinity == nil {
+		return allErrs
+	}
+	affinityPath := fldPath.Child("nodeAffinity")
+	if required := affinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+		requiredPath := affinityPath.Child("requiredDuringSchedulingIgnoredDuringExecution")
+		if len(required.NodeSelectorTerms) == 0 {
+			allErrs = append(allErrs, field.Required(requiredPath.Child("nodeSelectorTerms"), "must have at least one node selector term"))
+		}
+		for i, term := range required.NodeSelectorTerms {
+			allErrs = append(allErrs, validateNodeSelectorTerm(term, requiredPath.Child("nodeSelectorTerms").Index(i))...)
+		}
+	}
+	for i, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		termPath := affinityPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+		if term.Weight < 1 || term.Weight > 100 {
+			allErrs = append(allErrs, field.Invalid(termPath.Child("weight"), term.Weight, "must be in the range 1-100"))
+		}
+		allErrs = append(allErrs, validateNodeSelectorTerm(term.Preference, termPath.Child("preference"))...)
+	}
+	return allErrs
+}
+
+func validateNodeSelectorTerm(term corev1.NodeSelectorTerm, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, req := range term.MatchExpressions {
+		allErrs = append(allErrs, validateNodeSelectorRequirement(req, fldPath.Child("matchExpressions").Index(i))...)
+	}
+	for i, req := range term.MatchFields {
+		reqPath := fldPath.Child("matchFields").Index(i)
+		if req.Key != NodeFieldSelectorKeyNodeName {
+			allErrs = append(allErrs, field.NotSupported(reqPath.Child("key"), req.Key, []string{NodeFieldSelectorKeyNodeName}))
+		}
+		switch req.Operator {
+		case corev1.NodeSelectorOpIn, corev1.NodeSelectorOpNotIn:
+			if len(req.Values) != 1 {
+				allErrs = append(allErrs, field.Required(reqPath.Child("values"), "must have exactly one value for node name"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(reqPath.Child("operator"), req.Operator,
+				[]string{string(corev1.NodeSelectorOpIn), string(corev1.NodeSelectorOpNotIn)}))
+		}
+	}
+	return allErrs
+}
+
+func validateNodeSelectorRequirement(req corev1.NodeSelectorRequirement, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsQualifiedName(req.Key) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("key"), req.Key, msg))
+	}
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn, corev1.NodeSelectorOpNotIn:
+		if len(req.Values) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("values"), "must be specified when `operator` is 'In' or 'NotIn'"))
+		}
+		for i, value := range req.Values {
+			for _, msg := range validation.IsValidLabelValue(value) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("values").Index(i), value, msg))
+			}
+		}
+	case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+		if len(req.Values) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("values"), "may not be specified when `operator` is 'Exists' or 'DoesNotExist'"))
+		}
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if len(req.Values) != 1 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("values"), "must be specified single value when `operator` is 'Lt' or 'Gt'"))
+		} else if _, err := strconv.ParseInt(req.Values[0], 10, 64); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("values").Index(0), req.Values[0], "must be an integer when `operator` is 'Lt' or 'Gt'"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("operator"), req.Operator, []string{
+			string(corev1.NodeSelectorOpIn), string(corev1.NodeSelectorOpNotIn),
+			string(corev1.NodeSelectorOpExists), string(corev1.NodeSelectorOpDoesNotExist),
+			string(corev1.NodeSelectorOpGt), string(corev1.NodeSelectorOpLt),
+		}))
+	}
+	return allErrs
+}
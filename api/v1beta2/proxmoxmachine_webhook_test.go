@@ -0,0 +1,199 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("ProxmoxMachine validation", Label("unit", "webhook"), func() {
+	ctx := context.Background()
+	validator := infrav1.NewProxmoxMachineWebhook()
+
+	newMachine := func(selector map[string]string, affinity *corev1.NodeAffinity) *infrav1.ProxmoxMachine {
+		m := &infrav1.ProxmoxMachine{}
+		m.Name = "foo"
+		m.Spec.Image.URL = "https://cloud-images.ubuntu.com/releases/jammy/release/ubuntu-22.04-server-cloudimg-amd64.img"
+		m.Spec.NodeSelector = selector
+		m.Spec.NodeAffinity = affinity
+		return m
+	}
+
+	requiredAffinity := func(terms ...corev1.NodeSelectorTerm) *corev1.NodeAffinity {
+		return &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}
+	}
+
+	Context("without node placement", func() {
+		It("should be valid", func() {
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, nil))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with valid node placement", func() {
+		It("should be valid", func() {
+			affinity := requiredAffinity(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "rack", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+				MatchFields:      []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node1"}}},
+			})
+			affinity.PreferredDuringSchedulingIgnoredDuringExecution = []corev1.PreferredSchedulingTerm{{
+				Weight:     10,
+				Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "cores", Operator: corev1.NodeSelectorOpGt, Values: []string{"16"}}}},
+			}}
+			_, err := validator.ValidateUpdate(ctx, newMachine(nil, nil), newMachine(map[string]string{"gpu": "true"}, affinity))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with invalid node selector", func() {
+		It("should be invalid", func() {
+			_, err := validator.ValidateCreate(ctx, newMachine(map[string]string{"gpu": "not valid"}, nil))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with invalid node affinity", func() {
+		It("should reject empty required terms", func() {
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, requiredAffinity()))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject unsupported field key", func() {
+			affinity := requiredAffinity(corev1.NodeSelectorTerm{
+				MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.uid", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+			})
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, affinity))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject non-integer value for Gt", func() {
+			affinity := requiredAffinity(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "cores", Operator: corev1.NodeSelectorOpGt, Values: []string{"many"}}},
+			})
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, affinity))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject values for Exists", func() {
+			affinity := requiredAffinity(corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "ssd", Operator: corev1.NodeSelectorOpExists, Values: []string{"a"}}},
+			})
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, affinity))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject out of range weight", func() {
+			affinity := &corev1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+				Weight:     0,
+				Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "ssd", Operator: corev1.NodeSelectorOpExists}}},
+			}}}
+			_, err := validator.ValidateCreate(ctx, newMachine(nil, affinity))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with image", func() {
+		It("should default checksum type from checksum length", func() {
+			m := newMachine(nil, nil)
+			m.Spec.Image.Checksum = strings.Repeat("a", 64)
+			Expect(validator.Default(ctx, m)).To(Succeed())
+			Expect(m.Spec.Image.ChecksumType).To(Equal(ptr.To("sha256")))
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject checksum without checksum type", func() {
+			m := newMachine(nil, nil)
+			m.Spec.Image.Checksum = "abc"
+			Expect(validator.Default(ctx, m)).To(Succeed())
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject checksum not matching checksum type", func() {
+			m := newMachine(nil, nil)
+			m.Spec.Image.Checksum = strings.Repeat("a", 64)
+			m.Spec.Image.ChecksumType = ptr.To("md5")
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject relative url", func() {
+			m := newMachine(nil, nil)
+			m.Spec.Image.URL = "ubuntu.img"
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with hardware", func() {
+		It("should reject zero disk size", func() {
+			m := newMachine(nil, nil)
+			m.Spec.Hardware.Disk = ptr.To(resource.MustParse("0"))
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with vmid range annotation", func() {
+		It("should accept valid range", func() {
+			m := newMachine(nil, nil)
+			m.Annotations = map[string]string{infrav1.VMIDRangeAnnotation: "100-200"}
+			_, err := validator.ValidateCreate(ctx, m)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject invalid range", func() {
+			for _, r := range []string{"100", "a-200", "200-100", "0-10"} {
+				m := newMachine(nil, nil)
+				m.Annotations = map[string]string{infrav1.VMIDRangeAnnotation: r}
+				_, err := validator.ValidateCreate(ctx, m)
+				Expect(err).To(HaveOccurred(), r)
+			}
+		})
+	})
+
+	Context("on update", func() {
+		It("should allow setting vmid", func() {
+			old := newMachine(nil, nil)
+			m := newMachine(nil, nil)
+			m.Spec.VMID = ptr.To(100)
+			_, err := validator.ValidateUpdate(ctx, old, m)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject changing vmid", func() {
+			old := newMachine(nil, nil)
+			old.Spec.VMID = ptr.To(100)
+			m := newMachine(nil, nil)
+			m.Spec.VMID = ptr.To(101)
+			_, err := validator.ValidateUpdate(ctx, old, m)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
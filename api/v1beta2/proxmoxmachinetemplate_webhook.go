@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (r *ProxmoxMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	w := &proxmoxMachineTemplateWebhook{}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxmachinetemplate,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,verbs=create;update,versions=v1beta2,name=default.proxmoxmachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta2-proxmoxmachinetemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,verbs=create;update,versions=v1beta2,name=validation.proxmoxmachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+type proxmoxMachineTemplateWebhook struct{}
+
+var (
+	_ webhook.CustomDefaulter = &proxmoxMachineTemplateWebhook{}
+	_ webhook.CustomValidator = &proxmoxMachineTemplateWebhook{}
+)
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (*proxmoxMachineTemplateWebhook) Default(_ context.Context, obj runtime.Object) error {
+	t, ok := obj.(*ProxmoxMachineTemplate)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got a %T", obj))
+	}
+	defaultProxmoxMachineSpec(&t.Spec.Template.Spec)
+	return nil
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineTemplateWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	t, ok := obj.(*ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got a %T", obj))
+	}
+	return nil, t.validate(nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineTemplateWebhook) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	t, ok := newObj.(*ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got a %T", newObj))
+	}
+	old, ok := oldObj.(*ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got a %T", oldObj))
+	}
+	return nil, t.validate(old)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (*proxmoxMachineTemplateWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (r *ProxmoxMachineTemplate) validate(old *ProxmoxMachineTemplate) error {
+	templatePath := field.NewPath("spec", "template")
+	allErrs := validateProxmoxMachineSpec(&r.Spec.Template.Spec, templatePath.Child("spec"))
+	allErrs = append(allErrs, validateVMIDRangeAnnotation(r.Spec.Template.ObjectMeta.Annotations, templatePath.Child("metadata", "annotations"))...)
+	// machine templates are immutable as cluster api requires.
+	// a new template must be created to roll out changes
+	if old != nil && !apiequality.Semantic.DeepEqual(old.Spec, r.Spec) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec"), r.Spec, "ProxmoxMachineTemplate spec is immutable"))
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ProxmoxMachineTemplate").GroupKind(), r.Name, allErrs)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("ProxmoxMachineTemplate validation", Label("unit", "webhook"), func() {
+	ctx := context.Background()
+	validator := infrav1.NewProxmoxMachineTemplateWebhook()
+
+	newTemplate := func() *infrav1.ProxmoxMachineTemplate {
+		t := &infrav1.ProxmoxMachineTemplate{}
+		t.Name = "foo"
+		t.Spec.Template.Spec.Image.URL = "https://cloud-images.ubuntu.com/releases/jammy/release/ubuntu-22.04-server-cloudimg-amd64.img"
+		return t
+	}
+
+	It("should accept valid template", func() {
+		_, err := validator.ValidateCreate(ctx, newTemplate())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject invalid machine spec", func() {
+		t := newTemplate()
+		t.Spec.Template.Spec.Hardware.Disk = ptr.To(resource.MustParse("-1Gi"))
+		_, err := validator.ValidateCreate(ctx, t)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject invalid vmid range annotation", func() {
+		t := newTemplate()
+		t.Spec.Template.ObjectMeta.Annotations = map[string]string{infrav1.VMIDRangeAnnotation: "200-100"}
+		_, err := validator.ValidateCreate(ctx, t)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject spec update", func() {
+		old := newTemplate()
+		t := newTemplate()
+		t.Spec.Template.Spec.Hardware.Memory = 8192
+		_, err := validator.ValidateUpdate(ctx, old, t)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should accept spec having the same quantity in different notation", func() {
+		old := newTemplate()
+		old.Spec.Template.Spec.Hardware.Disk = ptr.To(resource.MustParse("1Gi"))
+		t := newTemplate()
+		t.Spec.Template.Spec.Hardware.Disk = resource.NewQuantity(1<<30, resource.BinarySI)
+		_, err := validator.ValidateUpdate(ctx, old, t)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should accept metadata update", func() {
+		old := newTemplate()
+		t := newTemplate()
+		t.Labels = map[string]string{"foo": "bar"}
+		_, err := validator.ValidateUpdate(ctx, old, t)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
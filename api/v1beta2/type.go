@@ -0,0 +1,406 @@
+package v1beta2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type InstanceStatus string
+
+var (
+	InstanceStatusPaused  = InstanceStatus(api.ProcessStatusPaused)
+	InstanceStatusRunning = InstanceStatus(api.ProcessStatusRunning)
+	InstanceStatusStopped = InstanceStatus(api.ProcessStatusStopped)
+)
+
+// ProvisioningPhase is the last completed step of creating the vm.
+// Each step is resumed from the persisted phase after the controller restarts
+// +kubebuilder:validation:Enum:=Scheduled;Created;Configured;Resized;Started
+type ProvisioningPhase string
+
+const (
+	// node, vmid and storage are assigned to the vm
+	ProvisioningPhaseScheduled ProvisioningPhase = "Scheduled"
+	// qemu is created on the node
+	ProvisioningPhaseCreated ProvisioningPhase = "Created"
+	// cloud-init snippet is uploaded
+	ProvisioningPhaseConfigured ProvisioningPhase = "Configured"
+	// boot disk is resized
+	ProvisioningPhaseResized ProvisioningPhase = "Resized"
+	// qemu is started
+	ProvisioningPhaseStarted ProvisioningPhase = "Started"
+)
+
+var provisioningPhaseOrder = []ProvisioningPhase{
+	ProvisioningPhaseScheduled,
+	ProvisioningPhaseCreated,
+	ProvisioningPhaseConfigured,
+	ProvisioningPhaseResized,
+	ProvisioningPhaseStarted,
+}
+
+// Reached returns true if the phase is the given phase or a later one
+func (p ProvisioningPhase) Reached(phase ProvisioningPhase) bool {
+	return p.index() >= phase.index()
+}
+
+func (p ProvisioningPhase) index() int {
+	for i, phase := range provisioningPhaseOrder {
+		if p == phase {
+			return i
+		}
+	}
+	return -1
+}
+
+// TaskOperation is the operation on the vm run as a proxmox task
+// +kubebuilder:validation:Enum:=create;resize;start;resume;migrate;shutdown;stop;delete;download;upload
+type TaskOperation string
+
+const (
+	TaskOperationCreate   TaskOperation = "create"
+	TaskOperationResize   TaskOperation = "resize"
+	TaskOperationStart    TaskOperation = "start"
+	TaskOperationResume   TaskOperation = "resume"
+	TaskOperationMigrate  TaskOperation = "migrate"
+	TaskOperationShutdown TaskOperation = "shutdown"
+	TaskOperationStop     TaskOperation = "stop"
+	TaskOperationDelete   TaskOperation = "delete"
+
+	// download and upload are run with API token, which cannot open node shells
+	TaskOperationDownload TaskOperation = "download"
+	TaskOperationUpload   TaskOperation = "upload"
+)
+
+// ProxmoxTask is a proxmox task in flight
+type ProxmoxTask struct {
+	// UPID is the unique id of the task
+	UPID string `json:"upid"`
+
+	// Operation run by the task
+	Operation TaskOperation `json:"operation"`
+
+	// StartTime is the time the task was requested
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// ServerRef is used for configuring Proxmox client
+type ServerRef struct {
+	// endpoint is the address of the Proxmox-VE REST API endpoint.
+	Endpoint string `json:"endpoint"`
+
+	// FailoverEndpoints are addresses of the REST API on the other nodes of the same Proxmox-VE cluster.
+	// they are used in order when the endpoint does not respond, e.g. the node is down for maintenance
+	// +optional
+	FailoverEndpoints []string `json:"failoverEndpoints,omitempty"`
+
+	// SecretRef is a reference for secret which contains proxmox login secrets.
+	// the secret has either PROXMOX_USER and PROXMOX_PASSWORD or PROXMOX_TOKENID and PROXMOX_SECRET.
+	// PEM encoded CA certificates can be set to PROXMOX_CA_CERT.
+	// either secretRef or identityRef must be specified
+	// +optional
+	SecretRef *ObjectReference `json:"secretRef,omitempty"`
+
+	// IdentityRef is a reference for ProxmoxClusterIdentity which allows the namespace of the ProxmoxCluster.
+	// either secretRef or identityRef must be specified
+	// +optional
+	IdentityRef *ProxmoxClusterIdentityReference `json:"identityRef,omitempty"`
+
+	// TLS configures how the certificate of the endpoint is verified.
+	// the certificate is not verified if empty, unless the secret has PROXMOX_CA_CERT
+	// +optional
+	TLS *ServerTLSConfig `json:"tls,omitempty"`
+}
+
+// ProxmoxClusterIdentityReference is a reference for cluster-scoped ProxmoxClusterIdentity
+type ProxmoxClusterIdentityReference struct {
+	// Name of the ProxmoxClusterIdentity
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ServerTLSConfig configures verification of the Proxmox-VE REST API endpoint certificate.
+// the certificate is verified against the system roots if neither CA bundle nor fingerprints are given
+type ServerTLSConfig struct {
+	// InsecureSkipVerify disables verification of the certificate
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CAConfigMapRef is a reference for configmap which contains PEM encoded CA certificates.
+	// it takes precedence over PROXMOX_CA_CERT of the secret
+	// +optional
+	CAConfigMapRef *ConfigMapKeyReference `json:"caConfigMapRef,omitempty"`
+
+	// Fingerprints pins SHA-256 fingerprints of the endpoint certificate
+	// e.g. "AB:CD:...:EF" as shown in proxmox web UI.
+	// the certificate chain is verified as well only if CA bundle is given
+	// +optional
+	Fingerprints []CertificateFingerprint `json:"fingerprints,omitempty"`
+}
+
+// CertificateFingerprint is colon separated SHA-256 fingerprint of certificate
+// +kubebuilder:validation:Pattern=`^([0-9A-Fa-f]{2}:){31}[0-9A-Fa-f]{2}$`
+type CertificateFingerprint string
+
+// ConfigMapKeyReference is a reference to a key of configmap.
+type ConfigMapKeyReference struct {
+	ObjectReference `json:",inline"`
+
+	// Key of the configmap data
+	// +kubebuilder:default:="ca.crt"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ObjectReference is a reference to another Kubernetes object instance.
+type ObjectReference struct {
+	// Namespace of the referent.
+	// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces/
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the referent.
+	// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#names
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// Image is the image to be provisioned
+type Image struct {
+	// +kubebuilder:validation:Pattern:=.*\.(iso|img|qcow2|qed|raw|vdi|vpc|vmdk)$
+	// URL is a location of an image to deploy.
+	// supported formats are iso/qcow2/qed/raw/vdi/vpc/vmdk.
+	URL string `json:"url"`
+
+	// Checksum
+	// Always better to specify checksum otherwise cappx will download
+	// same image for every time. If checksum is specified, cappx will try
+	// to avoid downloading existing image.
+	Checksum string `json:"checksum,omitempty"`
+
+	// +kubebuilder:validation:Enum:=sha256;sha256sum;md5;md5sum
+	// ChecksumType
+	ChecksumType *string `json:"checksumType,omitempty"`
+}
+
+// Hardware
+type Hardware struct {
+	// amount of RAM for the VM in MiB : 16 ~
+	// +kubebuilder:validation:Minimum:=16
+	// +kubebuilder:default:=4096
+	Memory int `json:"memory,omitempty"`
+
+	// number of CPU cores : 1 ~
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=2
+	CPU int `json:"cpu,omitempty"`
+
+	// Emulated CPU Type. Defaults to kvm64
+	CPUType string `json:"cpuType,omitempty"`
+
+	// +kubebuilder:validation:Minimum:=1
+	// The number of CPU sockets. Defaults to 1.
+	Sockets int `json:"sockets,omitempty"`
+
+	// +kubebuilder:validation:Minimum:=0
+	// Limit of CPU usage. If the computer has 2 CPUs, it has total of '2' CPU time.
+	// Value '0' indicates no CPU limit. Defaults to 0.
+	CPULimit int `json:"cpuLimit,omitempty"`
+
+	// Select BIOS implementation. Defaults to seabios. seabios or ovmf.
+	// Defaults to seabios.
+	BIOS BIOS `json:"bios,omitempty"`
+
+	// Specifies the QEMU machine type.
+	// regex: (pc|pc(-i440fx)?-\d+(\.\d+)+(\+pve\d+)?(\.pxe)?|q35|pc-q35-\d+(\.\d+)+(\+pve\d+)?(\.pxe)?|virt(?:-\d+(\.\d+)+)?(\+pve\d+)?)
+	// Machine string `json:"machine,omitempty"`
+
+	// SCSI controller model
+	// SCSIHardWare SCSIHardWare `json:"scsiHardWare,omitempty"`
+
+	// size of the boot disk e.g. 50Gi
+	// +kubebuilder:default:="50Gi"
+	Disk *resource.Quantity `json:"disk,omitempty"`
+
+	// network devices. they are attached to the vm as net0, net1, ... in order
+	// +kubebuilder:default:={{model:virtio,bridge:vmbr0,firewall:true}}
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:MaxItems:=32
+	// +listType=atomic
+	NetworkDevices []NetworkDevice `json:"networkDevices,omitempty"`
+}
+
+// units of disk size proxmox accepts
+var diskSizeUnits = []struct {
+	suffix string
+	size   int64
+}{{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}}
+
+// DiskSize returns disk size in proxmox format e.g. 50G.
+// empty string is returned if disk size is not specified
+func (h Hardware) DiskSize() string {
+	if h.Disk == nil {
+		return ""
+	}
+	bytes := h.Disk.Value()
+	if bytes == 0 {
+		return "0"
+	}
+	for _, unit := range diskSizeUnits {
+		if bytes%unit.size == 0 {
+			return fmt.Sprintf("%d%s", bytes/unit.size, unit.suffix)
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// Network Device
+type NetworkDevice struct {
+	// +kubebuilder:default:="virtio"
+	Model NetworkDeviceModel `json:"model,omitempty"`
+
+	// +kubebuilder:default:="vmbr0"
+	Bridge NetworkDeviceBridge `json:"bridge,omitempty"`
+
+	// +kubebuilder:default:=true
+	Firewall bool `json:"firewall,omitempty"`
+
+	LinkDown bool `json:"linkDown,omitempty"`
+
+	MacAddr string `json:"macAddr,omitempty"`
+
+	MTU int `json:"mtu,omitempty"`
+
+	Queues int `json:"queues,omitempty"`
+
+	// since float is highly discouraged, use string instead
+	// +kubebuilder:validation:Pattern:=[0-9]+(\.|)[0-9]*
+	Rate string `json:"rate,omitempty"`
+
+	Tag int `json:"tag,omitempty"`
+
+	// trunks: array of vlanid
+	Trunks []int `json:"trunks,omitempty"`
+}
+
+type (
+	// +kubebuilder:validation:Enum:=e1000;virtio;rtl8139;vmxnet3
+	NetworkDeviceModel string
+
+	// +kubebuilder:validation:Pattern:="vmbr[0-9]{1,4}"
+	NetworkDeviceBridge string
+)
+
+func (n *NetworkDevice) String() string {
+	config := []string{}
+	config = append(config, fmt.Sprintf("model=%s", string(n.Model)))
+	if n.Bridge != "" {
+		config = append(config, fmt.Sprintf("bridge=%s", string(n.Bridge)))
+	}
+	if n.Firewall {
+		config = append(config, fmt.Sprintf("firewall=%d", btoi(n.Firewall)))
+	}
+	if n.LinkDown {
+		config = append(config, fmt.Sprintf("link_down=%d", btoi(n.LinkDown)))
+	}
+	if n.MacAddr != "" {
+		config = append(config, fmt.Sprintf("macaddr=%s,%s=%s", n.MacAddr, string(n.Model), n.MacAddr))
+	}
+	if n.MTU != 0 {
+		config = append(config, fmt.Sprintf("mtu=%d", n.MTU))
+	}
+	if n.Queues != 0 {
+		config = append(config, fmt.Sprintf("queues=%d", n.Queues))
+	}
+	if n.Rate != "" {
+		config = append(config, fmt.Sprintf("rate=%s", n.Rate))
+	}
+	if n.Tag != 0 {
+		config = append(config, fmt.Sprintf("tag=%d", n.Tag))
+	}
+	if n.Trunks != nil {
+		config = append(config, fmt.Sprintf("trunks=%s", strings.Join(itoaSlice(n.Trunks), ";")))
+	}
+	return strings.Join(config, ",")
+}
+
+// Network
+// cloud-init network configuration is configured through Proxmox API
+// it may be migrated to raw yaml way from Proxmox API way in the future
+type Network struct {
+	// to do : should accept multiple IPConfig
+	IPConfig IPConfig `json:"ipConfig,omitempty"`
+
+	// DNS server
+	NameServer string `json:"nameServer,omitempty"`
+
+	// search domain
+	SearchDomain string `json:"searchDomain,omitempty"`
+}
+
+// IPConfig defines IP addresses and gateways for corresponding interface.
+// it defaults to using dhcp on IPv4 if neither IP nor IP6 is specified.
+type IPConfig struct {
+	// IPv4 with CIDR
+	IP string `json:"ip,omitempty"`
+
+	// gateway IPv4
+	Gateway string `json:"gateway,omitempty"`
+
+	// IPv6 with CIDR
+	IP6 string `json:"ip6,omitempty"`
+
+	// gateway IPv6
+	Gateway6 string `json:"gateway6,omitempty"`
+}
+
+func (c *IPConfig) String() string {
+	configs := []string{}
+	if c.IP != "" {
+		configs = append(configs, fmt.Sprintf("ip=%s", c.IP))
+	}
+	if c.Gateway != "" {
+		configs = append(configs, fmt.Sprintf("gw=%s", c.Gateway))
+	}
+	if c.IP6 != "" {
+		configs = append(configs, fmt.Sprintf("ip6=%s", c.IP6))
+	}
+	if c.Gateway6 != "" {
+		configs = append(configs, fmt.Sprintf("gw6=%s", c.Gateway6))
+	}
+	ipconfig := strings.Join(configs, ",")
+
+	// it defaults to using dhcp on IPv4 if neither IP nor IP6 is specified
+	if !strings.Contains(ipconfig, "ip") {
+		ipconfig = "ip=dhcp"
+	}
+
+	return ipconfig
+}
+
+// Storage for image and snippets
+type Storage struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// bool to int
+func btoi(x bool) int8 {
+	if x {
+		return 1
+	}
+	return 0
+}
+
+// []int to []string
+func itoaSlice(a []int) []string {
+	b := []string{}
+	for _, x := range a {
+		b = append(b, fmt.Sprintf("%d", x))
+	}
+	return b
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("ProvisioningPhase", Label("unit", "api"), func() {
+	Context("empty phase", func() {
+		It("should reach no phase", func() {
+			Expect(infrav1.ProvisioningPhase("").Reached(infrav1.ProvisioningPhaseScheduled)).To(BeFalse())
+		})
+	})
+
+	Context("intermediate phase", func() {
+		It("should reach itself and earlier phases only", func() {
+			phase := infrav1.ProvisioningPhaseConfigured
+			Expect(phase.Reached(infrav1.ProvisioningPhaseScheduled)).To(BeTrue())
+			Expect(phase.Reached(infrav1.ProvisioningPhaseConfigured)).To(BeTrue())
+			Expect(phase.Reached(infrav1.ProvisioningPhaseResized)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,143 @@
+package cloudinit
+
+import (
+	"encoding/binary"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// volume label of cloud-init NoCloud datasource
+	NoCloudVolumeID = "cidata"
+
+	sectorSize = 2048
+
+	// sectors of the image. files follow the root directory
+	pvdSector       = 16
+	lPathSector     = 18
+	mPathSector     = 19
+	rootDirSector   = 20
+	firstFileSector = 21
+)
+
+// file names which can be written without Rock Ridge or Joliet extensions.
+// linux reads them in lower case without the version suffix e.g. user-data
+var isoFileNameRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,30}$`)
+
+// GenerateNoCloudISO returns ISO9660 image labeled cidata which has the files
+// e.g. user-data, meta-data and network-config, so that cloud-init reads them
+// through NoCloud datasource
+func GenerateNoCloudISO(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if !isoFileNameRegexp.MatchString(name) {
+			return nil, errors.Errorf("invalid file name %q for iso image", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// root directory has ".", ".." and the files in a sector
+	rootDir := make([]byte, 0, sectorSize)
+	rootDir = append(rootDir, directoryRecord([]byte{0}, rootDirSector, sectorSize, true)...)
+	rootDir = append(rootDir, directoryRecord([]byte{1}, rootDirSector, sectorSize, true)...)
+	sector := firstFileSector
+	for _, name := range names {
+		id := []byte(strings.ToUpper(name) + ";1")
+		rootDir = append(rootDir, directoryRecord(id, uint32(sector), uint32(len(files[name])), false)...)
+		sector += sectors(len(files[name]))
+	}
+	if len(rootDir) > sectorSize {
+		return nil, errors.New("too many files for iso image")
+	}
+
+	image := make([]byte, sector*sectorSize)
+	copy(image[pvdSector*sectorSize:], primaryVolumeDescriptor(uint32(sector)))
+	// volume descriptor set terminator
+	copy(image[(pvdSector+1)*sectorSize:], []byte{255, 'C', 'D', '0', '0', '1', 1})
+	copy(image[lPathSector*sectorSize:], pathTable(binary.LittleEndian))
+	copy(image[mPathSector*sectorSize:], pathTable(binary.BigEndian))
+	copy(image[rootDirSector*sectorSize:], rootDir)
+	sector = firstFileSector
+	for _, name := range names {
+		copy(image[sector*sectorSize:], files[name])
+		sector += sectors(len(files[name]))
+	}
+	return image, nil
+}
+
+func primaryVolumeDescriptor(volumeSectors uint32) []byte {
+	pvd := make([]byte, sectorSize)
+	pvd[0] = 1
+	copy(pvd[1:6], "CD001")
+	pvd[6] = 1
+	copy(pvd[8:40], padRight("", 32))
+	copy(pvd[40:72], padRight(NoCloudVolumeID, 32))
+	putBothEndian32(pvd[80:88], volumeSectors)
+	putBothEndian16(pvd[120:124], 1)
+	putBothEndian16(pvd[124:128], 1)
+	putBothEndian16(pvd[128:132], sectorSize)
+	putBothEndian32(pvd[132:140], uint32(len(pathTable(binary.LittleEndian))))
+	binary.LittleEndian.PutUint32(pvd[140:144], lPathSector)
+	binary.BigEndian.PutUint32(pvd[148:152], mPathSector)
+	copy(pvd[156:190], directoryRecord([]byte{0}, rootDirSector, sectorSize, true))
+	// volume set, publisher, data preparer, application and file identifiers
+	copy(pvd[190:813], padRight("", 623))
+	// creation, modification, expiration and effective dates are not specified
+	for _, offset := range []int{813, 830, 847, 864} {
+		copy(pvd[offset:offset+16], strings.Repeat("0", 16))
+	}
+	pvd[881] = 1
+	return pvd
+}
+
+// pathTable returns path table which has only the root directory
+func pathTable(order binary.ByteOrder) []byte {
+	table := make([]byte, 10)
+	table[0] = 1
+	order.PutUint32(table[2:6], rootDirSector)
+	order.PutUint16(table[6:8], 1)
+	return table
+}
+
+func directoryRecord(id []byte, extent, size uint32, dir bool) []byte {
+	length := 33 + len(id)
+	if len(id)%2 == 0 {
+		length++
+	}
+	record := make([]byte, length)
+	record[0] = byte(length)
+	putBothEndian32(record[2:10], extent)
+	putBothEndian32(record[10:18], size)
+	// recording date 1970-01-01 00:00:00 UTC
+	copy(record[18:25], []byte{70, 1, 1, 0, 0, 0, 0})
+	if dir {
+		record[25] = 2
+	}
+	putBothEndian16(record[28:32], 1)
+	record[32] = byte(len(id))
+	copy(record[33:], id)
+	return record
+}
+
+func putBothEndian16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b[0:2], v)
+	binary.BigEndian.PutUint16(b[2:4], v)
+}
+
+func putBothEndian32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:4], v)
+	binary.BigEndian.PutUint32(b[4:8], v)
+}
+
+func padRight(s string, n int) string {
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+// sectors returns number of sectors for the size. empty files occupy no sector
+func sectors(size int) int {
+	return (size + sectorSize - 1) / sectorSize
+}
@@ -0,0 +1,54 @@
+package cloudinit_test
+
+import (
+	"encoding/binary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/cloudinit"
+)
+
+// readRootDir returns file contents in the root directory of the iso image
+func readRootDir(image []byte) map[string]string {
+	const sectorSize = 2048
+	pvd := image[16*sectorSize:]
+	root := pvd[156:]
+	rootDir := image[binary.LittleEndian.Uint32(root[2:6])*sectorSize:]
+	files := map[string]string{}
+	for offset := 0; rootDir[offset] != 0; offset += int(rootDir[offset]) {
+		record := rootDir[offset:]
+		id := string(record[33 : 33+int(record[32])])
+		if record[25]&2 != 0 {
+			continue
+		}
+		extent := binary.LittleEndian.Uint32(record[2:6])
+		size := binary.LittleEndian.Uint32(record[10:14])
+		files[id] = string(image[extent*sectorSize : extent*sectorSize+size])
+	}
+	return files
+}
+
+var _ = Describe("GenerateNoCloudISO", Label("unit", "cloudinit"), func() {
+	It("should generate cidata volume with the files", func() {
+		userData := "#cloud-config\nruncmd:\n  - echo foo\n"
+		image, err := cloudinit.GenerateNoCloudISO(map[string][]byte{
+			"user-data": []byte(userData),
+			"meta-data": []byte("instance-id: foo\n"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(image) % 2048).To(Equal(0))
+		Expect(string(image[16*2048+1 : 16*2048+6])).To(Equal("CD001"))
+		Expect(string(image[16*2048+40 : 16*2048+46])).To(Equal(cloudinit.NoCloudVolumeID))
+		Expect(binary.LittleEndian.Uint32(image[16*2048+80:])).To(Equal(uint32(len(image) / 2048)))
+		Expect(readRootDir(image)).To(Equal(map[string]string{
+			"META-DATA;1": "instance-id: foo\n",
+			"USER-DATA;1": userData,
+		}))
+	})
+
+	It("should reject file names which need extensions", func() {
+		_, err := cloudinit.GenerateNoCloudISO(map[string][]byte{"User Data.yaml": nil})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,82 @@
+package cloudinit
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+// network config version 2 which proxmox generates from ipconfig0, nameserver and searchdomain
+type networkConfig struct {
+	Version   int                       `yaml:"version"`
+	Ethernets map[string]ethernetConfig `yaml:"ethernets"`
+}
+
+type ethernetConfig struct {
+	Match       map[string]string `yaml:"match"`
+	SetName     string            `yaml:"set-name,omitempty"`
+	DHCP4       bool              `yaml:"dhcp4,omitempty"`
+	DHCP6       bool              `yaml:"dhcp6,omitempty"`
+	AcceptRA    bool              `yaml:"accept-ra,omitempty"`
+	Addresses   []string          `yaml:"addresses,omitempty"`
+	Routes      []route           `yaml:"routes,omitempty"`
+	Nameservers *nameservers      `yaml:"nameservers,omitempty"`
+}
+
+type route struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+type nameservers struct {
+	Addresses []string `yaml:"addresses,omitempty"`
+	Search    []string `yaml:"search,omitempty"`
+}
+
+// GenerateNetworkConfigYaml returns network config of the first network device with the mac address.
+// it configures the device in the same way as proxmox does from ipconfig0, nameserver and searchdomain
+func GenerateNetworkConfigYaml(macAddress string, network infrav1.Network) (string, error) {
+	eth := ethernetConfig{
+		Match:   map[string]string{"macaddress": strings.ToLower(macAddress)},
+		SetName: "eth0",
+	}
+	ipConfig := network.IPConfig
+	switch ipConfig.IP {
+	case "":
+		// proxmox uses dhcp on IPv4 if neither IP nor IP6 is specified
+		eth.DHCP4 = ipConfig.IP6 == ""
+	case "dhcp":
+		eth.DHCP4 = true
+	default:
+		eth.Addresses = append(eth.Addresses, ipConfig.IP)
+	}
+	switch ipConfig.IP6 {
+	case "":
+	case "dhcp":
+		eth.DHCP6 = true
+	case "auto":
+		eth.AcceptRA = true
+	default:
+		eth.Addresses = append(eth.Addresses, ipConfig.IP6)
+	}
+	if ipConfig.Gateway != "" {
+		eth.Routes = append(eth.Routes, route{To: "0.0.0.0/0", Via: ipConfig.Gateway})
+	}
+	if ipConfig.Gateway6 != "" {
+		eth.Routes = append(eth.Routes, route{To: "::/0", Via: ipConfig.Gateway6})
+	}
+	if network.NameServer != "" || network.SearchDomain != "" {
+		eth.Nameservers = &nameservers{
+			Addresses: strings.Fields(network.NameServer),
+			Search:    strings.Fields(network.SearchDomain),
+		}
+	}
+
+	b, err := yaml.Marshal(&networkConfig{Version: 2, Ethernets: map[string]ethernetConfig{"eth0": eth}})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
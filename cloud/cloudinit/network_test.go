@@ -0,0 +1,57 @@
+package cloudinit_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/cloudinit"
+)
+
+var _ = Describe("GenerateNetworkConfigYaml", Label("unit", "cloudinit"), func() {
+	It("should use dhcp on IPv4 by default", func() {
+		config, err := cloudinit.GenerateNetworkConfigYaml("BC:24:11:00:00:01", infrav1.Network{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(`version: 2
+ethernets:
+    eth0:
+        match:
+            macaddress: bc:24:11:00:00:01
+        set-name: eth0
+        dhcp4: true
+`))
+	})
+
+	It("should set static addresses, routes and nameservers", func() {
+		network := infrav1.Network{
+			IPConfig: infrav1.IPConfig{
+				IP:      "192.168.0.10/24",
+				Gateway: "192.168.0.1",
+				IP6:     "auto",
+			},
+			NameServer:   "1.1.1.1 8.8.8.8",
+			SearchDomain: "example.com",
+		}
+		config, err := cloudinit.GenerateNetworkConfigYaml("bc:24:11:00:00:01", network)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(`version: 2
+ethernets:
+    eth0:
+        match:
+            macaddress: bc:24:11:00:00:01
+        set-name: eth0
+        accept-ra: true
+        addresses:
+            - 192.168.0.10/24
+        routes:
+            - to: 0.0.0.0/0
+              via: 192.168.0.1
+        nameservers:
+            addresses:
+                - 1.1.1.1
+                - 8.8.8.8
+            search:
+                - example.com
+`))
+	})
+})
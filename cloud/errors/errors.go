@@ -0,0 +1,111 @@
+// Package errors classifies errors from proxmox into terminal and transient ones.
+// Terminal errors are not resolved by retrying reconciliation
+// e.g. invalid vm parameters, missing bridge or wrong image checksum.
+// Other errors are regarded as transient and retried with backoff.
+package errors
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/rest"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// closed list of proxmox task errors which retrying does not resolve.
+// errors which may be transient or racy, e.g. the vm config or a storage
+// missing during a concurrent migration, are deliberately not listed
+var terminalMessages = []*regexp.Regexp{
+	// invalid vm parameters checked by the task
+	regexp.MustCompile(`(?i)parameter verification failed`),
+	// network device referring to a bridge which is not defined on the node
+	regexp.MustCompile(`bridge '[^']+' does not exist`),
+}
+
+// TerminalError is an error which is not resolved by retrying reconciliation
+type TerminalError struct {
+	Reason capierrors.MachineStatusError
+	Err    error
+}
+
+func (e *TerminalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// NewTerminalError wraps err into TerminalError with the given reason
+func NewTerminalError(reason capierrors.MachineStatusError, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TerminalError{Reason: reason, Err: err}
+}
+
+// AsTerminal returns TerminalError if err is (or wraps) a terminal error
+func AsTerminal(err error) (*TerminalError, bool) {
+	var terminal *TerminalError
+	if errors.As(err, &terminal) {
+		return terminal, true
+	}
+	return nil, false
+}
+
+// IsTerminal returns true if err is (or wraps) a terminal error
+func IsTerminal(err error) bool {
+	_, ok := AsTerminal(err)
+	return ok
+}
+
+// Classify wraps err into TerminalError with InvalidConfiguration reason
+// if proxmox rejected the request permanently. Otherwise err is returned as it is
+func Classify(err error) error {
+	if err == nil || IsTerminal(err) {
+		return err
+	}
+	if isBadRequest(err) || hasTerminalMessage(err) {
+		return NewTerminalError(capierrors.InvalidConfigurationMachineError, err)
+	}
+	return err
+}
+
+// proxmox api returns 400 for parameter verification failure
+func isBadRequest(err error) bool {
+	var restErr *rest.Error
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	msg := restErr.Error()
+	return strings.HasPrefix(msg, "400 ") && strings.Contains(strings.ToLower(msg), "parameter verification failed")
+}
+
+func hasTerminalMessage(err error) bool {
+	// api errors other than 400 (e.g. 500 for overloaded node) are transient
+	var restErr *rest.Error
+	if errors.As(err, &restErr) {
+		return false
+	}
+	for _, m := range terminalMessages {
+		if m.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnauthorized returns true if proxmox rejected the credentials or the ticket,
+// which happens when the secret is rotated or the ticket has been expired on proxmox side.
+// proxmox-go returns the failure in retrieving a ticket as a plain error
+func IsUnauthorized(err error) bool {
+	if err == nil {
+		return false
+	}
+	var restErr *rest.Error
+	if errors.As(err, &restErr) {
+		return strings.HasPrefix(restErr.Error(), "401 ")
+	}
+	return strings.Contains(err.Error(), "failed to retrieve session token: 401 ")
+}
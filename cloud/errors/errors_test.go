@@ -0,0 +1,100 @@
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/rest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
+)
+
+func TestErrors(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Errors Suite")
+}
+
+var _ = Describe("Classify", Label("unit", "errors"), func() {
+	Context("nil error", func() {
+		It("should return nil", func() {
+			Expect(infraerrors.Classify(nil)).To(BeNil())
+		})
+	})
+
+	Context("bad request from proxmox api", func() {
+		It("should be terminal", func() {
+			err := rest.NewError(http.StatusBadRequest, "Parameter verification failed.", []byte("net0: invalid format"))
+			terminal, ok := infraerrors.AsTerminal(infraerrors.Classify(fmt.Errorf("failed to create qemu: %w", err)))
+			Expect(ok).To(BeTrue())
+			Expect(terminal.Reason).To(Equal(capierrors.InvalidConfigurationMachineError))
+		})
+	})
+
+	Context("server error from proxmox api", func() {
+		It("should be transient", func() {
+			err := rest.NewError(http.StatusInternalServerError, "Internal Server Error", []byte("storage does not exist"))
+			Expect(infraerrors.IsTerminal(infraerrors.Classify(err))).To(BeFalse())
+		})
+	})
+
+	Context("failed proxmox task", func() {
+		It("should be terminal if bridge is missing", func() {
+			err := errors.New("bridge 'vmbr9' does not exist")
+			Expect(infraerrors.IsTerminal(infraerrors.Classify(err))).To(BeTrue())
+		})
+
+		It("should be transient if timed out", func() {
+			err := errors.New("task wait deadline exceeded")
+			Expect(infraerrors.IsTerminal(infraerrors.Classify(err))).To(BeFalse())
+		})
+
+		It("should be transient if the vm config or storage is missing for a while", func() {
+			for _, msg := range []string{
+				"Configuration file 'nodes/node1/qemu-server/100.conf' does not exist",
+				"storage 'local-lvm' does not exist",
+				"unable to parse volume ID 'local-lvm:vm-100-disk-0'",
+			} {
+				Expect(infraerrors.IsTerminal(infraerrors.Classify(errors.New(msg)))).To(BeFalse(), msg)
+			}
+		})
+	})
+
+	Context("bad request from proxmox api other than parameter verification", func() {
+		It("should be transient", func() {
+			err := rest.NewError(http.StatusBadRequest, "Bad Request", []byte("unable to parse request"))
+			Expect(infraerrors.IsTerminal(infraerrors.Classify(err))).To(BeFalse())
+		})
+	})
+
+	Context("already terminal error", func() {
+		It("should keep the reason", func() {
+			err := infraerrors.NewTerminalError(capierrors.CreateMachineError, errors.New("foo"))
+			terminal, ok := infraerrors.AsTerminal(infraerrors.Classify(err))
+			Expect(ok).To(BeTrue())
+			Expect(terminal.Reason).To(Equal(capierrors.CreateMachineError))
+		})
+	})
+})
+
+var _ = Describe("IsUnauthorized", Label("unit", "errors"), func() {
+	It("should be true for 401 from proxmox api", func() {
+		err := rest.NewError(http.StatusUnauthorized, "401 permission denied - invalid PVE ticket", nil)
+		Expect(infraerrors.IsUnauthorized(fmt.Errorf("failed to get nodes: %w", err))).To(BeTrue())
+	})
+
+	It("should be true for failed login", func() {
+		err := errors.New(`Get "https://192.168.0.10:8006/api2/json/version": proxmox-go: Authentication faild: failed to retrieve session token: 401 - 401 authentication failure - `)
+		Expect(infraerrors.IsUnauthorized(err)).To(BeTrue())
+	})
+
+	It("should be false for other errors", func() {
+		Expect(infraerrors.IsUnauthorized(rest.NewError(http.StatusForbidden, "403 Permission check failed", nil))).To(BeFalse())
+		Expect(infraerrors.IsUnauthorized(errors.New("connection refused"))).To(BeFalse())
+		Expect(infraerrors.IsUnauthorized(nil)).To(BeFalse())
+	})
+})
@@ -2,13 +2,17 @@ package cloud
 
 import (
 	"context"
+	"time"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
 )
 
 type Reconciler interface {
@@ -18,6 +22,9 @@ type Reconciler interface {
 
 type Client interface {
 	CloudClient() *proxmox.Service
+	// UploadClient returns nil unless proxmox is accessed with API token only.
+	// API token cannot open node shells so files are uploaded via the API instead
+	UploadClient() *upload.Client
 }
 
 type Cluster interface {
@@ -41,6 +48,8 @@ type ClusterGetter interface {
 type ClusterSettter interface {
 	SetControlPlaneEndpoint(endpoint clusterv1.APIEndpoint)
 	SetStorage(storage infrav1.Storage)
+	MarkConditionTrue(t clusterv1.ConditionType)
+	MarkConditionFalse(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{})
 }
 
 // MachineGetter is an interface which can get machine information.
@@ -67,6 +76,16 @@ type MachineGetter interface {
 	GetHardware() infrav1.Hardware
 	GetVMID() *int
 	GetOptions() infrav1.Options
+	GetNodeSelector() map[string]string
+	GetNodeAffinity() *corev1.NodeAffinity
+	GetSchedulerProfile() string
+	GetShutdownTimeout() time.Duration
+	GetCurrentNode() string
+	GetProvisioningPhase() infrav1.ProvisioningPhase
+	GetTask() *infrav1.ProxmoxTask
+	UID() types.UID
+	GetClusterUID() types.UID
+	GetCondition(t clusterv1.ConditionType) *clusterv1.Condition
 }
 
 // MachineSetter is an interface which can set machine information.
@@ -77,6 +96,14 @@ type MachineSetter interface {
 	SetVMID(vmid int)
 	SetConfigStatus(config api.VirtualMachineConfig)
 	SetStorage(name string)
+	SetCurrentNode(name string)
+	SetProvisioningPhase(phase infrav1.ProvisioningPhase)
+	SetTask(task *infrav1.ProxmoxTask)
+	SetSchedulingDecision(decision string)
+	Eventf(reason, messageFormat string, args ...interface{})
+	Warnf(reason, messageFormat string, args ...interface{})
+	MarkConditionTrue(t clusterv1.ConditionType)
+	MarkConditionFalse(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{})
 	// SetFailureMessage(v error)
 	// SetFailureReason(v capierrors.MachineStatusError)
 	// SetAnnotation(key, value string)
@@ -0,0 +1,154 @@
+// Package metrics defines provider specific prometheus metrics.
+// they are registered to the controller-runtime registry and served
+// together with controller-runtime metrics on the diagnostics address.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "cappx"
+
+// results of scheduling attempts
+const (
+	ResultScheduled = "scheduled"
+	ResultFailed    = "failed"
+)
+
+// results of image downloads and cache lookups
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+// methods downloading os images into proxmox nodes
+const (
+	// wget in node shell
+	DownloadMethodShell = "shell"
+	// download-url API
+	DownloadMethodDownloadURL = "download-url"
+)
+
+var (
+	// SchedulingAttempts counts scheduling attempts of qemus by result and failure reason
+	SchedulingAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "schedule_attempts_total",
+		Help:      "Number of attempts to schedule qemus by result and failure reason.",
+	}, []string{"result", "reason"})
+
+	// PluginFilteredNodes counts nodes rejected by filter plugins
+	PluginFilteredNodes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "plugin_filtered_nodes_total",
+		Help:      "Number of proxmox nodes rejected by filter plugins.",
+	}, []string{"plugin"})
+
+	// PluginErrors counts errors of score plugins
+	PluginErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "plugin_errors_total",
+		Help:      "Number of errors returned by score plugins.",
+	}, []string{"plugin"})
+
+	// QueueDepth is the number of qemus waiting in the scheduling queues
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "queue_depth",
+		Help:      "Number of qemus waiting in the scheduling queues.",
+	})
+
+	// QueueWaitDuration is the time qemus wait in the scheduling queue
+	QueueWaitDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "queue_wait_duration_seconds",
+		Help:      "Time qemus wait in the scheduling queue until they are scheduled.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+
+	// APIRequestDuration is the latency of proxmox API requests by endpoint
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "proxmox_api",
+		Name:      "request_duration_seconds",
+		Help:      "Time to the first response byte of proxmox API requests by endpoint.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"endpoint"})
+
+	// APIRequestErrors counts failed proxmox API requests by endpoint and code
+	APIRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "proxmox_api",
+		Name:      "request_errors_total",
+		Help:      "Number of failed proxmox API requests by endpoint and code, the status code of error responses or connect, tls or transport for requests failed without response.",
+	}, []string{"endpoint", "code"})
+
+	// ImageDownloadDuration is the duration of os image downloads into proxmox nodes
+	ImageDownloadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "image",
+		Name:      "download_duration_seconds",
+		Help:      "Duration of os image downloads into proxmox nodes by method and result.",
+		Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"method", "result"})
+
+	// ImageCacheLookups counts lookups of os images already downloaded into proxmox nodes
+	ImageCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "image",
+		Name:      "cache_lookups_total",
+		Help:      "Number of lookups of os images already downloaded into proxmox nodes by result (hit, miss).",
+	}, []string{"result"})
+
+	// ImageCacheEvictions counts os images evicted from proxmox nodes
+	ImageCacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "image",
+		Name:      "cache_evictions_total",
+		Help:      "Number of os images evicted from proxmox nodes by result (success, failure).",
+	}, []string{"result"})
+
+	// MachineTimeToRunning is the time from the creation of ProxmoxMachines until their instances run
+	MachineTimeToRunning = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "machine",
+		Name:      "time_to_running_seconds",
+		Help:      "Time from the creation of ProxmoxMachines until their instances become running.",
+		Buckets:   prometheus.ExponentialBuckets(15, 2, 10),
+	})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		SchedulingAttempts,
+		PluginFilteredNodes,
+		PluginErrors,
+		QueueDepth,
+		QueueWaitDuration,
+		APIRequestDuration,
+		APIRequestErrors,
+		ImageDownloadDuration,
+		ImageCacheLookups,
+		ImageCacheEvictions,
+		MachineTimeToRunning,
+	)
+}
+
+// ObserveImageDownload records the duration of the image download started at start
+func ObserveImageDownload(method string, start time.Time, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	ImageDownloadDuration.WithLabelValues(method, result).Observe(time.Since(start).Seconds())
+}
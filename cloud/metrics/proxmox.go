@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// codes of proxmox API requests failed without response
+const (
+	CodeConnect   = "connect"
+	CodeTLS       = "tls"
+	CodeTransport = "transport"
+)
+
+// NewProxmoxTransport returns the transport recording metrics of proxmox API requests sent
+// through base. the endpoint label is host:port of the request
+func NewProxmoxTransport(base http.RoundTripper) http.RoundTripper {
+	return &apiTransport{base: base}
+}
+
+type apiTransport struct {
+	base http.RoundTripper
+}
+
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointHostPort(req.URL)
+	start := time.Now()
+	rsp, err := t.base.RoundTrip(req)
+	if err != nil {
+		APIRequestErrors.WithLabelValues(endpoint, failureCode(err)).Inc()
+		return rsp, err
+	}
+	APIRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	if rsp.StatusCode >= http.StatusBadRequest {
+		APIRequestErrors.WithLabelValues(endpoint, strconv.Itoa(rsp.StatusCode)).Inc()
+	}
+	return rsp, nil
+}
+
+// failureCode returns the code of the request failed without response
+func failureCode(err error) string {
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) {
+		return CodeTLS
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return CodeConnect
+	}
+	return CodeTransport
+}
+
+// return host:port of the url
+func endpointHostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
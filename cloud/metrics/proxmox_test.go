@@ -0,0 +1,87 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
+
+var _ = Describe("NewProxmoxTransport", Label("unit", "metrics"), func() {
+	var server *httptest.Server
+	var endpoint string
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api2/json/version":
+				w.WriteHeader(http.StatusOK)
+			case "/api2/json/nodes":
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		endpoint = strings.TrimPrefix(server.URL, "http://")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	client := func() *http.Client {
+		return &http.Client{Transport: metrics.NewProxmoxTransport(http.DefaultTransport)}
+	}
+
+	get := func(url string) {
+		rsp, err := client().Get(url)
+		if err == nil {
+			rsp.Body.Close()
+		}
+	}
+
+	failures := func(code string) float64 {
+		return testutil.ToFloat64(metrics.APIRequestErrors.WithLabelValues(endpoint, code))
+	}
+
+	It("should record the duration of requests", func() {
+		series := func() int {
+			return testutil.CollectAndCount(metrics.APIRequestDuration, "cappx_proxmox_api_request_duration_seconds")
+		}
+		before := series()
+		get(server.URL + "/api2/json/version")
+		Expect(series()).To(Equal(before + 1))
+		Expect(failures("200")).To(BeZero())
+	})
+
+	It("should count error responses by status code", func() {
+		get(server.URL + "/api2/json/nodes")
+		get(server.URL + "/api2/json/cluster/resources")
+		get(server.URL + "/api2/json/cluster/resources")
+		Expect(failures("401")).To(Equal(float64(1)))
+		Expect(failures("500")).To(Equal(float64(2)))
+	})
+
+	It("should count requests failed to connect", func() {
+		url := server.URL
+		server.Close()
+		get(url + "/api2/json/version")
+		Expect(failures(metrics.CodeConnect)).To(Equal(float64(1)))
+	})
+
+	It("should count requests failed in the TLS handshake", func() {
+		get("https://" + endpoint + "/api2/json/version")
+		Expect(failures(metrics.CodeTLS)).To(Equal(float64(1)))
+	})
+})
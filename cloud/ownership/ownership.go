@@ -0,0 +1,73 @@
+package ownership
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// prefix of the tag proving the qemu is created by the ProxmoxMachine
+	MachineTagPrefix = "cappx-"
+
+	// prefix of the tag proving the qemu belongs to the ProxmoxCluster
+	ClusterTagPrefix = "cappx-cluster-"
+)
+
+// MachineTag returns the tag put on the qemu created by the ProxmoxMachine with the uid
+func MachineTag(uid types.UID) string {
+	if uid == "" {
+		return ""
+	}
+	return MachineTagPrefix + string(uid)
+}
+
+// ClusterTag returns the tag put on the qemu belonging to the ProxmoxCluster with the uid
+func ClusterTag(uid types.UID) string {
+	if uid == "" {
+		return ""
+	}
+	return ClusterTagPrefix + string(uid)
+}
+
+// Tags returns the owner tags joined in proxmox format. empty uids are skipped
+func Tags(clusterUID, machineUID types.UID) string {
+	tags := []string{}
+	for _, tag := range []string{ClusterTag(clusterUID), MachineTag(machineUID)} {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return strings.Join(tags, ";")
+}
+
+// HasTag returns true if the tags contain the tag
+func HasTag(tags, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, t := range splitTags(tags) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MachineUID returns the uid of the ProxmoxMachine which created the qemu
+func MachineUID(tags string) (types.UID, bool) {
+	for _, t := range splitTags(tags) {
+		if strings.HasPrefix(t, ClusterTagPrefix) {
+			continue
+		}
+		if uid := strings.TrimPrefix(t, MachineTagPrefix); uid != t && uid != "" {
+			return types.UID(uid), true
+		}
+	}
+	return "", false
+}
+
+// proxmox accepts ';', ',' and ' ' as tag separators
+func splitTags(tags string) []string {
+	return strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' || r == ' ' })
+}
@@ -0,0 +1,65 @@
+package ownership_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
+)
+
+func TestOwnership(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ownership Suite")
+}
+
+var _ = Describe("HasTag", Label("unit", "ownership"), func() {
+	uid := types.UID("0e6b5ab4-1c7a-4b7e-9d3f-6f1d1e0c2a11")
+
+	Context("tags contain machine tag", func() {
+		It("should return true", func() {
+			tags := "foo;" + ownership.MachineTag(uid) + ";bar"
+			Expect(ownership.HasTag(tags, ownership.MachineTag(uid))).To(BeTrue())
+		})
+	})
+
+	Context("tags contain machine tag of another machine", func() {
+		It("should return false", func() {
+			tags := "foo;" + ownership.MachineTag(types.UID("another"))
+			Expect(ownership.HasTag(tags, ownership.MachineTag(uid))).To(BeFalse())
+		})
+	})
+
+	Context("uid is empty", func() {
+		It("should return false", func() {
+			Expect(ownership.MachineTag("")).To(BeEmpty())
+			Expect(ownership.HasTag("cappx-", ownership.MachineTag(""))).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Tags", Label("unit", "ownership"), func() {
+	It("should join cluster and machine tags", func() {
+		Expect(ownership.Tags("c", "m")).To(Equal("cappx-cluster-c;cappx-m"))
+	})
+
+	It("should skip empty uids", func() {
+		Expect(ownership.Tags("", "m")).To(Equal("cappx-m"))
+		Expect(ownership.Tags("", "")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("MachineUID", Label("unit", "ownership"), func() {
+	It("should return machine uid ignoring cluster tag", func() {
+		uid, ok := ownership.MachineUID("foo," + ownership.Tags("c", "m"))
+		Expect(ok).To(BeTrue())
+		Expect(uid).To(Equal(types.UID("m")))
+	})
+
+	It("should return false without machine tag", func() {
+		_, ok := ownership.MachineUID("foo;" + ownership.ClusterTag("c"))
+		Expect(ok).To(BeFalse())
+	})
+})
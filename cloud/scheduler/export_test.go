@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins"
+)
+
+func (m *Manager) RegisterScheduler(ipAddress string, sched *Scheduler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.table[schedulerID{IPAddress: ipAddress}] = sched
+}
+
+func (s *Scheduler) Registry(profile string) (plugins.PluginRegistry, error) {
+	return s.getRegistry(framework.ContextWithProfile(context.Background(), profile))
+}
+
+func (m *Manager) PluginConfigs() plugins.PluginConfigs {
+	return m.pluginConfigs()
+}
@@ -5,6 +5,7 @@ type CycleState struct {
 	err       error
 	messages  map[string]string
 	result    SchedulerResult
+	decision  Decision
 }
 
 type SchedulerResult struct {
@@ -55,6 +56,11 @@ func (c *CycleState) Result() SchedulerResult {
 	return c.result
 }
 
+// return the record of the cycle which plugins' outcomes and the result are put into
+func (c *CycleState) Decision() *Decision {
+	return &c.decision
+}
+
 func (r *SchedulerResult) Node() string {
 	return r.node
 }
@@ -0,0 +1,98 @@
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Decision is the record of a scheduling cycle telling why the node was selected
+type Decision struct {
+	// scheduler profile used for the cycle. empty means the default one
+	Profile string `json:"profile,omitempty"`
+
+	// map[node name]outcome of candidate nodes
+	Nodes map[string]NodeDecision `json:"nodes,omitempty"`
+
+	// selected node, vmid and storage. empty if scheduling failed
+	Node    string `json:"node,omitempty"`
+	VMID    int    `json:"vmid,omitempty"`
+	Storage string `json:"storage,omitempty"`
+
+	// why scheduling failed
+	Error string `json:"error,omitempty"`
+}
+
+// NodeDecision is the outcome of filtering and scoring of a node
+type NodeDecision struct {
+	// filter plugin which filtered out the node and its message. empty if the node passed all filters
+	FilteredBy string `json:"filteredBy,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+
+	// map[score plugin name]score and their sum. empty if the node was not scored
+	Scores map[string]int64 `json:"scores,omitempty"`
+	Total  int64            `json:"total,omitempty"`
+}
+
+// record the node filtered out by the plugin
+func (d *Decision) SetFiltered(node, plugin, reason string) {
+	d.initNodes()
+	d.Nodes[node] = NodeDecision{FilteredBy: plugin, Reason: reason}
+}
+
+// record the node passed all filters
+func (d *Decision) SetFeasible(node string) {
+	d.initNodes()
+	d.Nodes[node] = NodeDecision{}
+}
+
+// record scores of the node per plugin
+func (d *Decision) SetScores(node string, scores map[string]int64) {
+	d.initNodes()
+	n := d.Nodes[node]
+	n.Scores = scores
+	n.Total = 0
+	for _, score := range scores {
+		n.Total += score
+	}
+	d.Nodes[node] = n
+}
+
+func (d *Decision) initNodes() {
+	if d.Nodes == nil {
+		d.Nodes = map[string]NodeDecision{}
+	}
+}
+
+// Summary returns one line description of the decision e.g.
+// "scores: node1=180, node2=150; filtered out: node3 by CPUOvercommit (exceed cpu overcommit ratio)"
+func (d *Decision) Summary() string {
+	if len(d.Nodes) == 0 {
+		return "no nodes evaluated"
+	}
+	names := make([]string, 0, len(d.Nodes))
+	for name := range d.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var scored, filtered []string
+	for _, name := range names {
+		n := d.Nodes[name]
+		switch {
+		case n.FilteredBy != "":
+			filtered = append(filtered, fmt.Sprintf("%s by %s (%s)", name, n.FilteredBy, n.Reason))
+		case n.Scores != nil:
+			scored = append(scored, fmt.Sprintf("%s=%d", name, n.Total))
+		default:
+			scored = append(scored, name)
+		}
+	}
+	summary := "scores: " + strings.Join(scored, ", ")
+	if len(scored) == 0 {
+		summary = "no feasible nodes"
+	}
+	if len(filtered) > 0 {
+		summary += "; filtered out: " + strings.Join(filtered, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,48 @@
+package framework_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+)
+
+var _ = Describe("Decision", Label("unit", "framework"), func() {
+	var decision *framework.Decision
+
+	BeforeEach(func() {
+		state := framework.NewCycleState()
+		decision = state.Decision()
+	})
+
+	It("should summarize scores and filtered nodes", func() {
+		decision.SetFiltered("node3", "CPUOvercommit", "exceed cpu overcommit ratio")
+		decision.SetFeasible("node1")
+		decision.SetFeasible("node2")
+		decision.SetScores("node1", map[string]int64{"NodeResource": 80, "NodeAffinity": 100})
+		decision.SetScores("node2", map[string]int64{"NodeResource": 50, "NodeAffinity": 100})
+
+		Expect(decision.Nodes["node1"].Total).To(Equal(int64(180)))
+		Expect(decision.Summary()).To(Equal("scores: node1=180, node2=150; filtered out: node3 by CPUOvercommit (exceed cpu overcommit ratio)"))
+	})
+
+	It("should summarize decision without feasible nodes", func() {
+		decision.SetFiltered("node1", "NodeMaintenance", "node is not online")
+		Expect(decision.Summary()).To(Equal("no feasible nodes; filtered out: node1 by NodeMaintenance (node is not online)"))
+	})
+
+	It("should summarize decision without nodes", func() {
+		Expect(decision.Summary()).To(Equal("no nodes evaluated"))
+	})
+
+	It("should be marshaled to JSON", func() {
+		decision.Profile = "dev"
+		decision.SetFeasible("node1")
+		decision.Node, decision.VMID, decision.Storage = "node1", 100, "local-lvm"
+		b, err := json.Marshal(decision)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"profile":"dev","nodes":{"node1":{}},"node":"node1","vmid":100,"storage":"local-lvm"}`))
+	})
+})
@@ -2,9 +2,11 @@ package framework
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type Status struct {
@@ -23,6 +25,9 @@ func (s *Status) Code() int {
 }
 
 func (s *Status) SetCode(code int) {
+	if s == nil {
+		return
+	}
 	s.code = code
 }
 
@@ -41,20 +46,50 @@ func (s *Status) SetFailedPlugin(name string) {
 	s.failedPlugin = name
 }
 
+// IsSuccess returns true if the status is nil or has code 0
 func (s *Status) IsSuccess() bool {
-	return s.code == 0
+	return s == nil || s.code == 0
 }
 
 func (s *Status) Error() error {
 	return s.err
 }
 
+const (
+	// NodeStatusOnline is the status of proxmox node which is available
+	NodeStatusOnline = "online"
+
+	// HANodeStateMaintenance is the HA manager's node state for nodes in maintenance mode
+	HANodeStateMaintenance = "maintenance"
+)
+
 // NodeInfo is node level aggregated information
 type NodeInfo struct {
 	node *api.Node
 
 	// qemus assigned to the node
 	qemus []*api.VirtualMachine
+	// node config (e.g. notes) of the node
+	config NodeConfig
+	// node state seen by HA manager (online, maintenance etc.)
+	// empty if HA is not configured
+	haState string
+}
+
+// NodeConfig is a subset of proxmox node config (/nodes/{node}/config)
+type NodeConfig struct {
+	Description string `json:"description,omitempty"`
+}
+
+// haManagerStatus is a subset of HA manager status (/cluster/ha/status/manager_status)
+type haManagerStatus struct {
+	ManagerStatus struct {
+		NodeStatus map[string]string `json:"node_status,omitempty"`
+	} `json:"manager_status,omitempty"`
+}
+
+func NewNodeInfo(node *api.Node, qemus []*api.VirtualMachine, config NodeConfig, haState string) *NodeInfo {
+	return &NodeInfo{node: node, qemus: qemus, config: config, haState: haState}
 }
 
 func GetNodeInfoList(ctx context.Context, client *proxmox.Service) ([]*NodeInfo, error) {
@@ -62,17 +97,52 @@ func GetNodeInfoList(ctx context.Context, client *proxmox.Service) ([]*NodeInfo,
 	if err != nil {
 		return nil, err
 	}
+	haStates := getHANodeStates(ctx, client)
 	nodeInfos := []*NodeInfo{}
 	for _, node := range nodes {
+		// offline nodes can't answer node level api, so don't ask them
+		if node.Status != NodeStatusOnline {
+			nodeInfos = append(nodeInfos, &NodeInfo{node: node, haState: haStates[node.Node]})
+			continue
+		}
 		qemus, err := client.RESTClient().GetVirtualMachines(ctx, node.Node)
 		if err != nil {
 			return nil, err
 		}
-		nodeInfos = append(nodeInfos, &NodeInfo{node: node, qemus: qemus})
+		config := getNodeConfig(ctx, client, node.Node)
+		nodeInfo := &NodeInfo{node: node, qemus: qemus, config: config, haState: haStates[node.Node]}
+		if vmid, ok := MovingQEMUFromContext(ctx); ok {
+			nodeInfo = nodeInfo.WithoutQEMU(vmid)
+		}
+		nodeInfos = append(nodeInfos, nodeInfo)
 	}
 	return nodeInfos, nil
 }
 
+// return node config of the node. node config is optional information
+// so errors are logged and empty config (i.e. no notes) is returned
+func getNodeConfig(ctx context.Context, client *proxmox.Service, node string) NodeConfig {
+	var config NodeConfig
+	if err := client.RESTClient().Get(ctx, fmt.Sprintf("/nodes/%s/config", node), &config); err != nil {
+		log.FromContext(ctx).Error(err, "failed to get node config", "node", node)
+		return NodeConfig{}
+	}
+	return config
+}
+
+// return map[node name]HA node state.
+// HA status is optional information so errors are ignored and empty map is returned
+func getHANodeStates(ctx context.Context, client *proxmox.Service) map[string]string {
+	var status haManagerStatus
+	if err := client.RESTClient().Get(ctx, "/cluster/ha/status/manager_status", &status); err != nil {
+		return map[string]string{}
+	}
+	if status.ManagerStatus.NodeStatus == nil {
+		return map[string]string{}
+	}
+	return status.ManagerStatus.NodeStatus
+}
+
 func (n NodeInfo) Node() *api.Node {
 	return n.node
 }
@@ -81,6 +151,34 @@ func (n NodeInfo) QEMUs() []*api.VirtualMachine {
 	return n.qemus
 }
 
+// return copy of the node info without the qemu of the vmid
+func (n NodeInfo) WithoutQEMU(vmid int) *NodeInfo {
+	qemus := make([]*api.VirtualMachine, 0, len(n.qemus))
+	for _, q := range n.qemus {
+		if q.VMID != vmid {
+			qemus = append(qemus, q)
+		}
+	}
+	n.qemus = qemus
+	return &n
+}
+
+func (n NodeInfo) Config() NodeConfig {
+	return n.config
+}
+
+func (n NodeInfo) HAState() string {
+	return n.haState
+}
+
+// return true if the node is online
+func (n NodeInfo) IsOnline() bool {
+	return n.node.Status == NodeStatusOnline
+}
+
+// MaxNodeScore is the maximum score of score plugins scoring nodes in a fixed range
+const MaxNodeScore int64 = 100
+
 // NodeScoreList declares a list of nodes and their scores.
 type NodeScoreList []NodeScore
 
@@ -2,7 +2,12 @@ package framework_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -19,3 +24,45 @@ var _ = Describe("GetNodeInfoList", Label("integration", "framework"), func() {
 	})
 
 })
+
+var _ = Describe("GetNodeInfoList", Label("unit", "framework"), func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api2/json/nodes":
+				fmt.Fprint(w, `{"data":[{"node":"node1","status":"online"}]}`)
+			case "/api2/json/nodes/node1/qemu":
+				fmt.Fprint(w, `{"data":[{"vmid":100}]}`)
+			default:
+				// node config and HA status are not available
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should treat the node as having no notes if node config is not available", func() {
+		client, err := proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+		nodeInfos, err := framework.GetNodeInfoList(context.Background(), client)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeInfos).To(HaveLen(1))
+		Expect(nodeInfos[0].QEMUs()).To(HaveLen(1))
+		Expect(nodeInfos[0].Config()).To(Equal(framework.NodeConfig{}))
+	})
+})
+
+var _ = Describe("WithoutQEMU", Label("unit", "framework"), func() {
+	It("should exclude the qemu without changing the original node info", func() {
+		node := &api.Node{Node: "foo", Status: "online"}
+		qemus := []*api.VirtualMachine{{VMID: 100}, {VMID: 101}}
+		nodeInfo := framework.NewNodeInfo(node, qemus, framework.NodeConfig{}, "")
+		Expect(nodeInfo.WithoutQEMU(100).QEMUs()).To(Equal([]*api.VirtualMachine{{VMID: 101}}))
+		Expect(nodeInfo.QEMUs()).To(HaveLen(2))
+	})
+})
@@ -1,6 +1,10 @@
 package framework
 
-import "context"
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 type CtxKey string
 
@@ -12,3 +16,56 @@ func ContextWithMap(ctx context.Context, m map[string]string) context.Context {
 	}
 	return ctx
 }
+
+// NodePlacement is node selection constraints of qemu
+type NodePlacement struct {
+	NodeSelector map[string]string
+	NodeAffinity *corev1.NodeAffinity
+}
+
+type nodePlacementKey struct{}
+
+// bind node placement constraints to context
+func ContextWithNodePlacement(ctx context.Context, placement NodePlacement) context.Context {
+	return context.WithValue(ctx, nodePlacementKey{}, placement)
+}
+
+// return node placement constraints bound to context.
+// return empty NodePlacement if nothing is bound
+func NodePlacementFromContext(ctx context.Context) NodePlacement {
+	placement, ok := ctx.Value(nodePlacementKey{}).(NodePlacement)
+	if !ok {
+		return NodePlacement{}
+	}
+	return placement
+}
+
+type profileKey struct{}
+
+// bind name of the scheduler profile used for the qemu to context
+func ContextWithProfile(ctx context.Context, profile string) context.Context {
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// return name of the scheduler profile bound to context.
+// return empty name (default profile) if nothing is bound
+func ProfileFromContext(ctx context.Context) string {
+	profile, _ := ctx.Value(profileKey{}).(string)
+	return profile
+}
+
+type movingQEMUKey struct{}
+
+// bind vmid of the existing qemu being moved to another node to context.
+// the qemu is excluded from node infos so that its own resources are not
+// counted twice on the node it is currently assigned to
+func ContextWithMovingQEMU(ctx context.Context, vmid int) context.Context {
+	return context.WithValue(ctx, movingQEMUKey{}, vmid)
+}
+
+// return vmid of the qemu being moved bound to context.
+// return false if nothing is bound
+func MovingQEMUFromContext(ctx context.Context) (int, bool) {
+	vmid, ok := ctx.Value(movingQEMUKey{}).(int)
+	return vmid, ok
+}
@@ -37,3 +37,40 @@ var _ = Describe("ContextWithMap", Label("unit", "framework"), func() {
 		})
 	})
 })
+
+var _ = Describe("ContextWithNodePlacement", Label("unit", "framework"), func() {
+	c := context.Background()
+
+	Context("without node placement", func() {
+		It("should return empty node placement", func() {
+			Expect(framework.NodePlacementFromContext(c)).To(Equal(framework.NodePlacement{}))
+		})
+	})
+
+	Context("with node placement", func() {
+		It("should get node placement", func() {
+			placement := framework.NodePlacement{NodeSelector: map[string]string{"gpu": "true"}}
+			ctx := framework.ContextWithNodePlacement(c, placement)
+			Expect(framework.NodePlacementFromContext(ctx)).To(Equal(placement))
+		})
+	})
+})
+
+var _ = Describe("ContextWithMovingQEMU", Label("unit", "framework"), func() {
+	c := context.Background()
+
+	Context("without moving qemu", func() {
+		It("should return false", func() {
+			_, ok := framework.MovingQEMUFromContext(c)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("with moving qemu", func() {
+		It("should get vmid", func() {
+			vmid, ok := framework.MovingQEMUFromContext(framework.ContextWithMovingQEMU(c, 100))
+			Expect(ok).To(BeTrue())
+			Expect(vmid).To(Equal(100))
+		})
+	})
+})
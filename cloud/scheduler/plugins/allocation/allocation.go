@@ -0,0 +1,41 @@
+package allocation
+
+import (
+	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+)
+
+// return fractions of cpus and memory of the node allocated to the qemus assigned to it
+// and the qemu being scheduled. unlike the usage reported by proxmox, stopped qemus
+// count since they can be started anytime. templates are not counted.
+// fractions are capped at 1 so that overcommitted nodes score as fully allocated
+func allocatedFractions(config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (cpu, mem float64) {
+	sockets := config.Sockets
+	if sockets == 0 {
+		sockets = 1
+	}
+	cpus := config.Cores * sockets
+	mems := 1024 * 1024 * config.Memory
+	for _, q := range nodeInfo.QEMUs() {
+		if q.Template == 1 {
+			continue
+		}
+		cpus += q.Cpus
+		mems += q.MaxMem
+	}
+	node := nodeInfo.Node()
+	return fraction(cpus, node.MaxCpu), fraction(mems, node.MaxMem)
+}
+
+// return requested/capacity in [0, 1]. node without capacity is treated as fully allocated
+func fraction(requested, capacity int) float64 {
+	if capacity <= 0 {
+		return 1
+	}
+	f := float64(requested) / float64(capacity)
+	if f > 1 {
+		return 1
+	}
+	return f
+}
@@ -0,0 +1,85 @@
+package allocation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/allocation"
+)
+
+func TestAllocation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "allocation plugins")
+}
+
+const gib = 1024 * 1024 * 1024
+
+var _ = Describe("Score", Label("unit", "plugins"), func() {
+	ctx := context.Background()
+	var state framework.CycleState
+
+	// 2 cores and 2GiB memory
+	config := api.VirtualMachineCreateOptions{Cores: 2, Memory: 2048}
+
+	// node with 16 cpus and 32GiB memory
+	newNodeInfo := func(qemus ...*api.VirtualMachine) *framework.NodeInfo {
+		node := &api.Node{Node: "foo", Status: "online", MaxCpu: 16, MaxMem: 32 * gib}
+		return framework.NewNodeInfo(node, qemus, framework.NodeConfig{}, "online")
+	}
+
+	// 8 cpus and 8GiB memory allocated in total with the qemu being scheduled
+	cpuHeavy := newNodeInfo(
+		&api.VirtualMachine{Cpus: 6, MaxMem: 6 * gib, Status: api.ProcessStatusRunning},
+		&api.VirtualMachine{Cpus: 4, MaxMem: 4 * gib, Template: 1},
+	)
+	// 8 cpus and 16GiB memory allocated in total with the qemu being scheduled
+	balanced := newNodeInfo(
+		&api.VirtualMachine{Cpus: 6, MaxMem: 14 * gib, Status: api.ProcessStatusStopped},
+	)
+
+	BeforeEach(func() {
+		state = framework.NewCycleState()
+	})
+
+	score := func(pl framework.NodeScorePlugin, nodeInfo *framework.NodeInfo) int64 {
+		score, status := pl.Score(ctx, &state, config, nodeInfo)
+		Expect(status.IsSuccess()).To(BeTrue())
+		return score
+	}
+
+	Context("LeastAllocated", func() {
+		It("should score nodes by unallocated resources", func() {
+			pl := &allocation.LeastAllocated{}
+			Expect(score(pl, newNodeInfo())).To(Equal(int64(90)))
+			Expect(score(pl, cpuHeavy)).To(Equal(int64(62)))
+			Expect(score(pl, balanced)).To(Equal(int64(50)))
+		})
+	})
+
+	Context("MostAllocated", func() {
+		It("should score nodes by allocated resources", func() {
+			pl := &allocation.MostAllocated{}
+			Expect(score(pl, newNodeInfo())).To(Equal(int64(9)))
+			Expect(score(pl, cpuHeavy)).To(Equal(int64(37)))
+			Expect(score(pl, balanced)).To(Equal(int64(50)))
+		})
+
+		It("should treat overcommitted node as fully allocated", func() {
+			pl := &allocation.MostAllocated{}
+			Expect(score(pl, newNodeInfo(&api.VirtualMachine{Cpus: 64, MaxMem: 64 * gib}))).To(Equal(framework.MaxNodeScore))
+		})
+	})
+
+	Context("BalancedAllocation", func() {
+		It("should score nodes by balance of allocated cpu and memory", func() {
+			pl := &allocation.BalancedAllocation{}
+			Expect(score(pl, balanced)).To(Equal(framework.MaxNodeScore))
+			Expect(score(pl, cpuHeavy)).To(Equal(int64(87)))
+		})
+	})
+})
@@ -0,0 +1,34 @@
+package allocation
+
+import (
+	"context"
+	"math"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+)
+
+// BalancedAllocation favors the nodes whose allocated fractions of cpu and memory
+// are close to each other so that nodes don't run out of one while the other is left unused
+type BalancedAllocation struct{}
+
+var _ framework.NodeScorePlugin = &BalancedAllocation{}
+
+const (
+	BalancedAllocationName = names.BalancedAllocation
+)
+
+func (pl *BalancedAllocation) Name() string {
+	return BalancedAllocationName
+}
+
+// score = MaxNodeScore * (1 - standard deviation of allocated fractions of cpu and memory).
+// for two fractions the standard deviation is half of their difference
+func (pl *BalancedAllocation) Score(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	cpu, mem := allocatedFractions(config, nodeInfo)
+	std := math.Abs(cpu-mem) / 2
+	score := int64(float64(framework.MaxNodeScore) * (1 - std))
+	return score, framework.NewStatus()
+}
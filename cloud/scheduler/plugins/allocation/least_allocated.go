@@ -0,0 +1,31 @@
+package allocation
+
+import (
+	"context"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+)
+
+// LeastAllocated favors the nodes with fewer cpus and memory allocated
+// so that qemus are spread across the nodes
+type LeastAllocated struct{}
+
+var _ framework.NodeScorePlugin = &LeastAllocated{}
+
+const (
+	LeastAllocatedName = names.LeastAllocated
+)
+
+func (pl *LeastAllocated) Name() string {
+	return LeastAllocatedName
+}
+
+// score = MaxNodeScore * mean of unallocated fractions of cpu and memory
+func (pl *LeastAllocated) Score(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	cpu, mem := allocatedFractions(config, nodeInfo)
+	score := int64(float64(framework.MaxNodeScore) * ((1 - cpu) + (1 - mem)) / 2)
+	return score, framework.NewStatus()
+}
@@ -0,0 +1,31 @@
+package allocation
+
+import (
+	"context"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+)
+
+// MostAllocated favors the nodes with more cpus and memory allocated
+// so that qemus are packed into fewer nodes
+type MostAllocated struct{}
+
+var _ framework.NodeScorePlugin = &MostAllocated{}
+
+const (
+	MostAllocatedName = names.MostAllocated
+)
+
+func (pl *MostAllocated) Name() string {
+	return MostAllocatedName
+}
+
+// score = MaxNodeScore * mean of allocated fractions of cpu and memory
+func (pl *MostAllocated) Score(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	cpu, mem := allocatedFractions(config, nodeInfo)
+	score := int64(float64(framework.MaxNodeScore) * (cpu + mem) / 2)
+	return score, framework.NewStatus()
+}
@@ -0,0 +1,182 @@
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodemaintenance"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/overcommit"
+)
+
+const (
+	// APIVersionV1 is the current schema version of plugin config.
+	// plugin config without apiVersion is treated as v1
+	APIVersionV1 = "qemu-scheduler/v1"
+)
+
+// validates config of a plugin
+type configValidator func(config map[string]interface{}) error
+
+// map[plugin type]map[plugin name]validator of plugins which can be configured via plugin config
+var knownPlugins = map[string]map[string]configValidator{
+	"filters": {
+		names.NodeMaintenance:  validateNodeMaintenance,
+		names.NodeName:         noConfig,
+		names.CPUOvercommit:    validateOvercommit,
+		names.MemoryOvercommit: validateOvercommit,
+		names.NodeRegex:        noConfig,
+		names.NodeAffinity:     noConfig,
+	},
+	"scores": {
+		names.NodeResource:       noConfig,
+		names.NodeAffinity:       noConfig,
+		names.Random:             noConfig,
+		names.LeastAllocated:     noConfig,
+		names.MostAllocated:      noConfig,
+		names.BalancedAllocation: noConfig,
+	},
+	"vmids": {
+		names.Range: noConfig,
+		names.Regex: noConfig,
+	},
+}
+
+// Parse plugin config and validate it against its schema.
+// unknown fields are rejected so that typos don't silently fall back to defaults
+func ParsePluginConfigs(b []byte) (PluginConfigs, error) {
+	var config PluginConfigs
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return PluginConfigs{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return PluginConfigs{}, err
+	}
+	return config, nil
+}
+
+// Validate returns an error if the plugin config doesn't conform to its schema
+func (c PluginConfigs) Validate() error {
+	if c.APIVersion != "" && c.APIVersion != APIVersionV1 {
+		return fmt.Errorf("unsupported plugin config apiVersion %q, expected %q", c.APIVersion, APIVersionV1)
+	}
+	errs := validatePlugins("", c.FilterPlugins, c.ScorePlugins, c.VMIDPlugins)
+	for _, name := range sortedKeys(c.Profiles) {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("profiles: profile name must not be empty"))
+			continue
+		}
+		profile := c.Profiles[name]
+		errs = append(errs, validatePlugins("profiles."+name+".", profile.FilterPlugins, profile.ScorePlugins, profile.VMIDPlugins)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Profile returns plugin configs of the profile merged into the default ones.
+// empty name means the default plugin configs
+func (c PluginConfigs) Profile(name string) (PluginConfigs, error) {
+	if name == "" {
+		return PluginConfigs{
+			FilterPlugins: c.FilterPlugins,
+			ScorePlugins:  c.ScorePlugins,
+			VMIDPlugins:   c.VMIDPlugins,
+			NodeLabels:    c.NodeLabels,
+		}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return PluginConfigs{}, fmt.Errorf("scheduler profile %q not found in plugin config", name)
+	}
+	return PluginConfigs{
+		FilterPlugins: mergePluginConfigs(c.FilterPlugins, profile.FilterPlugins),
+		ScorePlugins:  mergePluginConfigs(c.ScorePlugins, profile.ScorePlugins),
+		VMIDPlugins:   mergePluginConfigs(c.VMIDPlugins, profile.VMIDPlugins),
+		NodeLabels:    c.NodeLabels,
+	}, nil
+}
+
+// return plugin configs of base overridden by the ones of override
+func mergePluginConfigs(base, override map[string]PluginConfig) map[string]PluginConfig {
+	merged := make(map[string]PluginConfig, len(base)+len(override))
+	for name, config := range base {
+		merged[name] = config
+	}
+	for name, config := range override {
+		merged[name] = config
+	}
+	return merged
+}
+
+func validatePlugins(prefix string, filters, scores, vmids map[string]PluginConfig) []error {
+	var errs []error
+	byType := map[string]map[string]PluginConfig{"filters": filters, "scores": scores, "vmids": vmids}
+	for _, pluginType := range sortedKeys(byType) {
+		configs := byType[pluginType]
+		for _, name := range sortedKeys(configs) {
+			validate, ok := knownPlugins[pluginType][name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s%s.%s: unknown plugin", prefix, pluginType, name))
+				continue
+			}
+			if err := validate(configs[name].Config); err != nil {
+				errs = append(errs, fmt.Errorf("%s%s.%s.config: %w", prefix, pluginType, name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// DiffPluginConfigs returns human readable difference between the plugin configs.
+// empty if they are equal
+func DiffPluginConfigs(old, updated PluginConfigs) string {
+	return cmp.Diff(old, updated)
+}
+
+func noConfig(config map[string]interface{}) error {
+	if len(config) != 0 {
+		return fmt.Errorf("plugin has no config but got %s", strings.Join(sortedKeys(config), ","))
+	}
+	return nil
+}
+
+func validateNodeMaintenance(config map[string]interface{}) error {
+	for key, value := range config {
+		if key != nodemaintenance.ExclusionMarkerConfigKey {
+			return fmt.Errorf("unknown key %q", key)
+		}
+		if marker, ok := value.(string); !ok || marker == "" {
+			return fmt.Errorf("%s must be non-empty string", key)
+		}
+	}
+	return nil
+}
+
+func validateOvercommit(config map[string]interface{}) error {
+	for key, value := range config {
+		if key != overcommit.RatioConfigKey {
+			return fmt.Errorf("unknown key %q", key)
+		}
+		if ratio, ok := overcommit.ParseRatio(value); !ok || ratio <= 0 {
+			return fmt.Errorf("%s must be positive number", key)
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -54,6 +54,9 @@ func findVMIDRange(ctx context.Context) (int, int, error) {
 		return 0, 0, fmt.Errorf("no vmid range is specified")
 	}
 	rangeStrs := strings.Split(fmt.Sprintf("%s", value), "-")
+	if len(rangeStrs) != 2 {
+		return 0, 0, fmt.Errorf("invalid range is specified: %s", value)
+	}
 	start, err := strconv.Atoi(rangeStrs[0])
 	if err != nil {
 		return 0, 0, fmt.Errorf("invalid range is specified: %w", err)
@@ -48,6 +48,14 @@ var _ = Describe("findVMIDRange", Label("unit", "plugins"), func() {
 		})
 	})
 
+	Context("specify invalid range (no separator)", func() {
+		It("should error", func() {
+			c := context.WithValue(ctx, framework.CtxKey(idrange.VMIDRangeKey), "10")
+			_, _, err := idrange.FindVMIDRange(c)
+			Expect(err.Error()).To(ContainSubstring("invalid range is specified"))
+		})
+	})
+
 	Context("specify valid range", func() {
 		It("should not error", func() {
 			c := context.WithValue(ctx, framework.CtxKey(idrange.VMIDRangeKey), "10-20")
@@ -11,12 +11,22 @@ const (
 	CPUOvercommit = "CPUOvercommit"
 	// filter by memory overcommit ratio
 	MemoryOvercommit = "MemoryOvercommit"
+	// filter out offline/maintenance nodes
+	NodeMaintenance = "NodeMaintenance"
+	// filter/score by node selector and node affinity
+	NodeAffinity = "NodeAffinity"
 
 	// score plugins
 	// random score
 	Random = "Random"
 	// resource utilization score
 	NodeResource = "NodeResource"
+	// spread by allocated resources
+	LeastAllocated = "LeastAllocated"
+	// bin-pack by allocated resources
+	MostAllocated = "MostAllocated"
+	// balance allocated cpu and memory
+	BalancedAllocation = "BalancedAllocation"
 
 	// vmid plugins
 	// select by range
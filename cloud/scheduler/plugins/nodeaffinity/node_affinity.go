@@ -0,0 +1,178 @@
+package nodeaffinity
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+)
+
+// NodeAffinity is a filter and score plugin which checks
+// NodeSelector and NodeAffinity of the qemu against proxmox node labels
+type NodeAffinity struct {
+	// map[node name]labels configured via plugin config
+	nodeLabels map[string]map[string]string
+}
+
+var _ framework.NodeFilterPlugin = &NodeAffinity{}
+var _ framework.NodeScorePlugin = &NodeAffinity{}
+
+const (
+	Name = names.NodeAffinity
+
+	// node labels can be specified in node notes with this prefix.
+	// example: "qemu-scheduler/labels: gpu=true,rack=a"
+	NodeLabelsNotesPrefix = "qemu-scheduler/labels:"
+
+	// only supported key of matchFields
+	NodeFieldSelectorKeyNodeName = "metadata.name"
+)
+
+// return new NodeAffinity plugin with node labels configured via plugin config
+func New(nodeLabels map[string]map[string]string) *NodeAffinity {
+	return &NodeAffinity{nodeLabels: nodeLabels}
+}
+
+func (pl *NodeAffinity) Name() string {
+	return Name
+}
+
+// pass the node matching NodeSelector and
+// required terms of NodeAffinity specified in ctx
+func (pl *NodeAffinity) Filter(ctx context.Context, state *framework.CycleState, _ api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) *framework.Status {
+	placement := framework.NodePlacementFromContext(ctx)
+	nodeName := nodeInfo.Node().Node
+	nodeLabels := pl.labels(nodeInfo)
+	if !labels.SelectorFromSet(placement.NodeSelector).Matches(labels.Set(nodeLabels)) {
+		return unschedulable(state, fmt.Sprintf("node %s didn't match node selector", nodeName))
+	}
+	affinity := placement.NodeAffinity
+	if affinity == nil || affinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return &framework.Status{}
+	}
+	if !MatchNodeSelectorTerms(affinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms, nodeName, nodeLabels) {
+		return unschedulable(state, fmt.Sprintf("node %s didn't match node affinity", nodeName))
+	}
+	return &framework.Status{}
+}
+
+// score = sum of weights of matching preferred terms of NodeAffinity specified in ctx
+func (pl *NodeAffinity) Score(ctx context.Context, state *framework.CycleState, _ api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	status := framework.NewStatus()
+	affinity := framework.NodePlacementFromContext(ctx).NodeAffinity
+	if affinity == nil {
+		return 0, status
+	}
+	nodeName := nodeInfo.Node().Node
+	nodeLabels := pl.labels(nodeInfo)
+	var score int64
+	for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if MatchNodeSelectorTerm(term.Preference, nodeName, nodeLabels) {
+			score += int64(term.Weight)
+		}
+	}
+	return score, status
+}
+
+func unschedulable(state *framework.CycleState, msg string) *framework.Status {
+	status := framework.NewStatus()
+	status.SetCode(1)
+	state.SetMessage(Name, msg)
+	return status
+}
+
+// return labels of the node.
+// labels in node notes take precedence over labels configured via plugin config
+func (pl *NodeAffinity) labels(nodeInfo *framework.NodeInfo) map[string]string {
+	nodeLabels := map[string]string{}
+	for key, value := range pl.nodeLabels[nodeInfo.Node().Node] {
+		nodeLabels[key] = value
+	}
+	for key, value := range ParseNotesLabels(nodeInfo.Config().Description) {
+		nodeLabels[key] = value
+	}
+	return nodeLabels
+}
+
+// parse labels from node notes.
+// each line having NodeLabelsNotesPrefix is treated as comma separated key=value list
+func ParseNotesLabels(notes string) map[string]string {
+	nodeLabels := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(notes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, NodeLabelsNotesPrefix) {
+			continue
+		}
+		for _, pair := range strings.Split(strings.TrimPrefix(line, NodeLabelsNotesPrefix), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if key == "" {
+				continue
+			}
+			nodeLabels[key] = value
+		}
+	}
+	return nodeLabels
+}
+
+// return true if any of the terms matches (terms are ORed)
+func MatchNodeSelectorTerms(terms []corev1.NodeSelectorTerm, nodeName string, nodeLabels map[string]string) bool {
+	for _, term := range terms {
+		if MatchNodeSelectorTerm(term, nodeName, nodeLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// return true if all the requirements of the term match (requirements are ANDed).
+// empty term matches no nodes
+func MatchNodeSelectorTerm(term corev1.NodeSelectorTerm, nodeName string, nodeLabels map[string]string) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		if !matchRequirement(req, labels.Set(nodeLabels)) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		if req.Key != NodeFieldSelectorKeyNodeName {
+			return false
+		}
+		if !matchRequirement(req, labels.Set{NodeFieldSelectorKeyNodeName: nodeName}) {
+			return false
+		}
+	}
+	return true
+}
+
+var operators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// invalid requirement matches nothing
+func matchRequirement(req corev1.NodeSelectorRequirement, set labels.Set) bool {
+	op, ok := operators[req.Operator]
+	if !ok {
+		return false
+	}
+	r, err := labels.NewRequirement(req.Key, op, req.Values)
+	if err != nil {
+		return false
+	}
+	return r.Matches(set)
+}
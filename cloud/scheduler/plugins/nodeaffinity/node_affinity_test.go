@@ -0,0 +1,119 @@
+package nodeaffinity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodeaffinity"
+)
+
+func TestNodeAffinity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "nodeaffinity plugin")
+}
+
+func newNodeInfo(name, notes string) *framework.NodeInfo {
+	node := &api.Node{Node: name, Status: "online"}
+	return framework.NewNodeInfo(node, nil, framework.NodeConfig{Description: notes}, "")
+}
+
+var _ = Describe("ParseNotesLabels", Label("unit", "plugins"), func() {
+	Context("notes without labels", func() {
+		It("should return empty labels", func() {
+			Expect(nodeaffinity.ParseNotesLabels("some notes")).To(BeEmpty())
+		})
+	})
+
+	Context("notes with labels", func() {
+		It("should return labels", func() {
+			notes := "rack A\nqemu-scheduler/labels: gpu=true, rack=a\nqemu-scheduler/labels: ssd="
+			Expect(nodeaffinity.ParseNotesLabels(notes)).To(Equal(map[string]string{"gpu": "true", "rack": "a", "ssd": ""}))
+		})
+	})
+})
+
+var _ = Describe("Filter", Label("unit", "plugins"), func() {
+	config := api.VirtualMachineCreateOptions{}
+	var state framework.CycleState
+	pl := nodeaffinity.New(map[string]map[string]string{"node1": {"rack": "a", "gpu": "false"}})
+
+	BeforeEach(func() {
+		state = framework.NewCycleState()
+	})
+
+	Context("without node placement", func() {
+		It("should pass", func() {
+			status := pl.Filter(context.Background(), &state, config, newNodeInfo("node1", ""))
+			Expect(status.IsSuccess()).To(BeTrue())
+		})
+	})
+
+	Context("with node selector", func() {
+		ctx := framework.ContextWithNodePlacement(context.Background(), framework.NodePlacement{
+			NodeSelector: map[string]string{"gpu": "true"},
+		})
+
+		It("should pass the node having labels in notes", func() {
+			status := pl.Filter(ctx, &state, config, newNodeInfo("node1", "qemu-scheduler/labels: gpu=true"))
+			Expect(status.IsSuccess()).To(BeTrue())
+		})
+
+		It("should not pass the node not matching", func() {
+			status := pl.Filter(ctx, &state, config, newNodeInfo("node1", ""))
+			Expect(status.IsSuccess()).To(BeFalse())
+			Expect(state.Messages()).To(HaveKey(nodeaffinity.Name))
+		})
+	})
+
+	Context("with required node affinity", func() {
+		ctx := framework.ContextWithNodePlacement(context.Background(), framework.NodePlacement{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "rack", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}}}},
+						{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node3"}}}},
+					},
+				},
+			},
+		})
+
+		It("should pass the node matching any term", func() {
+			Expect(pl.Filter(ctx, &state, config, newNodeInfo("node1", "")).IsSuccess()).To(BeTrue())
+			Expect(pl.Filter(ctx, &state, config, newNodeInfo("node2", "qemu-scheduler/labels: rack=b")).IsSuccess()).To(BeTrue())
+			Expect(pl.Filter(ctx, &state, config, newNodeInfo("node3", "")).IsSuccess()).To(BeTrue())
+		})
+
+		It("should not pass the node matching no terms", func() {
+			Expect(pl.Filter(ctx, &state, config, newNodeInfo("node2", "qemu-scheduler/labels: rack=c")).IsSuccess()).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Score", Label("unit", "plugins"), func() {
+	config := api.VirtualMachineCreateOptions{}
+	state := framework.NewCycleState()
+	pl := nodeaffinity.New(nil)
+	ctx := framework.ContextWithNodePlacement(context.Background(), framework.NodePlacement{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{Weight: 10, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "ssd", Operator: corev1.NodeSelectorOpExists}}}},
+				{Weight: 5, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "cores", Operator: corev1.NodeSelectorOpGt, Values: []string{"16"}}}}},
+			},
+		},
+	})
+
+	It("should return sum of weights of matching terms", func() {
+		score, status := pl.Score(ctx, &state, config, newNodeInfo("node1", "qemu-scheduler/labels: ssd=,cores=32"))
+		Expect(status.IsSuccess()).To(BeTrue())
+		Expect(score).To(Equal(int64(15)))
+
+		score, _ = pl.Score(ctx, &state, config, newNodeInfo("node1", "qemu-scheduler/labels: cores=8"))
+		Expect(score).To(Equal(int64(0)))
+	})
+})
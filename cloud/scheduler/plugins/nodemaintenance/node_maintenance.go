@@ -0,0 +1,66 @@
+package nodemaintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+)
+
+type NodeMaintenance struct {
+	// nodes having this marker in their notes are excluded from scheduling
+	exclusionMarker string
+}
+
+var _ framework.NodeFilterPlugin = &NodeMaintenance{}
+
+const (
+	Name = names.NodeMaintenance
+
+	// plugin config key to override default exclusion marker
+	ExclusionMarkerConfigKey = "exclusionMarker"
+	DefaultExclusionMarker   = "qemu-scheduler/exclude"
+)
+
+// return new NodeMaintenance plugin configured with plugin config
+func New(config map[string]interface{}) *NodeMaintenance {
+	pl := &NodeMaintenance{exclusionMarker: DefaultExclusionMarker}
+	if marker, ok := config[ExclusionMarkerConfigKey].(string); ok && marker != "" {
+		pl.exclusionMarker = marker
+	}
+	return pl
+}
+
+func (pl *NodeMaintenance) Name() string {
+	return Name
+}
+
+// filter out nodes which are offline, in HA maintenance mode
+// or having exclusion marker in their notes
+func (pl *NodeMaintenance) Filter(ctx context.Context, state *framework.CycleState, _ api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) *framework.Status {
+	if reason := pl.unschedulableReason(nodeInfo); reason != "" {
+		status := framework.NewStatus()
+		status.SetCode(1)
+		state.SetMessage(pl.Name(), fmt.Sprintf("node %s %s", nodeInfo.Node().Node, reason))
+		return status
+	}
+	return &framework.Status{}
+}
+
+// return empty string if the node is schedulable
+func (pl *NodeMaintenance) unschedulableReason(nodeInfo *framework.NodeInfo) string {
+	if !nodeInfo.IsOnline() {
+		return fmt.Sprintf("is not online (status=%s)", nodeInfo.Node().Status)
+	}
+	if nodeInfo.HAState() == framework.HANodeStateMaintenance {
+		return "is in HA maintenance mode"
+	}
+	if strings.Contains(nodeInfo.Config().Description, pl.exclusionMarker) {
+		return fmt.Sprintf("has exclusion marker %q", pl.exclusionMarker)
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package nodemaintenance_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodemaintenance"
+)
+
+func TestNodeMaintenance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "nodemaintenance plugin")
+}
+
+var _ = Describe("Filter", Label("unit", "plugins"), func() {
+	ctx := context.Background()
+	config := api.VirtualMachineCreateOptions{}
+	var state framework.CycleState
+
+	BeforeEach(func() {
+		state = framework.NewCycleState()
+	})
+
+	newNodeInfo := func(status, description, haState string) *framework.NodeInfo {
+		node := &api.Node{Node: "foo", Status: status}
+		return framework.NewNodeInfo(node, nil, framework.NodeConfig{Description: description}, haState)
+	}
+
+	Context("online node without marker", func() {
+		It("should pass", func() {
+			pl := nodemaintenance.New(nil)
+			status := pl.Filter(ctx, &state, config, newNodeInfo("online", "", "online"))
+			Expect(status.IsSuccess()).To(BeTrue())
+		})
+	})
+
+	Context("offline node", func() {
+		It("should not pass", func() {
+			pl := nodemaintenance.New(nil)
+			status := pl.Filter(ctx, &state, config, newNodeInfo("offline", "", ""))
+			Expect(status.IsSuccess()).To(BeFalse())
+			Expect(state.Messages()[nodemaintenance.Name]).To(ContainSubstring("not online"))
+		})
+	})
+
+	Context("node in HA maintenance mode", func() {
+		It("should not pass", func() {
+			pl := nodemaintenance.New(nil)
+			status := pl.Filter(ctx, &state, config, newNodeInfo("online", "", "maintenance"))
+			Expect(status.IsSuccess()).To(BeFalse())
+		})
+	})
+
+	Context("node having default exclusion marker", func() {
+		It("should not pass", func() {
+			pl := nodemaintenance.New(nil)
+			status := pl.Filter(ctx, &state, config, newNodeInfo("online", "disk replacement\nqemu-scheduler/exclude", ""))
+			Expect(status.IsSuccess()).To(BeFalse())
+		})
+	})
+
+	Context("node having custom exclusion marker", func() {
+		It("should not pass only with custom marker", func() {
+			pl := nodemaintenance.New(map[string]interface{}{nodemaintenance.ExclusionMarkerConfigKey: "#drain"})
+			status := pl.Filter(ctx, &state, config, newNodeInfo("online", "qemu-scheduler/exclude", ""))
+			Expect(status.IsSuccess()).To(BeTrue())
+			status = pl.Filter(ctx, &state, config, newNodeInfo("online", "#drain", ""))
+			Expect(status.IsSuccess()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,44 @@
+package noderesource_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/noderesource"
+)
+
+func TestNodeResource(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "noderesource plugin")
+}
+
+var _ = Describe("Score", Label("unit", "plugins"), func() {
+	ctx := context.Background()
+	config := api.VirtualMachineCreateOptions{}
+	state := framework.NewCycleState()
+	pl := &noderesource.NodeResource{}
+
+	newNodeInfo := func(cpu float32, mem, maxMem int) *framework.NodeInfo {
+		node := &api.Node{Node: "foo", Status: "online", Cpu: cpu, MaxCpu: 16, Mem: mem, MaxMem: maxMem}
+		return framework.NewNodeInfo(node, nil, framework.NodeConfig{}, "online")
+	}
+
+	It("should score less utilized nodes higher", func() {
+		idle, status := pl.Score(ctx, &state, config, newNodeInfo(0.1, 4, 16))
+		Expect(status.IsSuccess()).To(BeTrue())
+		busy, status := pl.Score(ctx, &state, config, newNodeInfo(0.5, 8, 16))
+		Expect(status.IsSuccess()).To(BeTrue())
+		Expect(idle).To(Equal(int64(97)))
+		Expect(busy).To(Equal(int64(75)))
+	})
+
+	It("should score node without capacity as fully utilized", func() {
+		score, _ := pl.Score(ctx, &state, config, newNodeInfo(0, 0, 0))
+		Expect(score).To(Equal(int64(0)))
+	})
+})
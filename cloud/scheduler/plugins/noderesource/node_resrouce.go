@@ -2,6 +2,7 @@ package noderesource
 
 import (
 	"context"
+	"math"
 
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
@@ -20,14 +21,16 @@ func (pl *NodeResource) Name() string {
 	return Name
 }
 
-// score = 1/(cpu/maxcpu * mem/maxmem)
+// score = MaxNodeScore * (1 - cpu/maxcpu * mem/maxmem).
+// cpu is the current cpu utilization of the node, which is already a fraction of maxcpu
 func (pl *NodeResource) Score(ctx context.Context, state *framework.CycleState, _ api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
-	cpu := nodeInfo.Node().Cpu
-	maxCPU := nodeInfo.Node().MaxCpu
-	mem := nodeInfo.Node().Mem
-	maxMem := nodeInfo.Node().MaxMem
-	u := cpu / float32(maxCPU) * float32(mem/maxMem)
-	score := int64(1 / u)
+	node := nodeInfo.Node()
+	u := 1.0
+	if node.MaxCpu > 0 && node.MaxMem > 0 {
+		u = float64(node.Cpu) * float64(node.Mem) / float64(node.MaxMem)
+	}
+	u = math.Min(math.Max(u, 0), 1)
+	score := int64(float64(framework.MaxNodeScore) * (1 - u))
 	status := framework.NewStatus()
 	status.SetCode(0)
 	return score, status
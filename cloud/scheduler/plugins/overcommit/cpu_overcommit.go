@@ -9,7 +9,10 @@ import (
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
 )
 
-type CPUOvercommit struct{}
+type CPUOvercommit struct {
+	// max ratio of cpus of running qemus to cpus of the node
+	ratio float64
+}
 
 var _ framework.NodeFilterPlugin = &CPUOvercommit{}
 
@@ -18,6 +21,15 @@ const (
 	defaultCPUOvercommitRatio = 4
 )
 
+// return new CPUOvercommit plugin configured with plugin config
+func NewCPUOvercommit(config map[string]interface{}) *CPUOvercommit {
+	pl := &CPUOvercommit{ratio: defaultCPUOvercommitRatio}
+	if ratio, ok := ParseRatio(config[RatioConfigKey]); ok && ratio > 0 {
+		pl.ratio = ratio
+	}
+	return pl
+}
+
 func (pl *CPUOvercommit) Name() string {
 	return CPUOvercommitName
 }
@@ -30,8 +42,8 @@ func (pl *CPUOvercommit) Filter(ctx context.Context, state *framework.CycleState
 	if sockets == 0 {
 		sockets = 1
 	}
-	ratio := float32(cpu+config.Cores*sockets) / float32(maxCPU)
-	if ratio > defaultCPUOvercommitRatio {
+	ratio := float64(cpu+config.Cores*sockets) / float64(maxCPU)
+	if ratio > pl.ratio {
 		status := framework.NewStatus()
 		status.SetCode(1)
 		state.SetMessage(pl.Name(), "exceed cpu overcommit ratio")
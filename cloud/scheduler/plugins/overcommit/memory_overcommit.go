@@ -9,7 +9,10 @@ import (
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
 )
 
-type MemoryOvercommit struct{}
+type MemoryOvercommit struct {
+	// ratio of memory of running qemus to memory of the node must be less than this
+	ratio float64
+}
 
 var _ framework.NodeFilterPlugin = &MemoryOvercommit{}
 
@@ -18,6 +21,15 @@ const (
 	defaultMemoryOvercommitRatio = 1
 )
 
+// return new MemoryOvercommit plugin configured with plugin config
+func NewMemoryOvercommit(config map[string]interface{}) *MemoryOvercommit {
+	pl := &MemoryOvercommit{ratio: defaultMemoryOvercommitRatio}
+	if ratio, ok := ParseRatio(config[RatioConfigKey]); ok && ratio > 0 {
+		pl.ratio = ratio
+	}
+	return pl
+}
+
 func (pl *MemoryOvercommit) Name() string {
 	return MemoryOvercommitName
 }
@@ -26,8 +38,8 @@ func (pl *MemoryOvercommit) Name() string {
 func (pl *MemoryOvercommit) Filter(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) *framework.Status {
 	mem := sumMems(nodeInfo.QEMUs())
 	maxMem := nodeInfo.Node().MaxMem
-	ratio := float32(mem+1024*1024*config.Memory) / float32(maxMem)
-	if ratio >= defaultMemoryOvercommitRatio {
+	ratio := float64(mem+1024*1024*config.Memory) / float64(maxMem)
+	if ratio >= pl.ratio {
 		status := framework.NewStatus()
 		status.SetCode(1)
 		state.SetMessage(pl.Name(), "exceed memory overcommit ratio")
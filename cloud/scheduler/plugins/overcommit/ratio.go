@@ -0,0 +1,16 @@
+package overcommit
+
+// plugin config key to override default overcommit ratio
+const RatioConfigKey = "ratio"
+
+// return overcommit ratio in plugin config.
+// yaml numbers are decoded as int or float64 depending on how they are written
+func ParseRatio(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
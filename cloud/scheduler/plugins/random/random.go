@@ -3,6 +3,7 @@ package random
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
@@ -19,6 +20,13 @@ const (
 	Name = names.Random
 )
 
+var (
+	// seeded once so that nodes scored in the same second get different scores.
+	// rand.Rand is not safe for concurrent use
+	mu  sync.Mutex
+	rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
 func (pl *Random) Name() string {
 	return Name
 }
@@ -26,8 +34,7 @@ func (pl *Random) Name() string {
 // return random score: 0 <= n < 100.
 // just a sample plugin
 func (pl *Random) Score(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
-	src := rand.NewSource(time.Now().Unix())
-	r := rand.New(src)
-	score := r.Int63n(100)
-	return score, nil
+	mu.Lock()
+	defer mu.Unlock()
+	return rng.Int63n(100), framework.NewStatus()
 }
@@ -1,29 +1,67 @@
 package plugins
 
 import (
+	"fmt"
 	"os"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/allocation"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/idrange"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodeaffinity"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodemaintenance"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/nodename"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/noderesource"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/overcommit"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/random"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/regex"
 )
 
 type PluginConfigs struct {
+	// schema version of plugin config. empty means APIVersionV1
+	APIVersion string `yaml:"apiVersion,omitempty"`
+
+	FilterPlugins map[string]PluginConfig `yaml:"filters,omitempty"`
+	ScorePlugins  map[string]PluginConfig `yaml:"scores,omitempty"`
+	VMIDPlugins   map[string]PluginConfig `yaml:"vmids,omitempty"`
+
+	// map[node name]labels used by NodeAffinity plugin
+	NodeLabels map[string]map[string]string `yaml:"nodeLabels,omitempty"`
+
+	// map[profile name]profile selected by ProxmoxCluster/ProxmoxMachine
+	Profiles map[string]PluginProfile `yaml:"profiles,omitempty"`
+}
+
+// PluginProfile overrides the default plugin configs per plugin.
+// plugins not in the profile are configured as the default
+type PluginProfile struct {
 	FilterPlugins map[string]PluginConfig `yaml:"filters,omitempty"`
 	ScorePlugins  map[string]PluginConfig `yaml:"scores,omitempty"`
 	VMIDPlugins   map[string]PluginConfig `yaml:"vmids,omitempty"`
 }
 
 type PluginConfig struct {
-	Enable bool                   `yaml:"enable,omitempty"`
+	// enable/disable the plugin. the plugin is enabled/disabled as default if nil
+	Enable *bool                  `yaml:"enable,omitempty"`
 	Config map[string]interface{} `yaml:"config,omitempty"`
 }
 
+// plugins which are disabled unless they are enabled via plugin config
+var disabledByDefault = map[string]bool{
+	names.Random:             true,
+	names.LeastAllocated:     true,
+	names.MostAllocated:      true,
+	names.BalancedAllocation: true,
+}
+
+// return true if the plugin is enabled by its config or by default
+func isEnabled(config map[string]PluginConfig, name string) bool {
+	if enable := config[name].Enable; enable != nil {
+		return *enable
+	}
+	return !disabledByDefault[name]
+}
+
 type PluginRegistry struct {
 	filterPlugins []framework.NodeFilterPlugin
 	scorePlugins  []framework.NodeScorePlugin
@@ -44,42 +82,67 @@ func (r *PluginRegistry) VMIDPlugins() []framework.VMIDPlugin {
 
 func NewRegistry(configs PluginConfigs) PluginRegistry {
 	r := PluginRegistry{
-		filterPlugins: NewNodeFilterPlugins(configs.FilterPlugins),
-		scorePlugins:  NewNodeScorePlugins(configs.ScorePlugins),
+		filterPlugins: NewNodeFilterPlugins(configs.FilterPlugins, configs.NodeLabels),
+		scorePlugins:  NewNodeScorePlugins(configs.ScorePlugins, configs.NodeLabels),
 		vmidPlugins:   NewVMIDPlugins(configs.VMIDPlugins),
 	}
 	return r
 }
 
-func NewNodeFilterPlugins(config map[string]PluginConfig) []framework.NodeFilterPlugin {
+// PluginRegistries is map[profile name]registry. the default registry has empty name
+type PluginRegistries map[string]PluginRegistry
+
+// return registries of the default config and all the profiles
+func NewRegistries(configs PluginConfigs) PluginRegistries {
+	registries := PluginRegistries{"": NewRegistry(configs)}
+	for name := range configs.Profiles {
+		profile, _ := configs.Profile(name)
+		registries[name] = NewRegistry(profile)
+	}
+	return registries
+}
+
+// return registry of the profile. empty name means the default registry
+func (r PluginRegistries) Get(profile string) (PluginRegistry, error) {
+	registry, ok := r[profile]
+	if !ok {
+		return PluginRegistry{}, fmt.Errorf("scheduler profile %q not found in plugin config", profile)
+	}
+	return registry, nil
+}
+
+func NewNodeFilterPlugins(config map[string]PluginConfig, nodeLabels map[string]map[string]string) []framework.NodeFilterPlugin {
 	pls := []framework.NodeFilterPlugin{
+		nodemaintenance.New(config[names.NodeMaintenance].Config),
 		&nodename.NodeName{},
-		&overcommit.CPUOvercommit{},
-		&overcommit.MemoryOvercommit{},
+		overcommit.NewCPUOvercommit(config[names.CPUOvercommit].Config),
+		overcommit.NewMemoryOvercommit(config[names.MemoryOvercommit].Config),
 		&regex.NodeRegex{},
+		nodeaffinity.New(nodeLabels),
 	}
 	plugins := []framework.NodeFilterPlugin{}
 	for _, pl := range pls {
-		c, ok := config[pl.Name()]
-		if ok && !c.Enable {
-			continue
+		if isEnabled(config, pl.Name()) {
+			plugins = append(plugins, pl)
 		}
-		plugins = append(plugins, pl)
 	}
 	return plugins
 }
 
-func NewNodeScorePlugins(config map[string]PluginConfig) []framework.NodeScorePlugin {
+func NewNodeScorePlugins(config map[string]PluginConfig, nodeLabels map[string]map[string]string) []framework.NodeScorePlugin {
 	pls := []framework.NodeScorePlugin{
 		&noderesource.NodeResource{},
+		nodeaffinity.New(nodeLabels),
+		&random.Random{},
+		&allocation.LeastAllocated{},
+		&allocation.MostAllocated{},
+		&allocation.BalancedAllocation{},
 	}
 	plugins := []framework.NodeScorePlugin{}
 	for _, pl := range pls {
-		c, ok := config[pl.Name()]
-		if ok && !c.Enable {
-			continue
+		if isEnabled(config, pl.Name()) {
+			plugins = append(plugins, pl)
 		}
-		plugins = append(plugins, pl)
 	}
 	return plugins
 }
@@ -91,11 +154,9 @@ func NewVMIDPlugins(config map[string]PluginConfig) []framework.VMIDPlugin {
 	}
 	plugins := []framework.VMIDPlugin{}
 	for _, pl := range pls {
-		c, ok := config[pl.Name()]
-		if ok && !c.Enable {
-			continue
+		if isEnabled(config, pl.Name()) {
+			plugins = append(plugins, pl)
 		}
-		plugins = append(plugins, pl)
 	}
 	return plugins
 }
@@ -110,8 +171,5 @@ func GetPluginConfigFromFile(path string) (PluginConfigs, error) {
 	if err != nil {
 		return config, err
 	}
-	if err := yaml.Unmarshal(b, &config); err != nil {
-		return config, err
-	}
-	return config, nil
+	return ParsePluginConfigs(b)
 }
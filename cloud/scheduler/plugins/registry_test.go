@@ -1,12 +1,16 @@
 package plugins_test
 
 import (
+	"context"
 	"os"
 	"testing"
 
+	"github.com/k8s-proxmox/proxmox-go/api"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
 
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins"
 )
 
@@ -44,7 +48,7 @@ var _ = Describe("GetPluginConfigFromFile", Label("unit", "scheduler"), func() {
 			config, err := plugins.GetPluginConfigFromFile(path)
 			Expect(err).NotTo(HaveOccurred())
 			scores := map[string]plugins.PluginConfig{}
-			scores["Random"] = plugins.PluginConfig{Enable: false}
+			scores["Random"] = plugins.PluginConfig{Enable: ptr.To(false)}
 			Expect(config).To(Equal(plugins.PluginConfigs{ScorePlugins: scores}))
 		})
 	})
@@ -59,6 +63,110 @@ var _ = Describe("GetPluginConfigFromFile", Label("unit", "scheduler"), func() {
 	})
 })
 
+var _ = Describe("ParsePluginConfigs", Label("unit", "scheduler"), func() {
+	It("should accept valid config", func() {
+		config, err := plugins.ParsePluginConfigs([]byte(`apiVersion: qemu-scheduler/v1
+filters:
+  CPUOvercommit:
+    config:
+      ratio: 2.5
+  MemoryOvercommit:
+    config:
+      ratio: 1
+  NodeMaintenance:
+    config:
+      exclusionMarker: "#no-schedule"`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.FilterPlugins).To(HaveLen(3))
+	})
+
+	It("should accept empty config", func() {
+		config, err := plugins.ParsePluginConfigs(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(plugins.PluginConfigs{}))
+	})
+
+	DescribeTable("should reject invalid config",
+		func(content string) {
+			_, err := plugins.ParsePluginConfigs([]byte(content))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unsupported apiVersion", "apiVersion: qemu-scheduler/v2"),
+		Entry("unknown field", "filter:\n  CPUOvercommit:\n    enable: false"),
+		Entry("unknown plugin", "filters:\n  CPUOvercomit:\n    enable: false"),
+		Entry("plugin of other type", "scores:\n  CPUOvercommit:\n    enable: false"),
+		Entry("non-positive ratio", "filters:\n  CPUOvercommit:\n    config:\n      ratio: 0"),
+		Entry("non-numeric ratio", "filters:\n  MemoryOvercommit:\n    config:\n      ratio: high"),
+		Entry("config of plugin without config", "vmids:\n  Range:\n    config:\n      start: 100"),
+		Entry("unknown plugin in profile", "profiles:\n  dev:\n    filters:\n      CPUOvercomit:\n        enable: false"),
+		Entry("invalid config in profile", "profiles:\n  dev:\n    filters:\n      CPUOvercommit:\n        config:\n          ratio: -1"),
+	)
+})
+
+var _ = Describe("PluginConfigs.Profile", Label("unit", "scheduler"), func() {
+	configs := plugins.PluginConfigs{
+		FilterPlugins: map[string]plugins.PluginConfig{
+			"CPUOvercommit":    {Enable: ptr.To(true), Config: map[string]interface{}{"ratio": 1}},
+			"MemoryOvercommit": {Enable: ptr.To(true)},
+		},
+		NodeLabels: map[string]map[string]string{"node1": {"rack": "a"}},
+		Profiles: map[string]plugins.PluginProfile{
+			"dev": {FilterPlugins: map[string]plugins.PluginConfig{
+				"CPUOvercommit": {Enable: ptr.To(true), Config: map[string]interface{}{"ratio": 8}},
+			}},
+		},
+	}
+
+	It("should override the default plugin configs", func() {
+		profile, err := configs.Profile("dev")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profile.FilterPlugins).To(Equal(map[string]plugins.PluginConfig{
+			"CPUOvercommit":    {Enable: ptr.To(true), Config: map[string]interface{}{"ratio": 8}},
+			"MemoryOvercommit": {Enable: ptr.To(true)},
+		}))
+		Expect(profile.NodeLabels).To(Equal(configs.NodeLabels))
+		Expect(configs.FilterPlugins["CPUOvercommit"].Config["ratio"]).To(Equal(1))
+	})
+
+	It("should return the default plugin configs with empty name", func() {
+		profile, err := configs.Profile("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(profile.FilterPlugins).To(Equal(configs.FilterPlugins))
+		Expect(profile.Profiles).To(BeEmpty())
+	})
+
+	It("should error with unknown profile", func() {
+		_, err := configs.Profile("production")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("NewRegistry", Label("unit", "scheduler"), func() {
+	It("should score nodes with Random enabled", func() {
+		registry := plugins.NewRegistry(plugins.PluginConfigs{
+			ScorePlugins: map[string]plugins.PluginConfig{"Random": {Enable: ptr.To(true)}},
+		})
+		names := []string{}
+		for _, pl := range registry.ScorePlugins() {
+			names = append(names, pl.Name())
+		}
+		Expect(names).To(ContainElement("Random"))
+
+		ctx := context.Background()
+		state := framework.NewCycleState()
+		config := api.VirtualMachineCreateOptions{Cores: 2, Memory: 2048}
+		for _, name := range []string{"node1", "node2", "node3"} {
+			node := &api.Node{Node: name, Status: "online", MaxCpu: 16, MaxMem: 32 * 1024 * 1024 * 1024}
+			nodeInfo := framework.NewNodeInfo(node, nil, framework.NodeConfig{}, "online")
+			for _, pl := range registry.ScorePlugins() {
+				score, status := pl.Score(ctx, &state, config, nodeInfo)
+				Expect(status.IsSuccess()).To(BeTrue(), pl.Name())
+				Expect(score).To(BeNumerically(">=", 0), pl.Name())
+			}
+		}
+	})
+})
+
 func stringToFile(str string, path string) error {
 	b := []byte(str)
 	return os.WriteFile(path, b, 0666)
@@ -3,8 +3,11 @@ package queue
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
 )
 
 type SchedulingQueue struct {
@@ -25,6 +28,9 @@ func New() *SchedulingQueue {
 type qemuSpec struct {
 	ctx    context.Context
 	config *api.VirtualMachineCreateOptions
+
+	// time the spec is added to the queue
+	addedAt time.Time
 }
 
 // add new qemuSpec to queue
@@ -36,7 +42,8 @@ func (s *SchedulingQueue) Add(ctx context.Context, config *api.VirtualMachineCre
 		return
 	}
 
-	s.activeQ = append(s.activeQ, &qemuSpec{ctx: ctx, config: config})
+	s.activeQ = append(s.activeQ, &qemuSpec{ctx: ctx, config: config, addedAt: time.Now()})
+	metrics.QueueDepth.Inc()
 	s.lock.Signal()
 }
 
@@ -63,6 +70,8 @@ func (s *SchedulingQueue) Get() (spec *qemuSpec, shutdown bool) {
 	// so the object will not be garbage collected.
 	s.activeQ[0] = nil
 	s.activeQ = s.activeQ[1:]
+	metrics.QueueDepth.Dec()
+	metrics.QueueWaitDuration.Observe(time.Since(spec.addedAt).Seconds())
 	return spec, false
 }
 
@@ -1,19 +1,26 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/queue"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
 )
 
 var (
@@ -33,6 +40,11 @@ type Manager struct {
 
 	// scheduler map
 	table map[schedulerID]*Scheduler
+	mu    sync.Mutex
+
+	// guards params.pluginconfigs which can be reloaded.
+	// it is updated only while mu is held as well
+	configMu sync.RWMutex
 }
 
 // return manager with initialized scheduler-table
@@ -47,6 +59,81 @@ func NewManager(params SchedulerParams) (*Manager, error) {
 	return &Manager{ctx: context.Background(), params: params, table: table}, nil
 }
 
+// return plugin config which new schedulers are initialized with
+func (m *Manager) pluginConfigs() plugins.PluginConfigs {
+	m.configMu.RLock()
+	defer m.configMu.RUnlock()
+	return m.params.PluginConfigs()
+}
+
+// UpdatePluginConfigs validates the plugin config and swaps the plugin registry
+// of all the running schedulers with the one built from it.
+// qemus being scheduled keep using the registry they started with
+func (m *Manager) UpdatePluginConfigs(config plugins.PluginConfigs) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid plugin config: %w", err)
+	}
+	// the scheduler table is locked until the registries are swapped so that
+	// concurrent updates and schedulers registered meanwhile never keep stale registries
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configMu.Lock()
+	diff := plugins.DiffPluginConfigs(m.params.pluginconfigs, config)
+	if diff == "" {
+		m.configMu.Unlock()
+		return nil
+	}
+	m.params.pluginconfigs = config
+	m.configMu.Unlock()
+	m.params.Logger.Info("reloaded plugin config", "diff", diff)
+
+	for _, sched := range m.table {
+		sched.setRegistries(plugins.NewRegistries(config))
+	}
+	return nil
+}
+
+// Start reloads plugin config whenever the config file changes until the context is done.
+// the file is polled since a ConfigMap mounted as a volume is updated by swapping symlinks.
+// invalid config is logged and the current one is kept
+func (m *Manager) Start(ctx context.Context) error {
+	if m.params.PluginConfigFile == "" {
+		return nil
+	}
+	interval := m.params.PluginConfigReloadInterval
+	if interval == 0 {
+		interval = defaultPluginConfigReloadInterval
+	}
+	log := m.params.Logger.WithValues("path", m.params.PluginConfigFile)
+	// content of the config file last seen. the first check is no-op unless
+	// the file has changed since the manager was created
+	var last []byte
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		b, err := os.ReadFile(m.params.PluginConfigFile)
+		if err != nil {
+			log.Error(err, "failed to read plugin config")
+			return
+		}
+		if bytes.Equal(b, last) {
+			return
+		}
+		last = b
+		config, err := plugins.ParsePluginConfigs(b)
+		if err == nil {
+			err = m.UpdatePluginConfigs(config)
+		}
+		if err != nil {
+			log.Error(err, "failed to reload plugin config, keep using current one")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection returns false so that plugin config is kept up to date on standby managers too
+func (m *Manager) NeedLeaderElection() bool {
+	return false
+}
+
 // return new/existing scheduler
 func (m *Manager) GetOrCreateScheduler(client *proxmox.Service) *Scheduler {
 	schedID, err := m.getSchedulerID(client)
@@ -56,6 +143,8 @@ func (m *Manager) GetOrCreateScheduler(client *proxmox.Service) *Scheduler {
 		sched := m.NewScheduler(client, WithTimeout(1*time.Minute))
 		return sched
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	sched, ok := m.table[*schedID]
 	if !ok {
 		// create and register new scheduler
@@ -65,6 +154,10 @@ func (m *Manager) GetOrCreateScheduler(client *proxmox.Service) *Scheduler {
 		m.table[*schedID] = sched
 		return sched
 	}
+	if sched.setClient(client) {
+		// the client has been recreated e.g. with rotated credentials
+		sched.logger.Info("updated proxmox client of existing scheduler")
+	}
 	sched.logger.V(4).Info("using existing scheduler")
 	return sched
 }
@@ -77,7 +170,7 @@ func (m *Manager) NewScheduler(client *proxmox.Service, opts ...SchedulerOption)
 		client:          client,
 		schedulingQueue: queue.New(),
 
-		registry: plugins.NewRegistry(m.params.PluginConfigs()),
+		registries: plugins.NewRegistries(m.pluginConfigs()),
 
 		resultMap: make(map[string]chan *framework.CycleState),
 		logger:    m.params.Logger.WithValues("Name", "qemu-scheduler"),
@@ -125,9 +218,12 @@ func (m *Manager) getSchedulerID(client *proxmox.Service) (*schedulerID, error)
 
 type Scheduler struct {
 	client          *proxmox.Service
+	clientMu        sync.RWMutex
 	schedulingQueue *queue.SchedulingQueue
 
-	registry plugins.PluginRegistry
+	// registries of the default plugin config and profiles
+	registries plugins.PluginRegistries
+	registryMu sync.RWMutex
 
 	// to do : cache
 
@@ -151,17 +247,54 @@ type SchedulerParams struct {
 	// file path for pluginConfig
 	PluginConfigFile string
 	pluginconfigs    plugins.PluginConfigs
+
+	// how often the plugin config file is checked for changes. defaults to 10s
+	PluginConfigReloadInterval time.Duration
 }
 
 func (p *SchedulerParams) PluginConfigs() plugins.PluginConfigs {
 	return p.pluginconfigs
 }
 
+const defaultPluginConfigReloadInterval = 10 * time.Second
+
 type schedulerID struct {
 	IPAddress   string
 	Fingreprint string
 }
 
+// return proxmox client which the scheduler currently uses
+func (s *Scheduler) getClient() *proxmox.Service {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.client
+}
+
+// replace proxmox client and return true if it differs from the current one
+func (s *Scheduler) setClient(client *proxmox.Service) bool {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	if s.client == client {
+		return false
+	}
+	s.client = client
+	return true
+}
+
+// return plugin registry of the scheduler profile bound to the context
+func (s *Scheduler) getRegistry(ctx context.Context) (plugins.PluginRegistry, error) {
+	s.registryMu.RLock()
+	defer s.registryMu.RUnlock()
+	return s.registries.Get(framework.ProfileFromContext(ctx))
+}
+
+// replace plugin registries e.g. with reloaded plugin config
+func (s *Scheduler) setRegistries(registries plugins.PluginRegistries) {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+	s.registries = registries
+}
+
 // run scheduler
 // and ensure only one process is running
 func (s *Scheduler) Run() {
@@ -200,17 +333,26 @@ func (s *Scheduler) ScheduleOne(ctx context.Context) {
 		return
 	}
 	config := qemu.Config()
-	qemuCtx := qemu.Context()
-	s.logger.Info("scheduling qemu")
+	profile := framework.ProfileFromContext(qemu.Context())
+	qemuCtx, span := tracing.Start(qemu.Context(), "scheduler.ScheduleOne", attribute.String("qemu", config.Name), attribute.String("profile", profile))
+	s.logger.Info("scheduling qemu", "profile", profile)
 
 	state := framework.NewCycleState()
+	state.Decision().Profile = profile
 	s.resultMap[config.Name] = make(chan *framework.CycleState, 1)
 	defer func() { s.resultMap[config.Name] <- &state }()
+	defer func() { tracing.End(span, state.Error()) }()
+	defer s.logDecision(config.Name, state.Decision())
+
+	if _, err := s.getRegistry(qemuCtx); err != nil {
+		s.recordFailure(&state, "ProfileNotFound", err)
+		return
+	}
 
 	// select node to run qemu
-	node, err := s.SelectNode(qemuCtx, *config)
+	node, err := s.selectNode(qemuCtx, &state, *config)
 	if err != nil {
-		state.UpdateState(true, err, framework.SchedulerResult{})
+		s.recordFailure(&state, "NodeSelectionFailed", err)
 		return
 	}
 
@@ -218,7 +360,7 @@ func (s *Scheduler) ScheduleOne(ctx context.Context) {
 	// to do: do this in parallel with SelectNode
 	vmid, err := s.SelectVMID(qemuCtx, *config)
 	if err != nil {
-		state.UpdateState(true, err, framework.SchedulerResult{})
+		s.recordFailure(&state, "VMIDSelectionFailed", err)
 		return
 	}
 
@@ -226,12 +368,39 @@ func (s *Scheduler) ScheduleOne(ctx context.Context) {
 	// must be done after node selection as some storages may not be available on some nodes
 	storage, err := s.SelectStorage(qemuCtx, *config, node)
 	if err != nil {
-		state.UpdateState(true, err, framework.SchedulerResult{})
+		s.recordFailure(&state, "StorageSelectionFailed", err)
 		return
 	}
 
 	result := framework.NewSchedulerResult(vmid, node, storage)
 	state.UpdateState(true, nil, result)
+	decision := state.Decision()
+	decision.Node, decision.VMID, decision.Storage = node, vmid, storage
+	metrics.SchedulingAttempts.WithLabelValues(metrics.ResultScheduled, "").Inc()
+}
+
+// put the error into the state and count the failed attempt.
+// reason is used unless the error tells no nodes or vmids are available
+func (s *Scheduler) recordFailure(state *framework.CycleState, reason string, err error) {
+	state.UpdateState(true, err, framework.SchedulerResult{})
+	state.Decision().Error = err.Error()
+	switch {
+	case errors.Is(err, ErrNoNodesAvailable):
+		reason = "NoNodesAvailable"
+	case errors.Is(err, ErrNoVMIDAvailable):
+		reason = "NoVMIDAvailable"
+	}
+	metrics.SchedulingAttempts.WithLabelValues(metrics.ResultFailed, reason).Inc()
+}
+
+// log why the node was selected, or why no node was selected
+func (s *Scheduler) logDecision(qemu string, decision *framework.Decision) {
+	log := s.logger.WithValues("qemu", qemu, "profile", decision.Profile, "decision", *decision)
+	if decision.Error != "" {
+		log.Info(fmt.Sprintf("failed to schedule qemu: %s", decision.Summary()), "error", decision.Error)
+		return
+	}
+	log.Info(fmt.Sprintf("scheduled qemu: %s", decision.Summary()), "node", decision.Node, "vmid", decision.VMID, "storage", decision.Storage)
 }
 
 // wait until CycleState is put into channel and then return it
@@ -259,36 +428,49 @@ func (s *Scheduler) WaitStatus(ctx context.Context, config *api.VirtualMachineCr
 
 // create new qemu with given spec and context
 func (s *Scheduler) CreateQEMU(ctx context.Context, config *api.VirtualMachineCreateOptions) (framework.SchedulerResult, error) {
+	status, err := s.Schedule(ctx, config)
+	return status.Result(), err
+}
+
+// schedule qemu with given spec and context, and return the state of the scheduling cycle
+// telling the result and why it was decided
+func (s *Scheduler) Schedule(ctx context.Context, config *api.VirtualMachineCreateOptions) (framework.CycleState, error) {
 	log := s.logger.WithValues("qemu", config.Name)
 	log.Info("adding qemu to scheduler queue")
 	// add qemu spec into the queue
 	s.schedulingQueue.Add(ctx, config)
 
 	// wait until the scheduller finishes its job
-	var err error
 	status, err := s.WaitStatus(ctx, config)
 	if err != nil {
-		return status.Result(), err
+		return status, err
 	}
 	if status.Error() != nil {
 		log.Error(status.Error(), fmt.Sprintf("failed to create qemu: %v", status.Messages()))
-		return status.Result(), status.Error()
+		return status, status.Error()
 	}
 	log.Info(fmt.Sprintf("%v", status.Messages()))
-	return status.Result(), nil
+	return status, nil
 }
 
 func (s *Scheduler) SelectNode(ctx context.Context, config api.VirtualMachineCreateOptions) (string, error) {
+	state := framework.NewCycleState()
+	return s.selectNode(ctx, &state, config)
+}
+
+// select node recording outcomes of the plugins into the state
+func (s *Scheduler) selectNode(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions) (string, error) {
 	s.logger.Info("finding proxmox node matching qemu")
-	nodes, err := s.client.GetNodes(ctx)
+	nodes, err := s.getClient().GetNodes(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	state := framework.NewCycleState()
-
 	// filter
-	nodelist, _ := s.RunFilterPlugins(ctx, &state, config, nodes)
+	nodelist, err := s.RunFilterPlugins(ctx, state, config, nodes)
+	if err != nil {
+		return "", err
+	}
 	if len(nodelist) == 0 {
 		return "", ErrNoNodesAvailable
 	}
@@ -297,7 +479,7 @@ func (s *Scheduler) SelectNode(ctx context.Context, config api.VirtualMachineCre
 	}
 
 	// score
-	scorelist, status := s.RunScorePlugins(ctx, &state, config, nodelist)
+	scorelist, status := s.RunScorePlugins(ctx, state, config, nodelist)
 	if !status.IsSuccess() {
 		s.logger.Error(status.Error(), "scoring failed")
 	}
@@ -309,23 +491,49 @@ func (s *Scheduler) SelectNode(ctx context.Context, config api.VirtualMachineCre
 	return selectedNode, nil
 }
 
-func (s *Scheduler) SelectVMID(ctx context.Context, config api.VirtualMachineCreateOptions) (int, error) {
+// return scores of the nodes which pass filter plugins for the given qemu spec.
+// unlike SelectNode, this does not select a node so it can be used for evaluating running qemus
+func (s *Scheduler) ScoreNodes(ctx context.Context, config api.VirtualMachineCreateOptions) (map[string]framework.NodeScore, error) {
+	nodes, err := s.getClient().GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := framework.NewCycleState()
+	nodelist, err := s.RunFilterPlugins(ctx, &state, config, nodes)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodelist) == 0 {
+		return nil, ErrNoNodesAvailable
+	}
+	scorelist, status := s.RunScorePlugins(ctx, &state, config, nodelist)
+	if !status.IsSuccess() {
+		return nil, fmt.Errorf("failed to score nodes")
+	}
+	return scorelist, nil
+}
+
+func (s *Scheduler) SelectVMID(ctx context.Context, config api.VirtualMachineCreateOptions) (vmid int, err error) {
+	ctx, span := tracing.Start(ctx, "scheduler.SelectVMID")
+	defer func() { tracing.End(span, err) }()
 	s.logger.Info("finding proxmox vmid to be assigned to qemu")
 	if config.VMID != nil {
 		return *config.VMID, nil
 	}
-	nextid, err := s.client.NextID(ctx)
+	nextid, err := s.getClient().NextID(ctx)
 	if err != nil {
 		return 0, err
 	}
-	usedID, err := usedIDMap(ctx, s.client)
+	usedID, err := usedIDMap(ctx, s.getClient())
 	if err != nil {
 		return 0, err
 	}
 	return s.RunVMIDPlugins(ctx, nil, config, nextid, *usedID)
 }
 
-func (s *Scheduler) SelectStorage(ctx context.Context, config api.VirtualMachineCreateOptions, nodeName string) (string, error) {
+func (s *Scheduler) SelectStorage(ctx context.Context, config api.VirtualMachineCreateOptions, nodeName string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "scheduler.SelectStorage", attribute.String("node", nodeName))
+	defer func() { tracing.End(span, err) }()
 	log := s.logger.WithValues("qemu", config.Name).WithValues("node", nodeName)
 	log.Info("finding proxmox storage to be used for qemu")
 	if config.Storage != "" {
@@ -333,7 +541,7 @@ func (s *Scheduler) SelectStorage(ctx context.Context, config api.VirtualMachine
 		return config.Storage, nil
 	}
 
-	node, err := s.client.Node(ctx, nodeName)
+	node, err := s.getClient().Node(ctx, nodeName)
 	if err != nil {
 		log.Error(err, "failed to get node")
 		return "", err
@@ -355,47 +563,67 @@ func (s *Scheduler) SelectStorage(ctx context.Context, config api.VirtualMachine
 	return "", fmt.Errorf("no storage available for VM image on node %s", nodeName)
 }
 
-func (s *Scheduler) RunFilterPlugins(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodes []*api.Node) ([]*api.Node, error) {
+func (s *Scheduler) RunFilterPlugins(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodes []*api.Node) (_ []*api.Node, err error) {
+	ctx, span := tracing.Start(ctx, "scheduler.Filter", attribute.Int("nodes", len(nodes)))
+	defer func() { tracing.End(span, err) }()
 	s.logger.Info("filtering proxmox node")
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
 	feasibleNodes := make([]*api.Node, 0, len(nodes))
-	nodeInfos, err := framework.GetNodeInfoList(ctx, s.client)
+	nodeInfos, err := framework.GetNodeInfoList(ctx, s.getClient())
 	if err != nil {
 		return nil, err
 	}
+	decision := state.Decision()
 	for _, nodeInfo := range nodeInfos {
 		status := framework.NewStatus()
-		for _, pl := range s.registry.FilterPlugins() {
+		for _, pl := range registry.FilterPlugins() {
 			status = pl.Filter(ctx, state, config, nodeInfo)
 			if !status.IsSuccess() {
 				status.SetFailedPlugin(pl.Name())
+				metrics.PluginFilteredNodes.WithLabelValues(pl.Name()).Inc()
+				decision.SetFiltered(nodeInfo.Node().Node, pl.Name(), state.Messages()[pl.Name()])
 				break
 			}
 		}
 		if status.IsSuccess() {
 			feasibleNodes = append(feasibleNodes, nodeInfo.Node())
+			decision.SetFeasible(nodeInfo.Node().Node)
 		}
 	}
+	span.SetAttributes(attribute.Int("feasibleNodes", len(feasibleNodes)))
 	return feasibleNodes, nil
 }
 
-func (s *Scheduler) RunScorePlugins(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodes []*api.Node) (map[string]framework.NodeScore, *framework.Status) {
+func (s *Scheduler) RunScorePlugins(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nodes []*api.Node) (_ map[string]framework.NodeScore, status *framework.Status) {
+	ctx, span := tracing.Start(ctx, "scheduler.Score", attribute.Int("nodes", len(nodes)))
+	defer func() { tracing.End(span, status.Error()) }()
 	s.logger.Info("scoring proxmox node")
-	status := framework.NewStatus()
+	status = framework.NewStatus()
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		status.SetCode(1)
+		s.logger.Error(err, "failed to get plugin registry")
+		return nil, status
+	}
 	scoresMap := make(map[string](map[string]framework.NodeScore))
-	for _, pl := range s.registry.ScorePlugins() {
+	for _, pl := range registry.ScorePlugins() {
 		scoresMap[pl.Name()] = make(map[string]framework.NodeScore)
 	}
-	nodeInfos, err := framework.GetNodeInfoList(ctx, s.client)
+	nodeInfos, err := framework.GetNodeInfoList(ctx, s.getClient())
 	if err != nil {
 		status.SetCode(1)
 		s.logger.Error(err, "failed to get node info list")
 		return nil, status
 	}
 	for _, nodeInfo := range nodeInfos {
-		for _, pl := range s.registry.ScorePlugins() {
+		for _, pl := range registry.ScorePlugins() {
 			score, status := pl.Score(ctx, state, config, nodeInfo)
 			if !status.IsSuccess() {
 				status.SetCode(1)
+				metrics.PluginErrors.WithLabelValues(pl.Name()).Inc()
 				s.logger.Error(status.Error(), fmt.Sprintf("failed to score node %s", nodeInfo.Node().Node))
 				return nil, status
 			}
@@ -408,10 +636,14 @@ func (s *Scheduler) RunScorePlugins(ctx context.Context, state *framework.CycleS
 	result := make(map[string]framework.NodeScore)
 	for _, node := range nodes {
 		result[node.Node] = framework.NodeScore{Name: node.Node, Score: 0}
+		scores := make(map[string]int64, len(scoresMap))
 		for plugin := range scoresMap {
 			r := result[node.Node]
 			r.Score += scoresMap[plugin][node.Node].Score
+			result[node.Node] = r
+			scores[plugin] = scoresMap[plugin][node.Node].Score
 		}
+		state.Decision().SetScores(node.Node, scores)
 	}
 	return result, status
 }
@@ -430,7 +662,11 @@ func selectHighestScoreNode(scoreList map[string]framework.NodeScore) (string, e
 }
 
 func (s *Scheduler) RunVMIDPlugins(ctx context.Context, state *framework.CycleState, config api.VirtualMachineCreateOptions, nextid int, usedID map[int]bool) (int, error) {
-	for _, pl := range s.registry.VMIDPlugins() {
+	registry, err := s.getRegistry(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, pl := range registry.VMIDPlugins() {
 		key := pl.PluginKey()
 		value := ctx.Value(key)
 		if value != nil {
@@ -2,15 +2,25 @@ package scheduler_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/names"
 )
 
 var _ = Describe("NewManager", Label("unit", "scheduler"), func() {
@@ -50,6 +60,185 @@ var _ = Describe("NewScheduler", Label("unit", "scheduler"), func() {
 	})
 })
 
+var _ = Describe("UpdatePluginConfigs", Label("unit", "scheduler"), func() {
+	var manager *scheduler.Manager
+	var sched *scheduler.Scheduler
+
+	BeforeEach(func() {
+		var err error
+		manager, err = scheduler.NewManager(scheduler.SchedulerParams{Logger: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))})
+		Expect(err).NotTo(HaveOccurred())
+		sched = manager.NewScheduler(proxmoxSvc)
+		manager.RegisterScheduler("192.168.0.1", sched)
+	})
+
+	It("should swap plugin registry of registered schedulers", func() {
+		registry, err := sched.Registry("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.FilterPlugins()).To(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+
+		err = manager.UpdatePluginConfigs(plugins.PluginConfigs{
+			FilterPlugins: map[string]plugins.PluginConfig{names.CPUOvercommit: {Enable: ptr.To(false)}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		registry, err = sched.Registry("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.FilterPlugins()).NotTo(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+	})
+
+	It("should keep current plugin registry with invalid config", func() {
+		err := manager.UpdatePluginConfigs(plugins.PluginConfigs{
+			FilterPlugins: map[string]plugins.PluginConfig{"Unknown": {Enable: ptr.To(false)}},
+		})
+		Expect(err).To(HaveOccurred())
+		registry, err := sched.Registry("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.FilterPlugins()).To(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+	})
+
+	It("should not leave stale plugin registry with concurrent updates", func() {
+		configs := []plugins.PluginConfigs{
+			{FilterPlugins: map[string]plugins.PluginConfig{names.CPUOvercommit: {Enable: ptr.To(false)}}},
+			{FilterPlugins: map[string]plugins.PluginConfig{names.CPUOvercommit: {Enable: ptr.To(true)}}},
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(config plugins.PluginConfigs) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(manager.UpdatePluginConfigs(config)).To(Succeed())
+			}(configs[i%2])
+		}
+		wg.Wait()
+
+		enabled := *manager.PluginConfigs().FilterPlugins[names.CPUOvercommit].Enable
+		registry, err := sched.Registry("")
+		Expect(err).NotTo(HaveOccurred())
+		if enabled {
+			Expect(registry.FilterPlugins()).To(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+		} else {
+			Expect(registry.FilterPlugins()).NotTo(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+		}
+	})
+
+	It("should reload plugin config when the file changes", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "plugin-config.yaml")
+		Expect(os.WriteFile(path, []byte(""), 0600)).To(Succeed())
+		manager, err := scheduler.NewManager(scheduler.SchedulerParams{
+			Logger:                     zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+			PluginConfigFile:           path,
+			PluginConfigReloadInterval: 100 * time.Millisecond,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		sched := manager.NewScheduler(proxmoxSvc)
+		manager.RegisterScheduler("192.168.0.1", sched)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			defer GinkgoRecover()
+			Expect(manager.Start(ctx)).To(Succeed())
+		}()
+
+		Expect(os.WriteFile(path, []byte("scores:\n  NodeResource:\n    enable: false"), 0600)).To(Succeed())
+		Eventually(func() int {
+			registry, err := sched.Registry("")
+			Expect(err).NotTo(HaveOccurred())
+			return len(registry.ScorePlugins())
+		}).Should(Equal(1))
+	})
+})
+
+var _ = Describe("Scheduler profiles", Label("unit", "scheduler"), func() {
+	It("should use the registry of the profile bound to the context", func() {
+		manager, err := scheduler.NewManager(scheduler.SchedulerParams{Logger: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))})
+		Expect(err).NotTo(HaveOccurred())
+		sched := manager.NewScheduler(proxmoxSvc)
+		manager.RegisterScheduler("192.168.0.1", sched)
+		err = manager.UpdatePluginConfigs(plugins.PluginConfigs{
+			Profiles: map[string]plugins.PluginProfile{
+				"dev": {FilterPlugins: map[string]plugins.PluginConfig{names.CPUOvercommit: {Enable: ptr.To(false)}}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		registry, err := sched.Registry("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.FilterPlugins()).To(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+		registry, err = sched.Registry("dev")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.FilterPlugins()).NotTo(ContainElement(HaveField("Name()", names.CPUOvercommit)))
+		_, err = sched.Registry("production")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail scheduling with unknown profile", func() {
+		manager, err := scheduler.NewManager(scheduler.SchedulerParams{Logger: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))})
+		Expect(err).NotTo(HaveOccurred())
+		sched := manager.NewScheduler(proxmoxSvc)
+		sched.RunAsync()
+		defer sched.Stop()
+
+		ctx := framework.ContextWithProfile(context.Background(), "production")
+		state, err := sched.Schedule(ctx, &api.VirtualMachineCreateOptions{Name: "profile-test"})
+		Expect(err).To(MatchError(ContainSubstring(`scheduler profile "production" not found`)))
+		Expect(state.Decision().Profile).To(Equal("production"))
+		Expect(state.Decision().Error).To(Equal(err.Error()))
+	})
+})
+
+var _ = Describe("RunScorePlugins", Label("unit", "scheduler"), func() {
+	var server *httptest.Server
+
+	// two empty nodes having 16 cpus and 32GiB memory
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api2/json/nodes":
+				fmt.Fprint(w, `{"data":[{"node":"node1","status":"online","maxcpu":16,"maxmem":34359738368},{"node":"node2","status":"online","maxcpu":16,"maxmem":34359738368}]}`)
+			case "/api2/json/nodes/node1/qemu", "/api2/json/nodes/node2/qemu":
+				fmt.Fprint(w, `{"data":[]}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should sum up scores of all the score plugins per node", func() {
+		client, err := proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+		manager, err := scheduler.NewManager(scheduler.SchedulerParams{Logger: zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))})
+		Expect(err).NotTo(HaveOccurred())
+		sched := manager.NewScheduler(client)
+		manager.RegisterScheduler("192.168.0.1", sched)
+		Expect(manager.UpdatePluginConfigs(plugins.PluginConfigs{
+			ScorePlugins: map[string]plugins.PluginConfig{
+				names.NodeResource:   {Enable: ptr.To(false)},
+				names.NodeAffinity:   {Enable: ptr.To(false)},
+				names.LeastAllocated: {Enable: ptr.To(true)},
+				names.MostAllocated:  {Enable: ptr.To(true)},
+			},
+		})).To(Succeed())
+
+		ctx := context.Background()
+		nodes, err := client.GetNodes(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		state := framework.NewCycleState()
+		// 2 cores and 2GiB memory. LeastAllocated scores 90 and MostAllocated scores 9
+		scores, status := sched.RunScorePlugins(ctx, &state, api.VirtualMachineCreateOptions{Cores: 2, Memory: 2048}, nodes)
+		Expect(status.IsSuccess()).To(BeTrue())
+		Expect(scores).To(Equal(map[string]framework.NodeScore{
+			"node1": {Name: "node1", Score: 99},
+			"node2": {Name: "node2", Score: 99},
+		}))
+		Expect(state.Decision().Nodes["node1"].Total).To(Equal(int64(99)))
+	})
+})
+
 var _ = Describe("GetOrCreateScheduler", Label("integration", "scheduler"), func() {
 	manager, err := scheduler.NewManager(scheduler.SchedulerParams{})
 	Expect(err).NotTo(HaveOccurred())
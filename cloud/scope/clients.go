@@ -18,24 +18,216 @@ package scope
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/trust"
 )
 
+const (
+	// keys of the secret referenced by serverRef.secretRef
+	userKey     = "PROXMOX_USER"
+	passwordKey = "PROXMOX_PASSWORD"
+	tokenIDKey  = "PROXMOX_TOKENID"
+	secretKey   = "PROXMOX_SECRET"
+	caCertKey   = "PROXMOX_CA_CERT"
+
+	defaultCAConfigMapKey = "ca.crt"
+)
+
+// namespace of the controller where secrets of ProxmoxClusterIdentities are kept
+var identitySecretNamespace = "cappx-system"
+
+// IdentitySecretNamespace returns the namespace where secrets of ProxmoxClusterIdentities are kept
+func IdentitySecretNamespace() string {
+	return identitySecretNamespace
+}
+
+// SetIdentitySecretNamespace sets the namespace where secrets of ProxmoxClusterIdentities are kept.
+// it should be called before starting the controllers
+func SetIdentitySecretNamespace(namespace string) {
+	identitySecretNamespace = namespace
+}
+
+// map[string]*proxmox.Service keyed by endpoint, credentials and whether the certificate is verified.
+// proxmox-go caches services too but its key doesn't tell whether the certificate is verified
+var computeServices sync.Map
+
+// map[string]string of the key of computeServices which each ProxmoxCluster and its failure domains use,
+// so that the service with stale credentials is dropped when the secret is rotated.
+// it is keyed by servicesID
+var clusterServiceKeys sync.Map
+
 type ProxmoxServices struct {
 	Compute *proxmox.Service
+
+	// Endpoint is the endpoint of serverRef which the services access
+	Endpoint string
+
+	// Upload is set only if proxmox is accessed with API token.
+	// files are uploaded via the API then since API token cannot open node shells
+	Upload *upload.Client
+
+	// InsecureReason tells why the certificate of the endpoint is not verified. empty if it is verified
+	InsecureReason string
+}
+
+// servicesID identifies the services of the ProxmoxCluster or its failure domain in the caches
+func servicesID(cluster *infrav1.ProxmoxCluster, failureDomain string) string {
+	if failureDomain == "" {
+		return string(cluster.UID)
+	}
+	return string(cluster.UID) + "/" + failureDomain
+}
+
+func newProxmoxServices(ctx context.Context, cluster *infrav1.ProxmoxCluster, crClient client.Client) (ProxmoxServices, error) {
+	return newProxmoxServicesFor(ctx, cluster, cluster.Spec.ServerRef, servicesID(cluster, ""), crClient)
+}
+
+// newProxmoxServicesFor returns services for the serverRef of the ProxmoxCluster or its failure domain
+func newProxmoxServicesFor(ctx context.Context, cluster *infrav1.ProxmoxCluster, serverRef infrav1.ServerRef, id string, crClient client.Client) (ProxmoxServices, error) {
+	secret, err := getCredentialsSecret(ctx, cluster, serverRef, crClient)
+	if err != nil {
+		return ProxmoxServices{}, err
+	}
+
+	tlsConfig, insecureReason, err := configureTLS(ctx, cluster, serverRef, secret, crClient)
+	if err != nil {
+		return ProxmoxServices{}, err
+	}
+	insecure := insecureReason != ""
+
+	authConfig := proxmox.AuthConfig{
+		Username: string(secret.Data[userKey]),
+		Password: string(secret.Data[passwordKey]),
+		TokenID:  string(secret.Data[tokenIDKey]),
+		Secret:   string(secret.Data[secretKey]),
+	}
+	if _, err := loginOption(authConfig); err != nil {
+		return ProxmoxServices{}, err
+	}
+	endpoint := selectEndpoint(ctx, id, endpoints(serverRef), tlsConfig, insecure)
+	base, err := trust.Transport(endpoint, tlsConfig, insecure)
+	if err != nil {
+		return ProxmoxServices{}, err
+	}
+	transport := apiTransport(base)
+	compute, err := getOrCreateComputeService(id, endpoint, authConfig, transport)
+	if err != nil {
+		return ProxmoxServices{}, err
+	}
+	services := ProxmoxServices{Compute: compute, Endpoint: endpoint, InsecureReason: insecureReason}
+	// proxmox-go prefers user/password to API token
+	if authConfig.Username == "" || authConfig.Password == "" {
+		services.Upload = upload.NewClient(endpoint, authConfig.TokenID, authConfig.Secret, transport)
+	}
+	return services, nil
 }
 
-func newComputeService(ctx context.Context, cluster *infrav1.ProxmoxCluster, crClient client.Client) (*proxmox.Service, error) {
-	serverRef := cluster.Spec.ServerRef
+// map[*http.Transport]*http.Transport of transports returned by trust.Transport to
+// the transports instrumenting proxmox API requests sent through them
+var apiTransports sync.Map
+
+// apiTransport returns the transport tracing and recording metrics of proxmox API requests sent through base.
+// proxmox-go reads the TLS config of the base transport of its REST client for websockets,
+// so the returned transport has the TLS config of base and passes every request to the
+// instrumented round tripper registered for http and https instead of wrapping base
+func apiTransport(base *http.Transport) *http.Transport {
+	if t, ok := apiTransports.Load(base); ok {
+		return t.(*http.Transport)
+	}
+	rt := tracing.NewProxmoxTransport(metrics.NewProxmoxTransport(base))
+	t := &http.Transport{
+		Proxy:           base.Proxy,
+		TLSClientConfig: base.TLSClientConfig,
+		// the transport never dials by itself
+		TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+	}
+	t.RegisterProtocol("http", rt)
+	t.RegisterProtocol("https", rt)
+	actual, _ := apiTransports.LoadOrStore(base, t)
+	return actual.(*http.Transport)
+}
+
+// getOrCreateComputeService returns the service of the endpoint using the transport,
+// which is shared by the endpoints having the same TLS config
+func getOrCreateComputeService(id string, endpoint string, authConfig proxmox.AuthConfig, transport *http.Transport) (*proxmox.Service, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n%p", endpoint, authConfig.Username, authConfig.Password, authConfig.TokenID, authConfig.Secret, transport)
+	key := fmt.Sprintf("%x", h.Sum(nil))
+	if old, loaded := clusterServiceKeys.Swap(id, key); loaded && old.(string) != key {
+		// credentials or endpoint have been changed
+		computeServices.Delete(old)
+	}
+	if svc, ok := computeServices.Load(key); ok {
+		return svc.(*proxmox.Service), nil
+	}
+
+	svc, err := newComputeService(endpoint, authConfig, transport)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := computeServices.LoadOrStore(key, svc)
+	return actual.(*proxmox.Service), nil
+}
+
+// newComputeService returns the service sending requests with the transport.
+// proxmox-go builds its REST client with either http.DefaultTransport or a transport
+// skipping verification and has no constructor taking a transport, so the REST client
+// built with the transport replaces the one of the service
+func newComputeService(endpoint string, authConfig proxmox.AuthConfig, transport *http.Transport) (*proxmox.Service, error) {
+	login, err := loginOption(authConfig)
+	if err != nil {
+		return nil, err
+	}
+	restclient, err := rest.NewRESTClient(endpoint, transport, login)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := proxmox.NewService(proxmox.NewParams(endpoint, authConfig, proxmox.ClientConfig{}))
+	if err != nil {
+		return nil, err
+	}
+	*svc.RESTClient() = *restclient
+	return svc, nil
+}
+
+// loginOption returns how the REST client logs in to proxmox. user/password is preferred to API token
+func loginOption(authConfig proxmox.AuthConfig) (rest.ClientOption, error) {
+	switch {
+	case authConfig.Username != "" && authConfig.Password != "":
+		return rest.WithUserPassword(authConfig.Username, authConfig.Password), nil
+	case authConfig.TokenID != "" && authConfig.Secret != "":
+		return rest.WithAPIToken(authConfig.TokenID, authConfig.Secret), nil
+	default:
+		return nil, errors.New("invalid authentication config")
+	}
+}
+
+// getCredentialsSecret returns the secret referenced by the serverRef directly
+// or through ProxmoxClusterIdentity
+func getCredentialsSecret(ctx context.Context, cluster *infrav1.ProxmoxCluster, serverRef infrav1.ServerRef, crClient client.Client) (*corev1.Secret, error) {
+	if serverRef.IdentityRef != nil {
+		return getIdentitySecret(ctx, cluster, serverRef.IdentityRef, crClient)
+	}
 	secretRef := serverRef.SecretRef
 	if secretRef == nil {
 		return nil, errors.New("failed to get proxmox client from nil secretRef")
@@ -56,16 +248,122 @@ func newComputeService(ctx context.Context, cluster *infrav1.ProxmoxCluster, crC
 	if err := crClient.Update(ctx, &secret); err != nil {
 		return nil, fmt.Errorf("failed to set ownerReference to secret: %w", err)
 	}
+	return &secret, nil
+}
 
-	authConfig := proxmox.AuthConfig{
-		Username: string(secret.Data["PROXMOX_USER"]),
-		Password: string(secret.Data["PROXMOX_PASSWORD"]),
-		TokenID:  string(secret.Data["PROXMOX_TOKENID"]),
-		Secret:   string(secret.Data["PROXMOX_SECRET"]),
+// getIdentitySecret returns the secret of ProxmoxClusterIdentity in the namespace of the controller
+// if the identity allows the namespace of the ProxmoxCluster.
+// the secret is shared by ProxmoxClusters, so ownerReference is not set unlike secretRef
+func getIdentitySecret(ctx context.Context, cluster *infrav1.ProxmoxCluster, identityRef *infrav1.ProxmoxClusterIdentityReference, crClient client.Client) (*corev1.Secret, error) {
+	var identity infrav1.ProxmoxClusterIdentity
+	if err := crClient.Get(ctx, client.ObjectKey{Name: identityRef.Name}, &identity); err != nil {
+		return nil, fmt.Errorf("failed to get ProxmoxClusterIdentity from identityRef: %w", err)
+	}
+
+	var namespace corev1.Namespace
+	if err := crClient.Get(ctx, client.ObjectKey{Name: cluster.Namespace}, &namespace); err != nil {
+		return nil, fmt.Errorf("failed to get namespace of ProxmoxCluster: %w", err)
+	}
+	allowed, err := IsNamespaceAllowed(&identity, &namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Errorf("ProxmoxClusterIdentity %s does not allow namespace %s", identity.Name, namespace.Name)
+	}
+
+	var secret corev1.Secret
+	key := client.ObjectKey{Namespace: IdentitySecretNamespace(), Name: identity.Spec.SecretName}
+	if err := crClient.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret of ProxmoxClusterIdentity: %w", err)
+	}
+	return &secret, nil
+}
+
+// IsNamespaceAllowed returns true if the identity allows the namespace
+// by either the list of names or the label selector
+func IsNamespaceAllowed(identity *infrav1.ProxmoxClusterIdentity, namespace *corev1.Namespace) (bool, error) {
+	allowed := identity.Spec.AllowedNamespaces
+	if allowed == nil {
+		return false, nil
+	}
+	if len(allowed.NamespaceList) == 0 && allowed.Selector == nil {
+		return true, nil
+	}
+	if slices.Contains(allowed.NamespaceList, namespace.Name) {
+		return true, nil
+	}
+	if allowed.Selector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(allowed.Selector)
+	if err != nil {
+		return false, errors.Errorf("invalid allowedNamespaces selector of ProxmoxClusterIdentity %s: %v", identity.Name, err)
+	}
+	return selector.Matches(labels.Set(namespace.Labels)), nil
+}
+
+// InvalidateProxmoxServices drops the cached service of the cluster
+// so that the next reconciliation reads the secret and logs in to proxmox again.
+// it should be called when proxmox rejects the credentials or the ticket
+func InvalidateProxmoxServices(cluster *infrav1.ProxmoxCluster) {
+	clusterServiceKeys.Range(func(id, key any) bool {
+		if isServicesOf(id.(string), cluster) {
+			clusterServiceKeys.Delete(id)
+			computeServices.Delete(key)
+		}
+		return true
+	})
+	ResetActiveEndpoint(cluster)
+}
+
+// isServicesOf returns true if the servicesID belongs to the ProxmoxCluster or its failure domains
+func isServicesOf(id string, cluster *infrav1.ProxmoxCluster) bool {
+	return id == string(cluster.UID) || strings.HasPrefix(id, string(cluster.UID)+"/")
+}
+
+// configureTLS returns how the certificates of the endpoints of serverRef are verified.
+// it returns the reason if the certificate should not be verified, which is the case
+// when neither spec.serverRef.tls nor PROXMOX_CA_CERT is given for backward compatibility
+func configureTLS(ctx context.Context, cluster *infrav1.ProxmoxCluster, serverRef infrav1.ServerRef, secret *corev1.Secret, crClient client.Client) (*trust.Config, string, error) {
+	caBundle := secret.Data[caCertKey]
+	tlsConfig := serverRef.TLS
+	if tlsConfig == nil {
+		if len(caBundle) == 0 {
+			return nil, "neither serverRef.tls nor " + caCertKey + " is given", nil
+		}
+		tlsConfig = &infrav1.ServerTLSConfig{}
+	}
+	if tlsConfig.InsecureSkipVerify {
+		return nil, "serverRef.tls.insecureSkipVerify is set", nil
+	}
+
+	if ref := tlsConfig.CAConfigMapRef; ref != nil {
+		namespace, key := ref.Namespace, ref.Key
+		if namespace == "" {
+			namespace = cluster.Namespace
+		}
+		if key == "" {
+			key = defaultCAConfigMapKey
+		}
+		var configMap corev1.ConfigMap
+		if err := crClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &configMap); err != nil {
+			return nil, "", fmt.Errorf("failed to get configmap from caConfigMapRef: %w", err)
+		}
+		data, ok := configMap.Data[key]
+		if !ok {
+			return nil, "", errors.Errorf("configmap %s/%s has no key %s", namespace, ref.Name, key)
+		}
+		caBundle = []byte(data)
+	}
+
+	fingerprints := make([]string, 0, len(tlsConfig.Fingerprints))
+	for _, fp := range tlsConfig.Fingerprints {
+		fingerprints = append(fingerprints, string(fp))
 	}
-	clientConfig := proxmox.ClientConfig{
-		InsecureSkipVerify: true,
+	config, err := trust.NewConfig(caBundle, fingerprints)
+	if err != nil {
+		return nil, "", errors.Errorf("invalid tls config of serverRef: %v", err)
 	}
-	param := proxmox.NewParams(serverRef.Endpoint, authConfig, clientConfig)
-	return proxmox.GetOrCreateService(param)
+	return config, "", nil
 }
@@ -2,17 +2,28 @@ package scope
 
 import (
 	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/trust"
 )
 
-var _ = Describe("newComputeService", Label("unit", "scope"), func() {
+var _ = Describe("newProxmoxServices", Label("unit", "scope"), func() {
 	var cluster *infrav1.ProxmoxCluster
 
 	Context("When SecretRef is not present in ProxmoxCluster", func() {
@@ -20,9 +31,9 @@ var _ = Describe("newComputeService", Label("unit", "scope"), func() {
 			cluster = &infrav1.ProxmoxCluster{}
 		})
 		It("should return proper error", func() {
-			svc, err := newComputeService(context.TODO(), cluster, k8sClient)
+			services, err := newProxmoxServices(context.TODO(), cluster, k8sClient)
 			Expect(err.Error()).To(Equal("failed to get proxmox client from nil secretRef"))
-			Expect(svc).To(BeNil())
+			Expect(services.Compute).To(BeNil())
 		})
 	})
 
@@ -36,9 +47,9 @@ var _ = Describe("newComputeService", Label("unit", "scope"), func() {
 		})
 
 		It("should return proper error", func() {
-			svc, err := newComputeService(context.TODO(), cluster, k8sClient)
+			services, err := newProxmoxServices(context.TODO(), cluster, k8sClient)
 			Expect(errors.IsNotFound(err)).To(BeTrue())
-			Expect(svc).To(BeNil())
+			Expect(services.Compute).To(BeNil())
 		})
 	})
 
@@ -59,9 +70,179 @@ var _ = Describe("newComputeService", Label("unit", "scope"), func() {
 		})
 
 		It("Should return proper error", func() {
-			svc, err := newComputeService(context.TODO(), cluster, k8sClient)
+			services, err := newProxmoxServices(context.TODO(), cluster, k8sClient)
 			Expect(err.Error()).To(Equal("invalid authentication config"))
-			Expect(svc).To(BeNil())
+			Expect(services.Compute).To(BeNil())
 		})
 	})
 })
+
+var _ = Describe("getOrCreateComputeService", Label("unit", "scope"), func() {
+	endpoint := "https://192.168.0.10:8006/api2/json"
+	var transport *http.Transport
+
+	BeforeEach(func() {
+		var err error
+		transport, err = trust.Transport(endpoint, nil, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should build the service with the transport", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":{"version":"8.2.4"}}`)
+		}))
+		defer server.Close()
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		config, err := trust.NewConfig(caBundle, nil)
+		Expect(err).NotTo(HaveOccurred())
+		verified, err := trust.Transport(server.URL, config, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		// the certificate of the test server is verified only by the transport
+		svc, err := newComputeService(server.URL+"/api2/json", proxmox.AuthConfig{TokenID: "root@pam!cappx", Secret: "foo"}, verified)
+		Expect(err).NotTo(HaveOccurred())
+		version, err := svc.RESTClient().GetVersion(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version.Version).To(Equal("8.2.4"))
+	})
+
+	It("should trace requests of the service through the api transport", func() {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		otel.SetTracerProvider(provider)
+		defer func() { Expect(provider.Shutdown(context.TODO())).To(Succeed()) }()
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":{"version":"8.2.4"}}`)
+		}))
+		defer server.Close()
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		config, err := trust.NewConfig(caBundle, nil)
+		Expect(err).NotTo(HaveOccurred())
+		verified, err := trust.Transport(server.URL, config, false)
+		Expect(err).NotTo(HaveOccurred())
+		transport := apiTransport(verified)
+		Expect(apiTransport(verified)).To(BeIdenticalTo(transport))
+		Expect(transport.TLSClientConfig).To(BeIdenticalTo(verified.TLSClientConfig))
+
+		svc, err := newComputeService(server.URL+"/api2/json", proxmox.AuthConfig{TokenID: "root@pam!cappx", Secret: "foo"}, transport)
+		Expect(err).NotTo(HaveOccurred())
+		ctx, span := tracing.Start(context.TODO(), "ProxmoxCluster.Reconcile")
+		_, err = svc.RESTClient().GetVersion(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		tracing.End(span, nil)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal("proxmox.Request"))
+		Expect(spans[0].Parent().SpanID()).To(Equal(span.SpanContext().SpanID()))
+	})
+
+	It("should recreate the service when the TLS config is changed", func() {
+		uid := "tls"
+		authConfig := proxmox.AuthConfig{Username: "root@pam", Password: "foo"}
+		svc, err := getOrCreateComputeService(uid, endpoint, authConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		config, err := trust.NewConfig(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		verified, err := trust.Transport(endpoint, config, false)
+		Expect(err).NotTo(HaveOccurred())
+		recreated, err := getOrCreateComputeService(uid, endpoint, authConfig, verified)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recreated).NotTo(BeIdenticalTo(svc))
+	})
+
+	It("should recreate the service when the credentials are rotated", func() {
+		uid := "rotate"
+		svc, err := getOrCreateComputeService(uid, endpoint, proxmox.AuthConfig{Username: "root@pam", Password: "foo"}, transport)
+		Expect(err).NotTo(HaveOccurred())
+		cached, err := getOrCreateComputeService(uid, endpoint, proxmox.AuthConfig{Username: "root@pam", Password: "foo"}, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached).To(BeIdenticalTo(svc))
+
+		rotated, err := getOrCreateComputeService(uid, endpoint, proxmox.AuthConfig{Username: "root@pam", Password: "bar"}, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated).NotTo(BeIdenticalTo(svc))
+		again, err := getOrCreateComputeService(uid, endpoint, proxmox.AuthConfig{Username: "root@pam", Password: "foo"}, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).NotTo(BeIdenticalTo(svc))
+	})
+
+	It("should recreate the invalidated service", func() {
+		cluster := &infrav1.ProxmoxCluster{}
+		cluster.SetUID("invalidate")
+		authConfig := proxmox.AuthConfig{TokenID: "root@pam!cappx", Secret: "foo"}
+		svc, err := getOrCreateComputeService(servicesID(cluster, ""), endpoint, authConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		InvalidateProxmoxServices(cluster)
+		recreated, err := getOrCreateComputeService(servicesID(cluster, ""), endpoint, authConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recreated).NotTo(BeIdenticalTo(svc))
+	})
+
+	It("should recreate the invalidated services of failure domains", func() {
+		cluster := &infrav1.ProxmoxCluster{}
+		cluster.SetUID("invalidate-fd")
+		authConfig := proxmox.AuthConfig{TokenID: "root@pam!cappx", Secret: "foo"}
+		svc, err := getOrCreateComputeService(servicesID(cluster, "site-a"), endpoint, authConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		other := &infrav1.ProxmoxCluster{}
+		other.SetUID("invalidate-fd-other")
+		otherAuthConfig := proxmox.AuthConfig{TokenID: "root@pam!cappx", Secret: "bar"}
+		otherSvc, err := getOrCreateComputeService(servicesID(other, "site-a"), endpoint, otherAuthConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+
+		InvalidateProxmoxServices(cluster)
+		recreated, err := getOrCreateComputeService(servicesID(cluster, "site-a"), endpoint, authConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recreated).NotTo(BeIdenticalTo(svc))
+		cached, err := getOrCreateComputeService(servicesID(other, "site-a"), endpoint, otherAuthConfig, transport)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached).To(BeIdenticalTo(otherSvc))
+	})
+})
+
+var _ = Describe("configureTLS", Label("unit", "scope"), func() {
+	cluster := &infrav1.ProxmoxCluster{}
+
+	It("should tell why the certificate is not verified", func() {
+		_, reason, err := configureTLS(context.TODO(), cluster, infrav1.ServerRef{}, &corev1.Secret{}, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(Equal("neither serverRef.tls nor PROXMOX_CA_CERT is given"))
+
+		serverRef := infrav1.ServerRef{TLS: &infrav1.ServerTLSConfig{InsecureSkipVerify: true}}
+		_, reason, err = configureTLS(context.TODO(), cluster, serverRef, &corev1.Secret{}, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(Equal("serverRef.tls.insecureSkipVerify is set"))
+	})
+
+	It("should verify the certificate with fingerprints", func() {
+		serverRef := infrav1.ServerRef{TLS: &infrav1.ServerTLSConfig{
+			Fingerprints: []infrav1.CertificateFingerprint{"AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67:89"},
+		}}
+		config, reason, err := configureTLS(context.TODO(), cluster, serverRef, &corev1.Secret{}, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reason).To(BeEmpty())
+		Expect(config).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("IsNamespaceAllowed", Label("unit", "scope"), func() {
+	namespace := &corev1.Namespace{}
+	namespace.SetName("tenant-a")
+	namespace.SetLabels(map[string]string{"tenant": "a"})
+
+	DescribeTable("should match the namespace", func(allowed *infrav1.AllowedNamespaces, expected bool) {
+		identity := &infrav1.ProxmoxClusterIdentity{Spec: infrav1.ProxmoxClusterIdentitySpec{SecretName: "pve", AllowedNamespaces: allowed}}
+		ok, err := IsNamespaceAllowed(identity, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(Equal(expected))
+	},
+		Entry("nil allows nothing", nil, false),
+		Entry("empty allows everything", &infrav1.AllowedNamespaces{}, true),
+		Entry("listed name", &infrav1.AllowedNamespaces{NamespaceList: []string{"tenant-a"}}, true),
+		Entry("unlisted name", &infrav1.AllowedNamespaces{NamespaceList: []string{"tenant-b"}}, false),
+		Entry("matching selector", &infrav1.AllowedNamespaces{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}}}, true),
+		Entry("unmatching selector", &infrav1.AllowedNamespaces{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}}}, false),
+	)
+})
@@ -23,11 +23,13 @@ import (
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	"github.com/pkg/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/storage"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
 )
 
 type ClusterScopeParams struct {
@@ -47,11 +49,11 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 	populateNamespace(params.ProxmoxCluster)
 
 	if params.ProxmoxServices.Compute == nil {
-		computeSvc, err := newComputeService(ctx, params.ProxmoxCluster, params.Client)
+		services, err := newProxmoxServices(ctx, params.ProxmoxCluster, params.Client)
 		if err != nil {
 			return nil, errors.Errorf("failed to create proxmox compute client: %v", err)
 		}
-		params.ProxmoxServices.Compute = computeSvc
+		params.ProxmoxServices = services
 	}
 
 	helper, err := patch.NewHelper(params.ProxmoxCluster, params.Client)
@@ -69,8 +71,13 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 }
 
 func populateNamespace(proxmoxCluster *infrav1.ProxmoxCluster) {
-	if proxmoxCluster.Spec.ServerRef.SecretRef.Namespace == "" {
-		proxmoxCluster.Spec.ServerRef.SecretRef.Namespace = proxmoxCluster.Namespace
+	if ref := proxmoxCluster.Spec.ServerRef.SecretRef; ref != nil && ref.Namespace == "" {
+		ref.Namespace = proxmoxCluster.Namespace
+	}
+	for _, fd := range proxmoxCluster.Spec.FailureDomains {
+		if fd.ServerRef != nil && fd.ServerRef.SecretRef != nil && fd.ServerRef.SecretRef.Namespace == "" {
+			fd.ServerRef.SecretRef.Namespace = proxmoxCluster.Namespace
+		}
 	}
 }
 
@@ -80,6 +87,50 @@ type ClusterScope struct {
 	patchHelper    *patch.Helper
 	Cluster        *clusterv1.Cluster
 	ProxmoxCluster *infrav1.ProxmoxCluster
+
+	// failure domain whose Proxmox-VE cluster the clients access. empty for spec.serverRef
+	failureDomain string
+}
+
+// ForFailureDomain returns the scope whose clients access the Proxmox-VE cluster of the failure domain.
+// the scope itself is returned if the failure domain is empty or doesn't have its own serverRef.
+// the returned scope shares the ProxmoxCluster and the patch helper with the scope
+func (s *ClusterScope) ForFailureDomain(ctx context.Context, name string) (*ClusterScope, error) {
+	if name == "" {
+		return s, nil
+	}
+	fd := s.ProxmoxCluster.GetFailureDomain(name)
+	if fd == nil {
+		return nil, errors.Errorf("failure domain %s is not defined in ProxmoxCluster %s", name, s.ProxmoxCluster.Name)
+	}
+	if fd.ServerRef == nil {
+		return s, nil
+	}
+	services, err := newProxmoxServicesFor(ctx, s.ProxmoxCluster, *fd.ServerRef, servicesID(s.ProxmoxCluster, name), s.client)
+	if err != nil {
+		return nil, errors.Errorf("failed to create proxmox compute client of failure domain %s: %v", name, err)
+	}
+	fdScope := *s
+	fdScope.ProxmoxServices = services
+	fdScope.failureDomain = name
+	return &fdScope, nil
+}
+
+// FailureDomain returns the failure domain whose Proxmox-VE cluster the clients access.
+// it is empty for the Proxmox-VE cluster of spec.serverRef
+func (s *ClusterScope) FailureDomain() string {
+	return s.failureDomain
+}
+
+// SiteFailureDomains returns names of the failure domains having their own Proxmox-VE cluster
+func (s *ClusterScope) SiteFailureDomains() []string {
+	names := []string{}
+	for _, fd := range s.ProxmoxCluster.Spec.FailureDomains {
+		if fd.ServerRef != nil {
+			names = append(names, fd.Name)
+		}
+	}
+	return names
 }
 
 func (s *ClusterScope) Name() string {
@@ -109,10 +160,36 @@ func (s *ClusterScope) CloudClient() *proxmox.Service {
 	return s.ProxmoxServices.Compute
 }
 
+func (s *ClusterScope) UploadClient() *upload.Client {
+	return s.ProxmoxServices.Upload
+}
+
 func (s *ClusterScope) Close() error {
 	return s.PatchObject()
 }
 
+// SetActiveEndpoint reports the endpoint of serverRef which the cloud client accesses
+func (s *ClusterScope) SetActiveEndpoint(endpoint string) {
+	s.ProxmoxCluster.Status.ActiveEndpoint = endpoint
+}
+
+// SetFailureDomains reports the failure domains of spec to Cluster API
+func (s *ClusterScope) SetFailureDomains() {
+	if len(s.ProxmoxCluster.Spec.FailureDomains) == 0 {
+		s.ProxmoxCluster.Status.FailureDomains = nil
+		return
+	}
+	failureDomains := clusterv1.FailureDomains{}
+	for _, fd := range s.ProxmoxCluster.Spec.FailureDomains {
+		spec := clusterv1.FailureDomainSpec{ControlPlane: fd.ControlPlane}
+		if fd.ServerRef != nil {
+			spec.Attributes = map[string]string{"endpoint": fd.ServerRef.Endpoint}
+		}
+		failureDomains[fd.Name] = spec
+	}
+	s.ProxmoxCluster.Status.FailureDomains = failureDomains
+}
+
 func (s *ClusterScope) SetReady() {
 	s.ProxmoxCluster.Status.Ready = true
 }
@@ -125,7 +202,32 @@ func (s *ClusterScope) SetStorage(storage infrav1.Storage) {
 	s.ProxmoxCluster.Spec.Storage = storage
 }
 
+// MarkConditionTrue marks the condition of the ProxmoxCluster.
+// it is ignored by the scope of failure domain whose results are summarized into FailureDomainsReady
+func (s *ClusterScope) MarkConditionTrue(t clusterv1.ConditionType) {
+	if s.failureDomain != "" {
+		return
+	}
+	conditions.MarkTrue(s.ProxmoxCluster, t)
+}
+
+// MarkConditionFalse marks the condition of the ProxmoxCluster.
+// it is ignored by the scope of failure domain whose results are summarized into FailureDomainsReady
+func (s *ClusterScope) MarkConditionFalse(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	if s.failureDomain != "" {
+		return
+	}
+	conditions.MarkFalse(s.ProxmoxCluster, t, reason, severity, messageFormat, messageArgs...)
+}
+
 // PatchObject persists the cluster configuration and status.
+// Ready condition is updated as a summary of the other conditions before patching
 func (s *ClusterScope) PatchObject() error {
-	return s.patchHelper.Patch(context.TODO(), s.ProxmoxCluster)
+	conditions.SetSummary(s.ProxmoxCluster,
+		conditions.WithConditions(infrav1.ClusterReadyConditions...),
+		conditions.WithStepCounterIf(s.ProxmoxCluster.ObjectMeta.DeletionTimestamp.IsZero()),
+	)
+	return s.patchHelper.Patch(context.TODO(), s.ProxmoxCluster, patch.WithOwnedConditions{
+		Conditions: append([]clusterv1.ConditionType{clusterv1.ReadyCondition, infrav1.CertificateVerifiedCondition}, infrav1.ClusterReadyConditions...),
+	})
 }
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/trust"
+)
+
+const (
+	// the endpoint found healthy is used without checking again during this interval
+	endpointCheckInterval = 30 * time.Second
+
+	endpointCheckTimeout = 5 * time.Second
+)
+
+type activeEndpoint struct {
+	endpoint string
+	checked  time.Time
+}
+
+// map[string]activeEndpoint of the endpoint each ProxmoxCluster and its failure domains currently use.
+// it is keyed by servicesID
+var activeEndpoints sync.Map
+
+// endpoints returns the endpoint and failover endpoints of serverRef in order of preference
+func endpoints(serverRef infrav1.ServerRef) []string {
+	return append([]string{serverRef.Endpoint}, serverRef.FailoverEndpoints...)
+}
+
+// selectEndpoint returns the first endpoint responding to the health check.
+// endpoints are checked in order so that the primary endpoint is used again once it recovers.
+// the primary endpoint is returned if none responds, so that the failure is reported as usual
+func selectEndpoint(ctx context.Context, id string, candidates []string, tlsConfig *trust.Config, insecure bool) string {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	if v, ok := activeEndpoints.Load(id); ok {
+		active := v.(activeEndpoint)
+		if time.Since(active.checked) < endpointCheckInterval && slices.Contains(candidates, active.endpoint) {
+			return active.endpoint
+		}
+	}
+
+	log := log.FromContext(ctx)
+	for _, endpoint := range candidates {
+		if err := checkEndpoint(ctx, endpoint, tlsConfig, insecure); err != nil {
+			log.Info("Proxmox endpoint is not healthy", "endpoint", endpoint, "reason", err.Error())
+			continue
+		}
+		if v, ok := activeEndpoints.Load(id); ok && v.(activeEndpoint).endpoint != endpoint {
+			log.Info("Switching Proxmox endpoint", "from", v.(activeEndpoint).endpoint, "to", endpoint)
+		}
+		activeEndpoints.Store(id, activeEndpoint{endpoint: endpoint, checked: time.Now()})
+		return endpoint
+	}
+	activeEndpoints.Delete(id)
+	return candidates[0]
+}
+
+// ResetActiveEndpoint makes the next reconciliation check the endpoints of the cluster again.
+// it should be called when the active endpoint doesn't respond
+func ResetActiveEndpoint(cluster *infrav1.ProxmoxCluster) {
+	activeEndpoints.Range(func(id, _ any) bool {
+		if isServicesOf(id.(string), cluster) {
+			activeEndpoints.Delete(id)
+		}
+		return true
+	})
+}
+
+// checkEndpoint requests the version api without credentials.
+// any response except server errors means the api of the node is up, since it answers 401 without credentials
+func checkEndpoint(ctx context.Context, endpoint string, tlsConfig *trust.Config, insecure bool) error {
+	ctx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+	defer cancel()
+
+	transport, err := trust.Transport(endpoint, tlsConfig, insecure)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/version", nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+	if rsp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status %s", rsp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package scope
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("selectEndpoint", Label("unit", "scope"), func() {
+	var up, down *httptest.Server
+
+	BeforeEach(func() {
+		// proxmox answers 401 to requests without credentials
+		up = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "authentication failure", http.StatusUnauthorized)
+		}))
+		down = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		down.Close()
+	})
+
+	AfterEach(func() {
+		up.Close()
+	})
+
+	It("should fail over to the healthy endpoint", func() {
+		uid := "failover"
+		endpoint := selectEndpoint(context.Background(), uid, []string{down.URL + "/api2/json", up.URL + "/api2/json"}, nil, true)
+		Expect(endpoint).To(Equal(up.URL + "/api2/json"))
+	})
+
+	It("should prefer the primary endpoint", func() {
+		uid := "primary"
+		endpoint := selectEndpoint(context.Background(), uid, []string{up.URL + "/api2/json", down.URL + "/api2/json"}, nil, true)
+		Expect(endpoint).To(Equal(up.URL + "/api2/json"))
+	})
+
+	It("should return the primary endpoint if none is healthy", func() {
+		uid := "none"
+		endpoint := selectEndpoint(context.Background(), uid, []string{down.URL + "/api2/json", down.URL + "/foo"}, nil, true)
+		Expect(endpoint).To(Equal(down.URL + "/api2/json"))
+	})
+
+	It("should keep the active endpoint until it is reset", func() {
+		uid := "reset"
+		candidates := []string{down.URL + "/api2/json", up.URL + "/api2/json"}
+		Expect(selectEndpoint(context.Background(), uid, candidates, nil, true)).To(Equal(up.URL + "/api2/json"))
+		up.Close()
+		Expect(selectEndpoint(context.Background(), uid, candidates, nil, true)).To(Equal(up.URL + "/api2/json"))
+		activeEndpoints.Delete(uid)
+		Expect(selectEndpoint(context.Background(), uid, candidates, nil, true)).To(Equal(down.URL + "/api2/json"))
+	})
+})
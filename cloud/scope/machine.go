@@ -18,6 +18,7 @@ package scope
 
 import (
 	"context"
+	"time"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
@@ -27,12 +28,20 @@ import (
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/providerid"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
+)
+
+const (
+	// grace period of graceful shutdown if spec.shutdownTimeout is not specified
+	defaultShutdownTimeout = 5 * time.Minute
 )
 
 type MachineScopeParams struct {
@@ -42,6 +51,9 @@ type MachineScopeParams struct {
 	ProxmoxMachine   *infrav1.ProxmoxMachine
 	ClusterGetter    *ClusterScope
 	SchedulerManager *scheduler.Manager
+
+	// persist the last scheduling decision in the annotation of ProxmoxMachine
+	RecordSchedulingDecision bool
 }
 
 func NewMachineScope(params MachineScopeParams) (*MachineScope, error) {
@@ -73,6 +85,8 @@ func NewMachineScope(params MachineScopeParams) (*MachineScope, error) {
 		patchHelper:      helper,
 		ClusterGetter:    params.ClusterGetter,
 		SchedulerManager: params.SchedulerManager,
+
+		recordSchedulingDecision: params.RecordSchedulingDecision,
 	}, err
 }
 
@@ -83,12 +97,18 @@ type MachineScope struct {
 	ProxmoxMachine   *infrav1.ProxmoxMachine
 	ClusterGetter    *ClusterScope
 	SchedulerManager *scheduler.Manager
+
+	recordSchedulingDecision bool
 }
 
 func (m *MachineScope) CloudClient() *proxmox.Service {
 	return m.ClusterGetter.CloudClient()
 }
 
+func (m *MachineScope) UploadClient() *upload.Client {
+	return m.ClusterGetter.UploadClient()
+}
+
 func (m *MachineScope) GetScheduler(client *proxmox.Service) *scheduler.Scheduler {
 	sched := m.SchedulerManager.GetOrCreateScheduler(client)
 	sched.RunAsync()
@@ -111,6 +131,14 @@ func (m *MachineScope) Namespace() string {
 	return m.ProxmoxMachine.Namespace
 }
 
+func (m *MachineScope) UID() types.UID {
+	return m.ProxmoxMachine.UID
+}
+
+func (m *MachineScope) GetClusterUID() types.UID {
+	return m.ClusterGetter.ProxmoxCluster.UID
+}
+
 func (m *MachineScope) Annotations() map[string]string {
 	return m.ProxmoxMachine.Annotations
 }
@@ -127,6 +155,23 @@ func (m *MachineScope) SetStorage(name string) {
 	m.ProxmoxMachine.Spec.Storage = name
 }
 
+// GetCurrentNode returns the node actually hosting the vm
+func (m *MachineScope) GetCurrentNode() string {
+	return m.ProxmoxMachine.Status.Node
+}
+
+func (m *MachineScope) SetCurrentNode(name string) {
+	m.ProxmoxMachine.Status.Node = name
+}
+
+func (m *MachineScope) GetProvisioningPhase() infrav1.ProvisioningPhase {
+	return m.ProxmoxMachine.Status.ProvisioningPhase
+}
+
+func (m *MachineScope) SetProvisioningPhase(phase infrav1.ProvisioningPhase) {
+	m.ProxmoxMachine.Status.ProvisioningPhase = phase
+}
+
 // func (m *MachineScope) Client() Compute {
 // 	return m.ClusterGetter.Client()
 // }
@@ -202,7 +247,59 @@ func (m *MachineScope) GetOptions() infrav1.Options {
 	return m.ProxmoxMachine.Spec.Options
 }
 
+func (m *MachineScope) GetNodeSelector() map[string]string {
+	return m.ProxmoxMachine.Spec.NodeSelector
+}
+
+func (m *MachineScope) GetNodeAffinity() *corev1.NodeAffinity {
+	return m.ProxmoxMachine.Spec.NodeAffinity
+}
+
+// persist the last scheduling decision in the annotation if enabled. no-op otherwise
+func (m *MachineScope) SetSchedulingDecision(decision string) {
+	if !m.recordSchedulingDecision {
+		return
+	}
+	if m.ProxmoxMachine.Annotations == nil {
+		m.ProxmoxMachine.Annotations = map[string]string{}
+	}
+	m.ProxmoxMachine.Annotations[infrav1.SchedulingDecisionAnnotation] = decision
+}
+
+// record normal event on the ProxmoxMachine
+func (m *MachineScope) Eventf(reason, messageFormat string, args ...interface{}) {
+	record.Eventf(m.ProxmoxMachine, reason, messageFormat, args...)
+}
+
+// record warning event on the ProxmoxMachine
+func (m *MachineScope) Warnf(reason, messageFormat string, args ...interface{}) {
+	record.Warnf(m.ProxmoxMachine, reason, messageFormat, args...)
+}
+
+// return the scheduler profile of the machine, or the one of the cluster if not specified
+func (m *MachineScope) GetSchedulerProfile() string {
+	if profile := m.ProxmoxMachine.Spec.SchedulerProfile; profile != "" {
+		return profile
+	}
+	return m.ClusterGetter.ProxmoxCluster.Spec.SchedulerProfile
+}
+
 // SetProviderID sets the ProxmoxMachine providerID in spec.
+func (m *MachineScope) GetTask() *infrav1.ProxmoxTask {
+	return m.ProxmoxMachine.Status.Task
+}
+
+func (m *MachineScope) SetTask(task *infrav1.ProxmoxTask) {
+	m.ProxmoxMachine.Status.Task = task
+}
+
+func (m *MachineScope) GetShutdownTimeout() time.Duration {
+	if timeout := m.ProxmoxMachine.Spec.ShutdownTimeout; timeout != nil {
+		return timeout.Duration
+	}
+	return defaultShutdownTimeout
+}
+
 func (m *MachineScope) SetProviderID(uuid string) error {
 	providerid, err := providerid.New(uuid)
 	if err != nil {
@@ -228,11 +325,39 @@ func (m *MachineScope) SetFailureMessage(v error) {
 	m.ProxmoxMachine.Status.FailureMessage = ptr.To(v.Error())
 }
 
+// HasFailed returns true if the machine has terminal failure
+func (m *MachineScope) HasFailed() bool {
+	return m.ProxmoxMachine.Status.FailureReason != nil || m.ProxmoxMachine.Status.FailureMessage != nil
+}
+
 func (m *MachineScope) SetFailureReason(v capierrors.MachineStatusError) {
 	m.ProxmoxMachine.Status.FailureReason = &v
 }
 
-// PatchObject persists the cluster configuration and status.
+func (m *MachineScope) GetCondition(t clusterv1.ConditionType) *clusterv1.Condition {
+	return conditions.Get(m.ProxmoxMachine, t)
+}
+
+func (m *MachineScope) MarkConditionTrue(t clusterv1.ConditionType) {
+	conditions.MarkTrue(m.ProxmoxMachine, t)
+}
+
+func (m *MachineScope) MarkConditionFalse(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	conditions.MarkFalse(m.ProxmoxMachine, t, reason, severity, messageFormat, messageArgs...)
+}
+
+// PatchObject persists the machine configuration and status.
+// Ready condition is updated as a summary of the other conditions before patching
 func (s *MachineScope) PatchObject() error {
-	return s.patchHelper.Patch(context.TODO(), s.ProxmoxMachine)
+	conditions.SetSummary(s.ProxmoxMachine,
+		conditions.WithConditions(infrav1.MachineReadyConditions...),
+		conditions.WithStepCounterIf(s.ProxmoxMachine.ObjectMeta.DeletionTimestamp.IsZero()),
+	)
+	return s.patchHelper.Patch(context.TODO(), s.ProxmoxMachine, patch.WithOwnedConditions{
+		Conditions: append([]clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			infrav1.MigratedCondition,
+			infrav1.ConfigSyncedCondition,
+		}, infrav1.MachineReadyConditions...),
+	})
 }
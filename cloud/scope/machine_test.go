@@ -0,0 +1,24 @@
+package scope
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+	caprecord "sigs.k8s.io/cluster-api/util/record"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+var _ = Describe("MachineScope events", Label("unit", "scope"), func() {
+	// the manager initializes the recorder in the same way
+	recorder := record.NewFakeRecorder(10)
+	caprecord.InitFromRecorder(recorder)
+
+	It("should record scheduling events on the ProxmoxMachine", func() {
+		scope := &MachineScope{ProxmoxMachine: &infrav1.ProxmoxMachine{}}
+		scope.Eventf("Scheduled", "Scheduled qemu to node %s", "node1")
+		scope.Warnf("SchedulingFailed", "Failed to schedule qemu - %s", "no node")
+		Expect(recorder.Events).To(Receive(Equal("Normal Scheduled Scheduled qemu to node node1")))
+		Expect(recorder.Events).To(Receive(Equal("Warning SchedulingFailed Failed to schedule qemu - no node")))
+	})
+})
@@ -0,0 +1,56 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
+)
+
+// vmid is used by a qemu with another name
+type vmidConflictError struct {
+	vmid int
+	name string
+}
+
+func (e *vmidConflictError) Error() string {
+	return fmt.Sprintf("vmid %d is already used by qemu %s", e.vmid, e.name)
+}
+
+// getOrphanQEMU finds qemu with the machine's name which was created
+// by this ProxmoxMachine but not recorded in its spec (e.g. controller crashed after creating it).
+// qemu with the same name but without owner tag is never adopted
+func (s *Service) getOrphanQEMU(ctx context.Context) (*proxmox.VirtualMachine, error) {
+	log := log.FromContext(ctx)
+	vms, err := s.client.VirtualMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if vm.Name != s.scope.Name() {
+			continue
+		}
+		qemu, err := s.client.VirtualMachine(ctx, vm.VMID)
+		if err != nil {
+			return nil, err
+		}
+		config, err := qemu.GetConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !ownership.HasTag(config.Tags, ownership.MachineTag(s.scope.UID())) {
+			// there should no qemu with same name
+			return nil, infraerrors.NewTerminalError(capierrors.CreateMachineError,
+				fmt.Errorf("qemu %s already exists and is not owned by this machine", s.scope.Name()))
+		}
+		log.Info("adopting orphan qemu", "node", qemu.Node, "vmid", qemu.VM.VMID)
+		return qemu, nil
+	}
+	return nil, rest.NotFoundErr
+}
@@ -3,25 +3,34 @@ package instance
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/cloudinit"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
 )
 
 const (
 	userSnippetPathFormat = "snippets/%s-user.yml"
+
+	// NoCloud iso uploaded in place of user snippet with API token
+	cloudInitISOFormat = "%s-cloudinit.iso"
 )
 
 // reconcileCloudInit
-func (s *Service) reconcileCloudInit(ctx context.Context) error {
+func (s *Service) reconcileCloudInit(ctx context.Context, instance *proxmox.VirtualMachine) error {
 	log := log.FromContext(ctx)
 	log.Info("Reconciling cloud init")
 
 	// user-data
-	if err := s.reconcileCloudInitUser(ctx); err != nil {
+	if err := s.reconcileCloudInitUser(ctx, instance); err != nil {
 		return err
 	}
 
@@ -34,8 +43,13 @@ func (s *Service) deleteCloudConfig(ctx context.Context) error {
 	log.Info("deleting cloud config file")
 
 	storageName := s.scope.GetClusterStorage().Name
-	path := userSnippetPath(s.scope.Name())
-	volumeID := fmt.Sprintf("%s:%s", storageName, path)
+	fileName := cloudInitFileName(s.scope.Name(), s.scope.GetClusterUID())
+	volumeID := fmt.Sprintf("%s:%s", storageName, userSnippetPath(fileName))
+	legacyVolumeID := fmt.Sprintf("%s:%s", storageName, userSnippetPath(s.scope.Name()))
+	if s.scope.UploadClient() != nil {
+		volumeID = cloudInitISOVolumeID(storageName, fileName)
+		legacyVolumeID = cloudInitISOVolumeID(storageName, s.scope.Name())
+	}
 
 	node, err := s.client.GetNode(ctx, s.scope.NodeName())
 	if err != nil {
@@ -46,12 +60,16 @@ func (s *Service) deleteCloudConfig(ctx context.Context) error {
 		return err
 	}
 	storage.Node = node.Node
+	// qemus created before the cluster uid was put in the file name have the legacy one
+	if _, err := storage.GetContent(ctx, volumeID); rest.IsNotFound(err) && legacyVolumeID != volumeID {
+		volumeID = legacyVolumeID
+	}
 	return storage.DeleteVolume(ctx, volumeID)
 }
 
 // get cloud-config user datas from Secret and ProxmoxMachine
 // then merge them and set merged user data file to Proxmox Storage
-func (s *Service) reconcileCloudInitUser(ctx context.Context) error {
+func (s *Service) reconcileCloudInitUser(ctx context.Context, instance *proxmox.VirtualMachine) error {
 	log := log.FromContext(ctx)
 
 	// cloud init from bootstrap provider
@@ -66,6 +84,7 @@ func (s *Service) reconcileCloudInitUser(ctx context.Context) error {
 	}
 
 	vmName := s.scope.Name()
+	fileName := cloudInitFileName(vmName, s.scope.GetClusterUID())
 	cloudConfig, err := mergeUserDatas(bootstrapConfig, baseUserData(vmName), s.scope.GetCloudInit().UserData)
 	if err != nil {
 		return err
@@ -76,20 +95,90 @@ func (s *Service) reconcileCloudInitUser(ctx context.Context) error {
 		return err
 	}
 
-	// to do: should be set via API
+	// API token cannot open node shell to write snippets
+	if uploader := s.scope.UploadClient(); uploader != nil {
+		return s.reconcileCloudInitISO(ctx, instance, uploader, configYaml)
+	}
+
 	vnc, err := s.vncClient(s.scope.NodeName())
 	if err != nil {
 		return err
 	}
 	defer vnc.Close()
-	filePath := fmt.Sprintf("%s/%s", s.scope.GetClusterStorage().Path, userSnippetPath(vmName))
-	if err := vnc.WriteFile(context.TODO(), configYaml, filePath); err != nil {
+	filePath := fmt.Sprintf("%s/%s", s.scope.GetClusterStorage().Path, userSnippetPath(fileName))
+	if err := vnc.WriteFile(ctx, configYaml, filePath); err != nil {
 		return errors.Errorf("failed to write file error : %v", err)
 	}
 
 	return nil
 }
 
+// reconcileCloudInitISO uploads NoCloud iso which has the user data and network config
+// then attaches it to ide2 in place of the cloud-init drive proxmox generates from snippets
+func (s *Service) reconcileCloudInitISO(ctx context.Context, instance *proxmox.VirtualMachine, uploader *upload.Client, userData string) error {
+	log := log.FromContext(ctx)
+	fileName := cloudInitFileName(s.scope.Name(), s.scope.GetClusterUID())
+	storageName := s.scope.GetClusterStorage().Name
+	volumeID := cloudInitISOVolumeID(storageName, fileName)
+
+	storage, err := s.client.Storage(ctx, storageName)
+	if err != nil {
+		return err
+	}
+	storage.Node = instance.Node
+	_, err = storage.GetContent(ctx, volumeID)
+	if rest.IsNotFound(err) {
+		iso, err := s.generateCloudInitISO(ctx, instance, userData)
+		if err != nil {
+			return err
+		}
+		log.Info("uploading cloud-init iso", "volume", volumeID)
+		upid, err := uploader.Upload(ctx, instance.Node, storageName, "iso", cloudInitISOName(fileName), iso)
+		if err != nil {
+			return errors.Errorf("failed to upload cloud-init iso: %v", err)
+		}
+		return s.startTask(infrav1.TaskOperationUpload, upid)
+	}
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/config", instance.Node, instance.VM.VMID)
+	return s.client.RESTClient().Put(ctx, path, map[string]interface{}{"ide2": volumeID + ",media=cdrom"}, nil)
+}
+
+func (s *Service) generateCloudInitISO(ctx context.Context, instance *proxmox.VirtualMachine, userData string) ([]byte, error) {
+	config, err := instance.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// proxmox generates mac address of the network device on creation
+	mac, err := macAddress(config.Net.Net0)
+	if err != nil {
+		return nil, err
+	}
+	networkConfig, err := cloudinit.GenerateNetworkConfigYaml(mac, s.scope.GetNetwork())
+	if err != nil {
+		return nil, err
+	}
+	metaData := fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", s.scope.UID(), s.scope.Name())
+	return cloudinit.GenerateNoCloudISO(map[string][]byte{
+		"user-data":      []byte(userData),
+		"meta-data":      []byte(metaData),
+		"network-config": []byte(networkConfig),
+	})
+}
+
+// macAddress returns mac address of the network device e.g. virtio=BC:24:11:2E:7A:01,bridge=vmbr0
+func macAddress(netDevice string) (string, error) {
+	model, _, _ := strings.Cut(netDevice, ",")
+	_, mac, ok := strings.Cut(model, "=")
+	if !ok || mac == "" {
+		return "", errors.Errorf("no mac address in network device %q", netDevice)
+	}
+	return mac, nil
+}
+
 // a and b must not be nil
 // only c can be nil
 func mergeUserDatas(a, b, c *infrav1.UserData) (*infrav1.UserData, error) {
@@ -114,8 +203,17 @@ func mergeUserDatas(a, b, c *infrav1.UserData) (*infrav1.UserData, error) {
 	return merged, err
 }
 
-func userSnippetPath(vmName string) string {
-	return fmt.Sprintf(userSnippetPathFormat, vmName)
+// cloudInitFileName returns the name of the user snippet and NoCloud iso without suffix.
+// the cluster tag in it proves the file belongs to the ProxmoxCluster on shared storage
+func cloudInitFileName(vmName string, clusterUID types.UID) string {
+	if clusterUID == "" {
+		return vmName
+	}
+	return vmName + "." + ownership.ClusterTag(clusterUID)
+}
+
+func userSnippetPath(fileName string) string {
+	return fmt.Sprintf(userSnippetPathFormat, fileName)
 }
 
 func baseUserData(vmName string) *infrav1.UserData {
@@ -125,3 +223,11 @@ func baseUserData(vmName string) *infrav1.UserData {
 		RunCmd:   []string{"systemctl start qemu-guest-agent"},
 	}
 }
+
+func cloudInitISOName(fileName string) string {
+	return fmt.Sprintf(cloudInitISOFormat, fileName)
+}
+
+func cloudInitISOVolumeID(storageName, fileName string) string {
+	return fmt.Sprintf("%s:iso/%s", storageName, cloudInitISOName(fileName))
+}
@@ -6,8 +6,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/instance"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/gc"
 )
 
 func TestCloudInit(t *testing.T) {
@@ -63,3 +64,31 @@ var _ = Describe("mergeUserDatas", Label("unit", "cloudinit"), func() {
 		})
 	})
 })
+
+var _ = Describe("macAddress", Label("unit", "cloudinit"), func() {
+	It("should return mac address of the network device", func() {
+		mac, err := instance.MacAddress("virtio=BC:24:11:2E:7A:01,bridge=vmbr0,firewall=1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mac).To(Equal("BC:24:11:2E:7A:01"))
+	})
+
+	It("should return error without mac address", func() {
+		_, err := instance.MacAddress("")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("cloud-init file name", Label("unit", "cloudinit"), func() {
+	It("should be proven to be of the cluster by garbage collector", func() {
+		for _, volumeID := range []string{
+			instance.UserSnippetVolumeID("local", "foo-md-0-abcde", "cluster"),
+			instance.CloudInitISOVolumeID("local", "foo-md-0-abcde", "cluster"),
+		} {
+			name, ok := gc.Snippet{VolumeID: volumeID}.MachineName("cluster")
+			Expect(ok).To(BeTrue(), volumeID)
+			Expect(name).To(Equal("foo-md-0-abcde"))
+			_, ok = gc.Snippet{VolumeID: volumeID}.MachineName("another")
+			Expect(ok).To(BeFalse(), volumeID)
+		}
+	})
+})
@@ -0,0 +1,221 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+// network device properties which can be changed in place
+var mutableNetProperties = []string{"link_down", "rate"}
+
+// difference between desired vm options and live vm config
+type configDrift struct {
+	// parameters to be updated in place
+	update map[string]interface{}
+	// parameters to be removed in place
+	delete []string
+	// parameters which differ but cannot be changed in place
+	immutable []string
+}
+
+func (d *configDrift) needsUpdate() bool {
+	return len(d.update) > 0 || len(d.delete) > 0
+}
+
+// reconcileConfig applies drift of mutable parameters to the vm in place
+// and reports drift of immutable parameters in ConfigSynced condition.
+// return the up-to-date instance
+func (s *Service) reconcileConfig(ctx context.Context, instance *proxmox.VirtualMachine) (*proxmox.VirtualMachine, error) {
+	log := log.FromContext(ctx)
+
+	config, err := instance.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	drift := diffConfig(s.generateVMOptions(), *config)
+
+	if drift.needsUpdate() {
+		log.Info("updating instance config in place", "update", drift.update, "delete", drift.delete)
+		params := drift.update
+		if len(drift.delete) > 0 {
+			params["delete"] = strings.Join(drift.delete, ",")
+		}
+		path := fmt.Sprintf("/nodes/%s/qemu/%d/config", instance.Node, instance.VM.VMID)
+		if err := s.client.RESTClient().Put(ctx, path, params, nil); err != nil {
+			return nil, err
+		}
+		// instance caches its config. get instance again to read the updated config
+		instance, err = s.client.VirtualMachine(ctx, instance.VM.VMID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(drift.immutable) > 0 {
+		log.Info("instance config has drifted from spec but cannot be updated in place", "parameters", drift.immutable)
+		s.scope.MarkConditionFalse(infrav1.ConfigSyncedCondition, infrav1.ImmutableConfigDriftedReason, clusterv1.ConditionSeverityWarning,
+			"parameters cannot be updated in place: %s", strings.Join(drift.immutable, ", "))
+		return instance, nil
+	}
+	s.scope.MarkConditionTrue(infrav1.ConfigSyncedCondition)
+	return instance, nil
+}
+
+// diffConfig compares desired vm options with live vm config.
+// balloon, tags, description, onboot, protection and rate/link state of network devices are updated in place.
+// other hardware parameters are only reported
+func diffConfig(desired api.VirtualMachineCreateOptions, live api.VirtualMachineConfig) configDrift {
+	drift := configDrift{update: map[string]interface{}{}}
+
+	// zero balloon is not distinguishable from unspecified one
+	if desired.Balloon != 0 && desired.Balloon != live.Balloon {
+		drift.update["balloon"] = desired.Balloon
+	}
+	if desired.OnBoot != live.OnBoot {
+		drift.update["onboot"] = desired.OnBoot
+	}
+	if desired.Protection != live.Protection {
+		drift.update["protection"] = desired.Protection
+	}
+	drift.updateString("description", strings.TrimSpace(desired.Description), strings.TrimSpace(live.Description))
+	drift.updateString("tags", normalizeTags(desired.Tags), normalizeTags(live.Tags))
+
+	if desired.Memory != int(live.Memory) {
+		drift.immutable = append(drift.immutable, "memory")
+	}
+	if desired.Cores != live.Cores {
+		drift.immutable = append(drift.immutable, "cores")
+	}
+	if desired.Sockets != 0 && desired.Sockets != live.Sockets {
+		drift.immutable = append(drift.immutable, "sockets")
+	}
+	if desired.Cpu != "" && desired.Cpu != live.Cpu {
+		drift.immutable = append(drift.immutable, "cpu")
+	}
+	if desired.BIOS != "" && desired.BIOS != live.BIOS {
+		drift.immutable = append(drift.immutable, "bios")
+	}
+
+	for i := 0; i < maxNetworkDevices; i++ {
+		key := fmt.Sprintf("net%d", i)
+		desiredNet, liveNet := netDevice(&desired.Net, i), netDevice(&live.Net, i)
+		if desiredNet == "" && liveNet == "" {
+			continue
+		}
+		// adding/removing network devices is not supported
+		if desiredNet == "" || liveNet == "" {
+			drift.immutable = append(drift.immutable, key)
+			continue
+		}
+		net, immutable := diffNetDevice(desiredNet, liveNet)
+		if net != liveNet {
+			drift.update[key] = net
+		}
+		if immutable {
+			drift.immutable = append(drift.immutable, key)
+		}
+	}
+	return drift
+}
+
+func (d *configDrift) updateString(key, desired, live string) {
+	if desired == live {
+		return
+	}
+	if desired == "" {
+		d.delete = append(d.delete, key)
+		return
+	}
+	d.update[key] = desired
+}
+
+// return live network device having desired mutable properties.
+// live one keeps mac address which is generated by proxmox.
+// model and bridge cannot be changed without recreating the device
+func diffNetDevice(desired, live string) (string, bool) {
+	desiredProps := parseNetDevice(desired)
+	liveProps := parseNetDevice(live)
+
+	immutable := false
+	if model := desiredProps.get("model"); model != "" && liveProps.get(model) == "" {
+		immutable = true
+	}
+	if desiredProps.get("bridge") != liveProps.get("bridge") {
+		immutable = true
+	}
+
+	for _, key := range mutableNetProperties {
+		liveProps = liveProps.set(key, desiredProps.get(key))
+	}
+	return liveProps.String(), immutable
+}
+
+// ordered key=value list e.g. virtio=XX:XX:XX:XX:XX:XX,bridge=vmbr0,firewall=1
+type netProperties [][2]string
+
+func parseNetDevice(s string) netProperties {
+	props := netProperties{}
+	for _, kv := range strings.Split(s, ",") {
+		if kv == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(kv, "=")
+		props = append(props, [2]string{key, value})
+	}
+	return props
+}
+
+func (p netProperties) get(key string) string {
+	for _, kv := range p {
+		if kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// set value to key. empty value removes key
+func (p netProperties) set(key, value string) netProperties {
+	props := netProperties{}
+	found := false
+	for _, kv := range p {
+		if kv[0] != key {
+			props = append(props, kv)
+			continue
+		}
+		found = true
+		if value != "" {
+			props = append(props, [2]string{key, value})
+		}
+	}
+	if !found && value != "" {
+		props = append(props, [2]string{key, value})
+	}
+	return props
+}
+
+func (p netProperties) String() string {
+	kvs := make([]string, len(p))
+	for i, kv := range p {
+		kvs[i] = kv[0] + "=" + kv[1]
+	}
+	return strings.Join(kvs, ",")
+}
+
+// proxmox returns tags sorted and separated by ";"
+func normalizeTags(tags string) string {
+	list := strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	})
+	sort.Strings(list)
+	return strings.Join(list, ";")
+}
@@ -0,0 +1,111 @@
+package instance_test
+
+import (
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/instance"
+)
+
+var _ = Describe("diffConfig", Label("unit", "drift"), func() {
+	var desired api.VirtualMachineCreateOptions
+	var live api.VirtualMachineConfig
+
+	BeforeEach(func() {
+		desired = api.VirtualMachineCreateOptions{
+			Memory:      4096,
+			Cores:       2,
+			Sockets:     1,
+			Tags:        "b;a;",
+			Description: "foo",
+			OnBoot:      1,
+			Net:         api.Net{Net0: "model=virtio,bridge=vmbr0,firewall=1"},
+		}
+		live = api.VirtualMachineConfig{
+			Memory:      4096,
+			Cores:       2,
+			Sockets:     1,
+			Tags:        "a;b",
+			Description: "foo\n",
+			OnBoot:      1,
+			Net:         api.Net{Net0: "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1"},
+		}
+	})
+
+	Context("config matches spec", func() {
+		It("should have no drift", func() {
+			update, del, immutable := instance.DiffConfig(desired, live)
+			Expect(update).To(BeEmpty())
+			Expect(del).To(BeEmpty())
+			Expect(immutable).To(BeEmpty())
+		})
+	})
+
+	Context("mutable parameters drifted", func() {
+		It("should update them in place", func() {
+			desired.Balloon = 2048
+			desired.OnBoot = 0
+			desired.Protection = 1
+			desired.Tags = "c;"
+			desired.Description = ""
+			desired.Net0 = "model=virtio,bridge=vmbr0,firewall=1,link_down=1,rate=10"
+			update, del, immutable := instance.DiffConfig(desired, live)
+			Expect(update).To(Equal(map[string]interface{}{
+				"balloon":    2048,
+				"onboot":     int8(0),
+				"protection": int8(1),
+				"tags":       "c",
+				"net0":       "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1,link_down=1,rate=10",
+			}))
+			Expect(del).To(ConsistOf("description"))
+			Expect(immutable).To(BeEmpty())
+		})
+
+		It("should remove network device properties", func() {
+			live.Net0 = "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1,rate=10"
+			update, _, _ := instance.DiffConfig(desired, live)
+			Expect(update).To(HaveKeyWithValue("net0", "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1"))
+		})
+	})
+
+	Context("immutable parameters drifted", func() {
+		It("should report them", func() {
+			desired.Memory = 8192
+			desired.Cores = 4
+			desired.Net0 = "model=e1000,bridge=vmbr1"
+			update, _, immutable := instance.DiffConfig(desired, live)
+			Expect(immutable).To(ConsistOf("memory", "cores", "net0"))
+			Expect(update).NotTo(HaveKey("net0"))
+		})
+
+		It("should report added network devices", func() {
+			desired.Net1 = "model=virtio,bridge=vmbr1"
+			_, _, immutable := instance.DiffConfig(desired, live)
+			Expect(immutable).To(ConsistOf("net1"))
+		})
+	})
+
+	Context("multiple network devices", func() {
+		It("should update each of them", func() {
+			desired.Net1 = "model=virtio,bridge=vmbr1,rate=10"
+			live.Net1 = "virtio=BC:24:11:00:00:02,bridge=vmbr1"
+			update, _, immutable := instance.DiffConfig(desired, live)
+			Expect(update).To(Equal(map[string]interface{}{"net1": "virtio=BC:24:11:00:00:02,bridge=vmbr1,rate=10"}))
+			Expect(immutable).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("generateNet", Label("unit", "drift"), func() {
+	It("should assign network devices in order", func() {
+		net := instance.GenerateNet([]infrav1.NetworkDevice{
+			{Model: "virtio", Bridge: "vmbr0"},
+			{Model: "e1000", Bridge: "vmbr1"},
+		})
+		Expect(net.Net0).To(Equal("model=virtio,bridge=vmbr0"))
+		Expect(net.Net1).To(Equal("model=e1000,bridge=vmbr1"))
+		Expect(net.Net2).To(BeEmpty())
+	})
+})
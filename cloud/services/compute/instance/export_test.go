@@ -1,9 +1,48 @@
 package instance
 
 import (
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	"context"
+	"fmt"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 )
 
 func MergeUserDatas(a, b, c *infrav1.UserData) (*infrav1.UserData, error) {
 	return mergeUserDatas(a, b, c)
 }
+
+func NeedsMigration(desired, current, actual string) bool {
+	return needsMigration(desired, current, actual)
+}
+
+func DiffConfig(desired api.VirtualMachineCreateOptions, live api.VirtualMachineConfig) (map[string]interface{}, []string, []string) {
+	drift := diffConfig(desired, live)
+	return drift.update, drift.delete, drift.immutable
+}
+
+func GenerateNet(devices []infrav1.NetworkDevice) api.Net {
+	return generateNet(devices)
+}
+
+func TaskFailedError(operation infrav1.TaskOperation, err error, log []string) error {
+	return &taskFailedError{operation: operation, err: err, log: log}
+}
+
+func MacAddress(netDevice string) (string, error) {
+	return macAddress(netDevice)
+}
+
+func ReconcileTask(ctx context.Context, s *Service) error {
+	return s.reconcileTask(ctx)
+}
+
+func UserSnippetVolumeID(storageName, vmName string, clusterUID types.UID) string {
+	return fmt.Sprintf("%s:%s", storageName, userSnippetPath(cloudInitFileName(vmName, clusterUID)))
+}
+
+func CloudInitISOVolumeID(storageName, vmName string, clusterUID types.UID) string {
+	return cloudInitISOVolumeID(storageName, cloudInitFileName(vmName, clusterUID))
+}
@@ -3,32 +3,49 @@ package instance
 import (
 	"context"
 	"fmt"
-	"path"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
 	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/imagecache"
 )
 
 const (
-	rawImageDirPath = etcCAPPX + "/images"
+	rawImageDirPath = imagecache.DirPath
 )
 
+// wget output on 4xx http responses e.g. "ERROR 404: Not Found."
+var wgetClientErrorRegexp = regexp.MustCompile(`ERROR 4\d\d`)
+
 // reconcileBootDevice
 func (s *Service) reconcileBootDevice(ctx context.Context, vm *proxmox.VirtualMachine) error {
 	log := log.FromContext(ctx)
 	log.Info("reconciling boot device")
 
 	// boot disk
+	size := s.scope.GetHardware().DiskSize()
+	if size == "" {
+		return nil
+	}
 	log.Info("resizing boot disk")
-	if err := vm.ResizeVolume(ctx, bootDvice, s.scope.GetHardware().Disk); err != nil {
+	// proxmox older than 8 resizes the disk synchronously and returns no UPID
+	var upid string
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/resize", vm.Node, vm.VM.VMID)
+	if err := s.client.RESTClient().Put(ctx, path, map[string]interface{}{"disk": bootDvice, "size": size}, &upid); err != nil {
 		return err
 	}
-
-	return nil
+	return s.startTask(infrav1.TaskOperationResize, upid)
 }
 
 // setCloudImage downloads OS image into Proxmox node
@@ -37,6 +54,11 @@ func (s *Service) setCloudImage(ctx context.Context) error {
 	log := log.FromContext(ctx)
 	log.Info("setting cloud image")
 
+	// API token cannot open node shell
+	if s.scope.UploadClient() != nil {
+		return s.downloadCloudImage(ctx)
+	}
+
 	image := s.scope.GetImage()
 	rawImageFilePath := rawImageFilePath(image)
 
@@ -47,24 +69,96 @@ func (s *Service) setCloudImage(ctx context.Context) error {
 	defer vnc.Close()
 
 	// download image
-	ok, _ := isChecksumOK(vnc, image, rawImageFilePath)
-	if !ok { // if checksum is ok, it means the image is already there. skip installing
+	ok, _ := isChecksumOK(ctx, vnc, image, rawImageFilePath)
+	if ok {
+		metrics.ImageCacheLookups.WithLabelValues(metrics.ResultHit).Inc()
+		// mark the image as recently used so that it outlives older ones on eviction
+		if out, _, err := vnc.Exec(ctx, fmt.Sprintf("touch -c %s", rawImageFilePath)); err != nil {
+			log.Error(err, "failed to update mtime of cached image", "path", rawImageFilePath, "output", out)
+		}
+	} else { // if checksum is ok, it means the image is already there. skip installing
+		metrics.ImageCacheLookups.WithLabelValues(metrics.ResultMiss).Inc()
 		out, _, err := vnc.Exec(ctx, fmt.Sprintf("mkdir -p %s && mkdir -p %s", etcCAPPX, rawImageDirPath))
 		if err != nil {
 			return errors.Errorf("failed to create dir %s: %s : %v", rawImageDirPath, out, err)
 		}
 		log.Info("downloading node image. this will take few mins.")
+		start := time.Now()
 		out, _, err = vnc.Exec(ctx, fmt.Sprintf("wget %s -O %s", image.URL, rawImageFilePath))
+		metrics.ObserveImageDownload(metrics.DownloadMethodShell, start, err)
 		if err != nil {
-			return errors.Errorf("failed to download image: %s : %v", out, err)
+			err = errors.Errorf("failed to download image: %s : %v", out, err)
+			// 4xx responses mean the url is wrong
+			if wgetClientErrorRegexp.MatchString(out) {
+				return infraerrors.NewTerminalError(capierrors.InvalidConfigurationMachineError, err)
+			}
+			return err
 		}
-		if _, err = isChecksumOK(vnc, image, rawImageFilePath); err != nil {
-			return errors.Errorf("failed to confirm checksum: %v", err)
+		if _, err = isChecksumOK(ctx, vnc, image, rawImageFilePath); err != nil {
+			// freshly downloaded image does not match the checksum
+			return infraerrors.NewTerminalError(capierrors.InvalidConfigurationMachineError, errors.Errorf("failed to confirm checksum: %v", err))
 		}
 	}
 	return nil
 }
 
+// downloadCloudImage downloads OS image into import content of the cluster storage on the node
+// with download-url API so that proxmox can import the image from there.
+// it requires proxmox 8.2 or later
+func (s *Service) downloadCloudImage(ctx context.Context) error {
+	log := log.FromContext(ctx)
+	image := s.scope.GetImage()
+	node, storageName := s.scope.NodeName(), s.scope.GetClusterStorage().Name
+
+	storage, err := s.client.Storage(ctx, storageName)
+	if err != nil {
+		return err
+	}
+	storage.Node = node
+	_, err = storage.GetContent(ctx, imagecache.ImportVolumeID(storageName, image))
+	if err == nil {
+		// the image is already there. skip downloading
+		metrics.ImageCacheLookups.WithLabelValues(metrics.ResultHit).Inc()
+		return nil
+	}
+	if !rest.IsNotFound(err) {
+		return err
+	}
+	metrics.ImageCacheLookups.WithLabelValues(metrics.ResultMiss).Inc()
+
+	option := api.ContentDownloadOption{
+		Content:            "import",
+		Filename:           imagecache.ImportFileName(image),
+		URL:                image.URL,
+		VerifyCertificates: true,
+	}
+	if image.Checksum != "" {
+		if image.ChecksumType == nil {
+			return infraerrors.NewTerminalError(capierrors.InvalidConfigurationMachineError, errors.New("checksum type must be specified with checksum"))
+		}
+		cscmd, err := findValidChecksumCommand(*image.ChecksumType)
+		if err != nil {
+			return infraerrors.NewTerminalError(capierrors.InvalidConfigurationMachineError, err)
+		}
+		option.Checksum = image.Checksum
+		option.ChecksumAlgorithm = strings.TrimSuffix(cscmd, "sum")
+	}
+	log.Info("downloading node image. this will take few mins.")
+	upid, err := s.client.RESTClient().DownloadFromURL(ctx, node, storageName, option)
+	if err != nil {
+		return errors.Errorf("failed to download image: %v", err)
+	}
+	return s.startTask(infrav1.TaskOperationDownload, ptr.Deref(upid, ""))
+}
+
+// imageImportSource returns the image which the boot disk is imported from
+func (s *Service) imageImportSource() string {
+	if s.scope.UploadClient() != nil {
+		return imagecache.ImportVolumeID(s.scope.GetClusterStorage().Name, s.scope.GetImage())
+	}
+	return rawImageFilePath(s.scope.GetImage())
+}
+
 func findValidChecksumCommand(csType string) (string, error) {
 	csType = strings.ToLower(csType)
 	switch csType {
@@ -77,14 +171,17 @@ func findValidChecksumCommand(csType string) (string, error) {
 	}
 }
 
-func isChecksumOK(client *proxmox.VNCWebSocketClient, image infrav1.Image, path string) (bool, error) {
+func isChecksumOK(ctx context.Context, client *vncShell, image infrav1.Image, path string) (bool, error) {
 	if image.Checksum != "" {
+		if image.ChecksumType == nil {
+			return false, errors.New("checksum type must be specified with checksum")
+		}
 		cscmd, err := findValidChecksumCommand(*image.ChecksumType)
 		if err != nil {
 			return false, err
 		}
 		cmd := fmt.Sprintf("echo -n '%s %s' | %s --check -", image.Checksum, path, cscmd)
-		out, _, err := client.Exec(context.TODO(), cmd)
+		out, _, err := client.Exec(ctx, cmd)
 		if err != nil {
 			return false, errors.Errorf("failed to confirm checksum: %s : %v", out, err)
 		}
@@ -94,9 +191,5 @@ func isChecksumOK(client *proxmox.VNCWebSocketClient, image infrav1.Image, path
 }
 
 func rawImageFilePath(image infrav1.Image) string {
-	fileName := path.Base(image.URL)
-	if image.Checksum != "" {
-		fileName = image.Checksum + "." + fileName
-	}
-	return fmt.Sprintf("%s/%s", rawImageDirPath, fileName)
+	return fmt.Sprintf("%s/%s", rawImageDirPath, imagecache.FileName(image))
 }
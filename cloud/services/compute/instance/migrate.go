@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/storage"
+)
+
+type migrateOption struct {
+	Target         string `json:"target"`
+	Online         int    `json:"online,omitempty"`
+	WithLocalDisks int    `json:"with-local-disks,omitempty"`
+}
+
+// reconcileMigration migrates the vm to spec.node if it differs from the node hosting the vm.
+// the vm keeps its vmid and bios uuid so the providerID stays the same after the migration.
+// the migration task is tracked by reconcileTask
+func (s *Service) reconcileMigration(ctx context.Context, instance *proxmox.VirtualMachine) error {
+	log := log.FromContext(ctx)
+
+	if !needsMigration(s.scope.NodeName(), s.scope.GetCurrentNode(), instance.Node) {
+		// accept the actual node e.g. for new instances, instances moved outside of cappx or migrated ones
+		s.scope.SetNodeName(instance.Node)
+		s.scope.SetCurrentNode(instance.Node)
+		return nil
+	}
+
+	target := s.scope.NodeName()
+	log.Info("migrating instance", "node", instance.Node, "target", target)
+	upid, err := s.migrateQEMU(ctx, instance, target)
+	if err != nil {
+		return err
+	}
+	return s.startTask(infrav1.TaskOperationMigrate, upid)
+}
+
+func (s *Service) migrateQEMU(ctx context.Context, instance *proxmox.VirtualMachine, target string) (string, error) {
+	option := migrateOption{Target: target}
+	if instance.VM.Status == api.ProcessStatusRunning {
+		option.Online = 1
+	}
+	shared, err := storage.IsSharedStorage(ctx, &s.client, instance.Node, s.scope.GetStorage())
+	if err != nil {
+		return "", err
+	}
+	if !shared {
+		option.WithLocalDisks = 1
+	}
+	var upid string
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/migrate", instance.Node, instance.VM.VMID)
+	if err := s.client.RESTClient().Post(ctx, path, option, &upid); err != nil {
+		return "", err
+	}
+	return upid, nil
+}
+
+// return true if the vm should be migrated from the actual node to the desired node.
+// migration is never triggered before the current node is known (e.g. just after creation)
+func needsMigration(desired, current, actual string) bool {
+	return current != "" && desired != "" && desired != current && desired != actual
+}
@@ -0,0 +1,23 @@
+package instance_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/instance"
+)
+
+var _ = Describe("needsMigration", Label("unit", "migration"), func() {
+	It("should migrate when spec.node is changed", func() {
+		Expect(instance.NeedsMigration("node2", "node1", "node1")).To(BeTrue())
+	})
+
+	It("should not migrate before the current node is known", func() {
+		Expect(instance.NeedsMigration("node2", "", "node1")).To(BeFalse())
+	})
+
+	It("should not migrate when the vm is already on the desired node", func() {
+		Expect(instance.NeedsMigration("node2", "node1", "node2")).To(BeFalse())
+		Expect(instance.NeedsMigration("node1", "node1", "node1")).To(BeFalse())
+	})
+})
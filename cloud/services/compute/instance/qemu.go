@@ -2,17 +2,27 @@ package instance
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	"github.com/k8s-proxmox/proxmox-go/rest"
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
 	bootDvice = "scsi0"
+
+	// number of network devices proxmox supports
+	maxNetworkDevices = 32
 )
 
 // reconciles QEMU instance
@@ -27,25 +37,31 @@ func (s *Service) reconcileQEMU(ctx context.Context) (*proxmox.VirtualMachine, e
 			return nil, err
 		}
 
-		// no qemu found, try to create new one
-		log.V(3).Info("qemu wasn't found. new qemu will be created")
-		if exist, err := s.client.VirtualMachineExistsWithName(ctx, s.scope.Name()); exist || err != nil {
-			if exist {
-				// there should no qemu with same name. occuring an error
-				err = fmt.Errorf("qemu %s already exists", s.scope.Name())
-			}
-			log.Error(err, "stop creating new qemu to avoid replicating same qemu")
-			return nil, err
-		}
-		qemu, err = s.createQEMU(ctx)
+		// qemu may have been created before its vmid was persisted
+		qemu, err = s.getOrphanQEMU(ctx)
 		if err != nil {
-			log.Error(err, "failed to create qemu")
-			return nil, err
+			if !rest.IsNotFound(err) {
+				log.Error(err, "stop creating new qemu to avoid replicating same qemu")
+				return nil, err
+			}
+
+			// no qemu found, try to create new one
+			log.V(3).Info("qemu wasn't found. new qemu will be created")
+			qemu, err = s.createQEMU(ctx)
+			if err != nil {
+				if !errors.Is(err, errTaskInFlight) {
+					log.Error(err, "failed to create qemu")
+				}
+				return nil, err
+			}
 		}
 	}
 
 	s.scope.SetVMID(qemu.VM.VMID)
 	s.scope.SetNodeName(qemu.Node)
+	if !s.scope.GetProvisioningPhase().Reached(infrav1.ProvisioningPhaseCreated) {
+		s.scope.SetProvisioningPhase(infrav1.ProvisioningPhaseCreated)
+	}
 	if err := s.scope.PatchObject(); err != nil {
 		return nil, err
 	}
@@ -57,10 +73,18 @@ func (s *Service) getQEMU(ctx context.Context) (*proxmox.VirtualMachine, error)
 	log := log.FromContext(ctx)
 	log.Info("getting qemu from vmid")
 	vmid := s.scope.GetVMID()
-	if vmid != nil {
-		return s.client.VirtualMachine(ctx, *vmid)
+	if vmid == nil {
+		return nil, rest.NotFoundErr
+	}
+	qemu, err := s.client.VirtualMachine(ctx, *vmid)
+	if err != nil {
+		return nil, err
 	}
-	return nil, rest.NotFoundErr
+	// vmid may be reused by another qemu
+	if qemu.VM.Name != s.scope.Name() {
+		return nil, &vmidConflictError{vmid: *vmid, name: qemu.VM.Name}
+	}
+	return qemu, nil
 }
 
 func (s *Service) createQEMU(ctx context.Context) (*proxmox.VirtualMachine, error) {
@@ -70,33 +94,80 @@ func (s *Service) createQEMU(ctx context.Context) (*proxmox.VirtualMachine, erro
 	// create qemu
 	log.Info("making qemu spec")
 	vmoption := s.generateVMOptions()
-	// bind annotation key-values to context
-	schedCtx := framework.ContextWithMap(ctx, s.scope.Annotations())
-	result, err := s.scheduler.CreateQEMU(schedCtx, &vmoption)
-	if err != nil {
-		log.Error(err, "failed to schedule qemu instance")
-		return nil, err
+
+	// resume with the persisted scheduling result. it is also kept when creating qemu fails
+	// since node, vmid and storage cannot be changed once set
+	if !s.scope.GetProvisioningPhase().Reached(infrav1.ProvisioningPhaseScheduled) || s.scope.GetVMID() == nil {
+		if err := s.scheduleQEMU(ctx, &vmoption); err != nil {
+			return nil, err
+		}
 	}
-	node, vmid, storage := result.Node(), result.VMID(), result.Storage()
-	s.scope.SetNodeName(node)
-	s.scope.SetVMID(vmid)
+	node, vmid, storage := s.scope.NodeName(), *s.scope.GetVMID(), s.scope.GetStorage()
 
 	// inject storage
 	s.injectVMOption(&vmoption, storage)
-	s.scope.SetStorage(storage)
 
 	// os image
 	if err := s.setCloudImage(ctx); err != nil {
+		if !errors.Is(err, errTaskInFlight) {
+			s.scope.MarkConditionFalse(infrav1.ImageReadyCondition, infrav1.ImageDownloadFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		}
 		return nil, err
 	}
+	s.scope.MarkConditionTrue(infrav1.ImageReadyCondition)
 
-	// actually create qemu
-	vm, err := s.client.CreateVirtualMachine(ctx, node, vmid, vmoption)
+	// actually create qemu. os image is imported by the task
+	upid, err := s.client.RESTClient().CreateVirtualMachine(ctx, node, vmid, vmoption)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.startTask(infrav1.TaskOperationCreate, ptr.Deref(upid, "")); err != nil {
+		return nil, err
+	}
+	return s.client.VirtualMachine(ctx, vmid)
+}
+
+// scheduleQEMU assigns node, vmid and storage to the qemu and persists them
+// so that the qemu is created with them even if the controller restarts
+func (s *Service) scheduleQEMU(ctx context.Context, vmoption *api.VirtualMachineCreateOptions) error {
+	log := log.FromContext(ctx)
 
-	return vm, nil
+	// bind annotation key-values, node placement constraints and scheduler profile to context
+	schedCtx := framework.ContextWithMap(ctx, s.scope.Annotations())
+	schedCtx = framework.ContextWithNodePlacement(schedCtx, framework.NodePlacement{
+		NodeSelector: s.scope.GetNodeSelector(),
+		NodeAffinity: s.scope.GetNodeAffinity(),
+	})
+	schedCtx = framework.ContextWithProfile(schedCtx, s.scope.GetSchedulerProfile())
+	state, err := s.scheduler.Schedule(schedCtx, vmoption)
+	s.recordSchedulingDecision(ctx, state.Decision(), err)
+	if err != nil {
+		log.Error(err, "failed to schedule qemu instance")
+		s.scope.MarkConditionFalse(infrav1.ScheduledCondition, infrav1.SchedulingFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return err
+	}
+	result := state.Result()
+	s.scope.MarkConditionTrue(infrav1.ScheduledCondition)
+	s.scope.SetNodeName(result.Node())
+	s.scope.SetVMID(result.VMID())
+	s.scope.SetStorage(result.Storage())
+	s.scope.SetProvisioningPhase(infrav1.ProvisioningPhaseScheduled)
+	return s.scope.PatchObject()
+}
+
+// record the scheduling decision as the event and, if enabled, the annotation of ProxmoxMachine
+func (s *Service) recordSchedulingDecision(ctx context.Context, decision *framework.Decision, err error) {
+	if err != nil {
+		s.scope.Warnf("SchedulingFailed", "Failed to schedule qemu - %v: %s", err, decision.Summary())
+	} else {
+		s.scope.Eventf("Scheduled", "Scheduled qemu to node %s (vmid %d, storage %s) - %s", decision.Node, decision.VMID, decision.Storage, decision.Summary())
+	}
+	b, err := json.Marshal(decision)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to marshal scheduling decision")
+		return
+	}
+	s.scope.SetSchedulingDecision(string(b))
 }
 
 func (s *Service) generateVMOptions() api.VirtualMachineCreateOptions {
@@ -106,10 +177,13 @@ func (s *Service) generateVMOptions() api.VirtualMachineCreateOptions {
 	network := s.scope.GetNetwork()
 	hardware := s.scope.GetHardware()
 	options := s.scope.GetOptions()
-	cicustom := fmt.Sprintf("user=%s:%s", snippetStorageName, userSnippetPath(vmName))
+	cicustom := fmt.Sprintf("user=%s:%s", snippetStorageName, userSnippetPath(cloudInitFileName(vmName, s.scope.GetClusterUID())))
 	ide2 := fmt.Sprintf("file=%s:cloudinit,media=cdrom", imageStorageName)
-	scsi0 := fmt.Sprintf("%s:0,import-from=%s", imageStorageName, rawImageFilePath(s.scope.GetImage()))
-	net0 := hardware.NetworkDevice.String()
+	scsi0 := fmt.Sprintf("%s:0,import-from=%s", imageStorageName, s.imageImportSource())
+	// API token cannot write snippets. cloud-init iso is attached to ide2 instead
+	if s.scope.UploadClient() != nil {
+		cicustom, ide2 = "", ""
+	}
 
 	vmoptions := api.VirtualMachineCreateOptions{
 		ACPI:          boolToInt8(options.ACPI),
@@ -129,11 +203,10 @@ func (s *Service) generateVMOptions() api.VirtualMachineCreateOptions {
 		KeepHugePages: boolToInt8(options.KeepHugePages),
 		KVM:           boolToInt8(options.KVM),
 		LocalTime:     boolToInt8(options.LocalTime),
-		Lock:          string(options.Lock),
 		Memory:        hardware.Memory,
 		Name:          vmName,
 		NameServer:    network.NameServer,
-		Net:           api.Net{Net0: net0},
+		Net:           generateNet(hardware.NetworkDevices),
 		Numa:          boolToInt8(options.NUMA),
 		Node:          s.scope.NodeName(),
 		OnBoot:        boolToInt8(options.OnBoot),
@@ -147,7 +220,7 @@ func (s *Service) generateVMOptions() api.VirtualMachineCreateOptions {
 		Shares:        options.Shares,
 		Sockets:       hardware.Sockets,
 		Tablet:        boolToInt8(options.Tablet),
-		Tags:          options.Tags.String(),
+		Tags:          options.Tags.String() + ownership.Tags(s.scope.GetClusterUID(), s.scope.UID()),
 		TDF:           boolToInt8(options.TimeDriftFix),
 		Template:      boolToInt8(options.Template),
 		VCPUs:         options.VCPUs,
@@ -158,6 +231,27 @@ func (s *Service) generateVMOptions() api.VirtualMachineCreateOptions {
 	return vmoptions
 }
 
+// generateNet assigns network devices to net0, net1, ... in order
+func generateNet(devices []infrav1.NetworkDevice) api.Net {
+	net := api.Net{}
+	for i, device := range devices {
+		if i >= maxNetworkDevices {
+			break
+		}
+		netDeviceField(&net, i).SetString(device.String())
+	}
+	return net
+}
+
+// netDevice returns i-th network device (net<i>) of proxmox net options
+func netDevice(net *api.Net, i int) string {
+	return netDeviceField(net, i).String()
+}
+
+func netDeviceField(net *api.Net, i int) reflect.Value {
+	return reflect.ValueOf(net).Elem().FieldByName(fmt.Sprintf("Net%d", i))
+}
+
 func boolToInt8(b bool) int8 {
 	if b {
 		return 1
@@ -167,10 +261,11 @@ func boolToInt8(b bool) int8 {
 
 func (s *Service) injectVMOption(vmOption *api.VirtualMachineCreateOptions, storage string) *api.VirtualMachineCreateOptions {
 	// storage is finalized after node scheduling so we need to inject storage name here
-	ide2 := fmt.Sprintf("file=%s:cloudinit,media=cdrom", storage)
-	scsi0 := fmt.Sprintf("%s:0,import-from=%s", storage, rawImageFilePath(s.scope.GetImage()))
+	scsi0 := fmt.Sprintf("%s:0,import-from=%s", storage, s.imageImportSource())
 	vmOption.Scsi.Scsi0 = scsi0
-	vmOption.Ide.Ide2 = ide2
+	if vmOption.Ide.Ide2 != "" {
+		vmOption.Ide.Ide2 = fmt.Sprintf("file=%s:cloudinit,media=cdrom", storage)
+	}
 	vmOption.Storage = storage
 
 	return vmOption
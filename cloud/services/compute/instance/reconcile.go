@@ -9,22 +9,51 @@ import (
 	"github.com/k8s-proxmox/proxmox-go/rest"
 	"github.com/pkg/errors"
 	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
 )
 
 const (
 	etcCAPPX = "/etc/cappx"
 )
 
-// reconcile normal
+// reconcile normal.
+// the reconciliation returns without error while a proxmox task is in flight
 func (s *Service) Reconcile(ctx context.Context) error {
+	if err := s.reconcile(ctx); err != nil && !errors.Is(err, errTaskInFlight) {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) reconcile(ctx context.Context) error {
 	log := log.FromContext(ctx)
 	log.Info("Reconciling instance")
 	instance, err := s.createOrGetInstance(ctx)
 	if err != nil {
-		log.Error(err, "failed to create/get instance")
+		if errors.Is(err, errTaskInFlight) {
+			return err
+		}
+		err = infraerrors.Classify(err)
+		log.Error(err, "failed to create/get instance", "terminal", infraerrors.IsTerminal(err))
+		s.scope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.InstanceProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return err
+	}
+	s.scope.MarkConditionTrue(infrav1.InstanceProvisionedCondition)
+
+	if err := s.reconcileMigration(ctx, instance); err != nil {
+		if !errors.Is(err, errTaskInFlight) {
+			log.Error(err, "failed to reconcile migration")
+		}
+		return err
+	}
+
+	instance, err = s.reconcileConfig(ctx, instance)
+	if err != nil {
+		log.Error(err, "failed to reconcile instance config")
 		return err
 	}
 
@@ -38,7 +67,6 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		return err
 	}
 	s.scope.SetInstanceStatus(infrav1.InstanceStatus(instance.VM.Status))
-	s.scope.SetNodeName(instance.Node)
 	s.scope.SetVMID(instance.VM.VMID)
 
 	log.Info("updating instance config status")
@@ -50,14 +78,46 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return nil
 }
 
-// reconcile delete
+// reconcile delete.
+// the reconciliation returns without error while a proxmox task is in flight
 func (s *Service) Delete(ctx context.Context) error {
+	if err := s.delete(ctx); err != nil && !errors.Is(err, errTaskInFlight) {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) delete(ctx context.Context) error {
 	log := log.FromContext(ctx)
 	log.Info("Deleting instance resources")
+	s.scope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
+	// vm cannot be deleted while it is locked by the task in flight e.g. creation or migration
+	previous, err := s.pollTask(ctx)
+	if err != nil {
+		var failed *taskFailedError
+		if !errors.As(err, &failed) {
+			return err
+		}
+		switch previous.Operation {
+		case infrav1.TaskOperationStop, infrav1.TaskOperationDelete:
+			return err
+		case infrav1.TaskOperationShutdown:
+			log.Error(err, "failed to shut down instance gracefully. stopping instance")
+		default:
+			log.Error(err, "ignoring failed task", "operation", previous.Operation)
+		}
+	}
 
 	log.Info("trying to get qemu")
 	instance, err := s.getQEMU(ctx)
 	if err != nil {
+		var conflict *vmidConflictError
+		if errors.As(err, &conflict) {
+			// never delete qemu of others
+			log.Info("qemu with the vmid is not owned by this machine. skipping deletion", "reason", err.Error())
+			return s.deleteCloudConfig(ctx)
+		}
 		if !rest.IsNotFound(err) {
 			return err
 		}
@@ -67,7 +127,7 @@ func (s *Service) Delete(ctx context.Context) error {
 
 	// must stop or pause instance before deletion
 	// otherwise deletion will be fail
-	if err := ensureStoppedOrPaused(ctx, *instance); err != nil {
+	if err := s.ensureStopped(ctx, instance, previous); err != nil {
 		return err
 	}
 
@@ -77,12 +137,22 @@ func (s *Service) Delete(ctx context.Context) error {
 	}
 
 	// delete qemu
-	return instance.Delete(ctx)
+	var upid string
+	path := fmt.Sprintf("/nodes/%s/qemu/%d", instance.Node, instance.VM.VMID)
+	if err := s.client.RESTClient().Delete(ctx, path, nil, &upid); err != nil {
+		return err
+	}
+	return s.startTask(infrav1.TaskOperationDelete, upid)
 }
 
 func (s *Service) createOrGetInstance(ctx context.Context) (*proxmox.VirtualMachine, error) {
 	log := log.FromContext(ctx)
 
+	// complete the step run by the task in flight
+	if err := s.reconcileTask(ctx); err != nil {
+		return nil, err
+	}
+
 	instance, err := s.getInstance(ctx)
 	if err != nil {
 		if rest.IsNotFound(err) {
@@ -93,6 +163,11 @@ func (s *Service) createOrGetInstance(ctx context.Context) (*proxmox.VirtualMach
 		return nil, err
 	}
 
+	// the instance has gone through all the provisioning steps
+	s.scope.MarkConditionTrue(infrav1.ScheduledCondition)
+	s.scope.MarkConditionTrue(infrav1.ImageReadyCondition)
+	s.scope.MarkConditionTrue(infrav1.CloudInitReadyCondition)
+	s.scope.SetProvisioningPhase(infrav1.ProvisioningPhaseStarted)
 	return instance, nil
 }
 
@@ -135,7 +210,8 @@ func getBiosUUIDFromVM(ctx context.Context, vm *proxmox.VirtualMachine) (*string
 	return ptr.To(uuid), nil
 }
 
-// reconciles qemu, cloud-config, os image and storage
+// reconciles qemu, cloud-config, os image and storage.
+// each step is skipped if the persisted provisioning phase shows it is already done
 func (s *Service) createInstance(ctx context.Context) (*proxmox.VirtualMachine, error) {
 	log := log.FromContext(ctx)
 
@@ -148,57 +224,66 @@ func (s *Service) createInstance(ctx context.Context) (*proxmox.VirtualMachine,
 	log.Info(fmt.Sprintf("reconciled qemu: node=%s,vmid=%d", instance.Node, vmid))
 
 	// cloud init
-	if err := s.reconcileCloudInit(ctx); err != nil {
-		return nil, err
+	if !s.scope.GetProvisioningPhase().Reached(infrav1.ProvisioningPhaseConfigured) {
+		if err := s.reconcileCloudInit(ctx, instance); err != nil {
+			if !errors.Is(err, errTaskInFlight) {
+				s.scope.MarkConditionFalse(infrav1.CloudInitReadyCondition, infrav1.CloudInitFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+			}
+			return nil, err
+		}
+		if err := s.completePhase(infrav1.ProvisioningPhaseConfigured); err != nil {
+			return nil, err
+		}
 	}
+	s.scope.MarkConditionTrue(infrav1.CloudInitReadyCondition)
 
 	// set cloud image to hard disk and then resize
-	if err := s.reconcileBootDevice(ctx, instance); err != nil {
-		return nil, err
+	if !s.scope.GetProvisioningPhase().Reached(infrav1.ProvisioningPhaseResized) {
+		if err := s.reconcileBootDevice(ctx, instance); err != nil {
+			return nil, err
+		}
+		if err := s.completePhase(infrav1.ProvisioningPhaseResized); err != nil {
+			return nil, err
+		}
 	}
 
 	// vm status
-	if err := ensureRunning(ctx, *instance); err != nil {
+	if err := s.ensureRunning(ctx, instance); err != nil {
+		return nil, err
+	}
+	if err := s.completePhase(infrav1.ProvisioningPhaseStarted); err != nil {
 		return nil, err
 	}
 	return instance, nil
 }
 
-func ensureRunning(ctx context.Context, instance proxmox.VirtualMachine) error {
+// completePhase persists the provisioning phase
+func (s *Service) completePhase(phase infrav1.ProvisioningPhase) error {
+	s.scope.SetProvisioningPhase(phase)
+	return s.scope.PatchObject()
+}
+
+func (s *Service) ensureRunning(ctx context.Context, instance *proxmox.VirtualMachine) error {
 	log := log.FromContext(ctx)
 	log.Info("ensuring qemu is running")
 	switch instance.VM.Status {
 	case api.ProcessStatusRunning:
 		return nil
 	case api.ProcessStatusStopped:
-		if err := instance.Start(ctx, api.VirtualMachineStartOption{}); err != nil {
+		upid, err := requestStatus(ctx, &s.client, instance, "start", api.VirtualMachineStartOption{})
+		if err != nil {
 			log.Error(err, "failed to start instance process")
 			return err
 		}
+		return s.startTask(infrav1.TaskOperationStart, upid)
 	case api.ProcessStatusPaused:
-		if err := instance.Resume(ctx, api.VirtualMachineResumeOption{}); err != nil {
+		upid, err := requestStatus(ctx, &s.client, instance, "resume", api.VirtualMachineResumeOption{})
+		if err != nil {
 			log.Error(err, "failed to resume instance process")
 			return err
 		}
+		return s.startTask(infrav1.TaskOperationResume, upid)
 	default:
 		return errors.Errorf("unexpected status : %s", instance.VM.Status)
 	}
-	return nil
-}
-
-func ensureStoppedOrPaused(ctx context.Context, instance proxmox.VirtualMachine) error {
-	log := log.FromContext(ctx)
-	log.Info("ensuring qemus is stopped or paused")
-	switch instance.VM.Status {
-	case api.ProcessStatusRunning:
-		if err := instance.Stop(ctx, api.VirtualMachineStopOption{}); err != nil {
-			log.Error(err, "failed to stop instance process")
-			return err
-		}
-	case api.ProcessStatusPaused, api.ProcessStatusStopped:
-		return nil
-	default:
-		return errors.Errorf("unexpected status : %s", instance.VM.Status)
-	}
-	return nil
 }
@@ -2,11 +2,14 @@ package instance
 
 import (
 	"context"
+	"strings"
 
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
 )
 
 type Scope interface {
@@ -27,10 +30,39 @@ func NewService(s Scope) *Service {
 	}
 }
 
-func (s *Service) vncClient(nodeName string) (*proxmox.VNCWebSocketClient, error) {
+func (s *Service) vncClient(nodeName string) (*vncShell, error) {
 	client, err := s.client.NewNodeVNCWebSocketConnection(context.TODO(), nodeName)
 	if err != nil {
 		return nil, err
 	}
-	return client, nil
+	return &vncShell{VNCWebSocketClient: client, node: nodeName}, nil
+}
+
+// vncShell is the node shell whose commands are traced
+type vncShell struct {
+	*proxmox.VNCWebSocketClient
+	node string
+}
+
+// Exec runs the command in the node shell. only the command name is put into the span
+// since arguments may have e.g. urls with credentials
+func (v *vncShell) Exec(ctx context.Context, cmd string) (out string, code int, err error) {
+	ctx, span := tracing.Start(ctx, "proxmox.VNCExec",
+		attribute.String("node", v.node),
+		attribute.String("command", strings.SplitN(cmd, " ", 2)[0]),
+	)
+	defer func() { tracing.End(span, err) }()
+	out, code, err = v.VNCWebSocketClient.Exec(ctx, cmd)
+	span.SetAttributes(attribute.Int("exitCode", code))
+	return out, code, err
+}
+
+// WriteFile writes the content to the path on the node
+func (v *vncShell) WriteFile(ctx context.Context, content, path string) (err error) {
+	ctx, span := tracing.Start(ctx, "proxmox.VNCWriteFile",
+		attribute.String("node", v.node),
+		attribute.String("path", path),
+	)
+	defer func() { tracing.End(span, err) }()
+	return v.VNCWebSocketClient.WriteFile(ctx, content, path)
 }
@@ -0,0 +1,52 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+type shutdownOption struct {
+	// seconds to wait for the vm to stop
+	Timeout int `json:"timeout,omitempty"`
+	// power off the vm if it does not stop within the timeout
+	ForceStop int `json:"forceStop,omitempty"`
+}
+
+// ensureStopped stops the running qemu before deletion.
+// qemu guest agent is used if it is enabled in the vm config. otherwise ACPI shutdown is used.
+// proxmox powers off the vm when the timeout expires.
+// the vm is powered off immediately if the timeout is 0 or the previous graceful shutdown has failed
+func (s *Service) ensureStopped(ctx context.Context, instance *proxmox.VirtualMachine, previous *infrav1.ProxmoxTask) error {
+	log := log.FromContext(ctx)
+	switch instance.VM.Status {
+	case api.ProcessStatusPaused, api.ProcessStatusStopped:
+		return nil
+	case api.ProcessStatusRunning:
+	default:
+		return errors.Errorf("unexpected status : %s", instance.VM.Status)
+	}
+
+	timeout := s.scope.GetShutdownTimeout()
+	if timeout > 0 && (previous == nil || previous.Operation != infrav1.TaskOperationShutdown) {
+		log.Info("shutting down instance", "timeout", timeout)
+		upid, err := requestStatus(ctx, &s.client, instance, "shutdown", shutdownOption{Timeout: int(timeout.Seconds()), ForceStop: 1})
+		if err != nil {
+			return err
+		}
+		return s.startTask(infrav1.TaskOperationShutdown, upid)
+	}
+
+	log.Info("stopping instance")
+	upid, err := requestStatus(ctx, &s.client, instance, "stop", api.VirtualMachineStopOption{})
+	if err != nil {
+		log.Error(err, "failed to stop instance process")
+		return err
+	}
+	return s.startTask(infrav1.TaskOperationStop, upid)
+}
@@ -0,0 +1,188 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/task"
+)
+
+const (
+	// number of task log lines put into the condition when the task fails
+	taskLogTailLines = 5
+)
+
+// errTaskInFlight is returned when the operation continues in a proxmox task.
+// the reconciliation is resumed once the task is finished
+var errTaskInFlight = errors.New("waiting for proxmox task")
+
+// taskFailedError is a proxmox task finished with non-OK exit status
+type taskFailedError struct {
+	operation infrav1.TaskOperation
+	err       error
+	log       []string
+}
+
+func (e *taskFailedError) Error() string {
+	if len(e.log) == 0 {
+		return fmt.Sprintf("%s task failed: %v", e.operation, e.err)
+	}
+	return fmt.Sprintf("%s task failed: %v: %s", e.operation, e.err, strings.Join(e.log, "; "))
+}
+
+func (e *taskFailedError) Unwrap() error {
+	return e.err
+}
+
+// startTask records the task in the status and returns errTaskInFlight.
+// empty upid means the operation has finished synchronously
+func (s *Service) startTask(operation infrav1.TaskOperation, upid string) error {
+	if upid == "" {
+		return nil
+	}
+	t := &infrav1.ProxmoxTask{UPID: upid, Operation: operation, StartTime: metav1.Now()}
+	s.scope.SetTask(t)
+	s.markTaskInFlight(t)
+	// persist the upid so that the task is tracked even if the controller restarts
+	if err := s.scope.PatchObject(); err != nil {
+		return err
+	}
+	return errTaskInFlight
+}
+
+// pollTask checks the task in flight without blocking.
+// errTaskInFlight is returned while the task is running.
+// the finished task is cleared from the status and returned with *taskFailedError if it has failed.
+// the task which cannot be found is cleared and nil is returned as if there were no task
+func (s *Service) pollTask(ctx context.Context) (*infrav1.ProxmoxTask, error) {
+	log := log.FromContext(ctx)
+	t := s.scope.GetTask()
+	if t == nil {
+		return nil, nil
+	}
+
+	finished, err := task.IsFinished(ctx, &s.client, t.UPID)
+	if !finished {
+		if task.IsNotFound(err) {
+			// task log is gone e.g. node reinstalled, or the upid is unparseable or stale.
+			// actual state is checked again by the caller
+			log.Info("task not found. forgetting it", "operation", t.Operation, "upid", t.UPID, "reason", err.Error())
+			s.scope.SetTask(nil)
+			return nil, nil
+		}
+		if err != nil {
+			return t, err
+		}
+		log.Info("waiting for task to be finished", "operation", t.Operation, "upid", t.UPID)
+		s.markTaskInFlight(t)
+		return t, errTaskInFlight
+	}
+
+	s.scope.SetTask(nil)
+	if err != nil {
+		lines, logErr := task.LogTail(ctx, &s.client, t.UPID, taskLogTailLines)
+		if logErr != nil {
+			log.Error(logErr, "failed to get task log", "upid", t.UPID)
+		}
+		return t, &taskFailedError{operation: t.Operation, err: err, log: lines}
+	}
+	log.Info("task finished", "operation", t.Operation, "upid", t.UPID)
+	return t, nil
+}
+
+// report the task in flight on the condition of the operation
+func (s *Service) markTaskInFlight(t *infrav1.ProxmoxTask) {
+	switch t.Operation {
+	case infrav1.TaskOperationMigrate:
+		s.scope.MarkConditionFalse(infrav1.MigratedCondition, infrav1.MigratingReason, clusterv1.ConditionSeverityInfo, "%s", t.UPID)
+	case infrav1.TaskOperationShutdown:
+		s.scope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.ShuttingDownReason, clusterv1.ConditionSeverityInfo, "%s", t.UPID)
+	case infrav1.TaskOperationStop, infrav1.TaskOperationDelete:
+		s.scope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "%s task %s", t.Operation, t.UPID)
+	default:
+		s.scope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.WaitingForTaskReason, clusterv1.ConditionSeverityInfo, "%s task %s", t.Operation, t.UPID)
+	}
+}
+
+// reconcileTask completes the provisioning step or migration run by the finished task.
+// failures of provisioning steps are returned so that they are retried
+func (s *Service) reconcileTask(ctx context.Context) error {
+	log := log.FromContext(ctx)
+	t, err := s.pollTask(ctx)
+	if t == nil || errors.Is(err, errTaskInFlight) {
+		return err
+	}
+	var failed *taskFailedError
+	if err != nil && !errors.As(err, &failed) {
+		return err
+	}
+
+	switch t.Operation {
+	case infrav1.TaskOperationCreate:
+		if failed != nil {
+			// retried with the same node, vmid and storage
+			return failed
+		}
+		return s.completePhase(infrav1.ProvisioningPhaseCreated)
+	case infrav1.TaskOperationResize:
+		if failed != nil {
+			return failed
+		}
+		return s.completePhase(infrav1.ProvisioningPhaseResized)
+	case infrav1.TaskOperationStart, infrav1.TaskOperationResume:
+		if failed != nil {
+			return failed
+		}
+		return s.completePhase(infrav1.ProvisioningPhaseStarted)
+	case infrav1.TaskOperationDownload:
+		if failed != nil {
+			metrics.ObserveImageDownload(metrics.DownloadMethodDownloadURL, t.StartTime.Time, failed)
+			s.scope.MarkConditionFalse(infrav1.ImageReadyCondition, infrav1.ImageDownloadFailedReason, clusterv1.ConditionSeverityWarning, "%v", failed)
+			return failed
+		}
+		metrics.ObserveImageDownload(metrics.DownloadMethodDownloadURL, t.StartTime.Time, nil)
+		return nil
+	case infrav1.TaskOperationUpload:
+		if failed != nil {
+			s.scope.MarkConditionFalse(infrav1.CloudInitReadyCondition, infrav1.CloudInitFailedReason, clusterv1.ConditionSeverityWarning, "%v", failed)
+			return failed
+		}
+		return nil
+	case infrav1.TaskOperationMigrate:
+		if failed != nil {
+			// the vm stays on the source node. give up the migration
+			log.Error(failed, "failed to migrate instance")
+			s.scope.MarkConditionFalse(infrav1.MigratedCondition, infrav1.MigrationFailedReason, clusterv1.ConditionSeverityWarning, "%v", failed)
+			s.scope.SetNodeName(s.scope.GetCurrentNode())
+			return nil
+		}
+		// current node is updated to the actual node of the vm by reconcileMigration
+		log.Info("instance migrated")
+		s.scope.MarkConditionTrue(infrav1.MigratedCondition)
+		return nil
+	default:
+		if failed != nil {
+			log.Error(failed, "ignoring failed task", "operation", t.Operation)
+		}
+		return nil
+	}
+}
+
+// request the qemu status change (e.g. start, stop) and return the UPID of the task
+func requestStatus(ctx context.Context, client *proxmox.Service, instance *proxmox.VirtualMachine, command string, option interface{}) (string, error) {
+	var upid string
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/%s", instance.Node, instance.VM.VMID, command)
+	if err := client.RESTClient().Post(ctx, path, option, &upid); err != nil {
+		return "", err
+	}
+	return upid, nil
+}
@@ -0,0 +1,97 @@
+package instance_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/instance"
+)
+
+var _ = Describe("taskFailedError", Label("unit", "task"), func() {
+	exitStatus := errors.New("task UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmcreate:100:root@pam: failed: unable to create VM 100")
+
+	It("should include the operation and log tail", func() {
+		err := instance.TaskFailedError(infrav1.TaskOperationCreate, exitStatus, []string{"importing disk", "TASK ERROR: unable to create VM 100"})
+		Expect(err.Error()).To(Equal("create task failed: " + exitStatus.Error() + ": importing disk; TASK ERROR: unable to create VM 100"))
+		Expect(errors.Is(err, exitStatus)).To(BeTrue())
+	})
+
+	It("should omit empty log", func() {
+		err := instance.TaskFailedError(infrav1.TaskOperationStart, exitStatus, nil)
+		Expect(err.Error()).To(Equal("start task failed: " + exitStatus.Error()))
+	})
+})
+
+// taskScope is a machine scope having only what reconciling the task needs
+type taskScope struct {
+	instance.Scope
+	client *proxmox.Service
+	phase  infrav1.ProvisioningPhase
+	task   *infrav1.ProxmoxTask
+}
+
+func (s *taskScope) CloudClient() *proxmox.Service                        { return s.client }
+func (s *taskScope) GetScheduler(*proxmox.Service) *scheduler.Scheduler   { return nil }
+func (s *taskScope) GetProvisioningPhase() infrav1.ProvisioningPhase      { return s.phase }
+func (s *taskScope) SetProvisioningPhase(phase infrav1.ProvisioningPhase) { s.phase = phase }
+func (s *taskScope) GetTask() *infrav1.ProxmoxTask                        { return s.task }
+func (s *taskScope) SetTask(task *infrav1.ProxmoxTask)                    { s.task = task }
+func (s *taskScope) MarkConditionFalse(clusterv1.ConditionType, string, clusterv1.ConditionSeverity, string, ...interface{}) {
+}
+
+var _ = Describe("reconcileTask", Label("unit", "task"), func() {
+	const upid = "UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmcreate:100:root@pam:"
+	var server *httptest.Server
+	var scope *taskScope
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/status"):
+				fmt.Fprint(w, `{"data":{"status":"stopped","exitstatus":"unable to create VM 100"}}`)
+			case strings.HasSuffix(r.URL.Path, "/log"):
+				fmt.Fprint(w, `{"data":[{"n":1,"t":"TASK ERROR: unable to create VM 100"}],"total":1}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		client, err := proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+		scope = &taskScope{
+			client: client,
+			phase:  infrav1.ProvisioningPhaseScheduled,
+			task:   &infrav1.ProxmoxTask{UPID: upid, Operation: infrav1.TaskOperationCreate},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should keep the scheduling result when creating qemu fails", func() {
+		err := instance.ReconcileTask(context.Background(), instance.NewService(scope))
+		Expect(err).To(MatchError(ContainSubstring("create task failed")))
+		Expect(err.Error()).To(HaveSuffix("TASK ERROR: unable to create VM 100"))
+		Expect(scope.GetTask()).To(BeNil())
+		Expect(scope.GetProvisioningPhase()).To(Equal(infrav1.ProvisioningPhaseScheduled))
+	})
+
+	It("should forget the task with unparseable upid", func() {
+		scope.task.UPID = "UPID:broken"
+		Expect(instance.ReconcileTask(context.Background(), instance.NewService(scope))).To(Succeed())
+		Expect(scope.GetTask()).To(BeNil())
+		// the actual qemu state is checked again instead of completing the step
+		Expect(scope.GetProvisioningPhase()).To(Equal(infrav1.ProvisioningPhaseScheduled))
+	})
+})
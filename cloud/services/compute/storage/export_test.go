@@ -7,3 +7,7 @@ import (
 func GenerateVMStorageOptions(scope Scope) api.StorageCreateOptions {
 	return generateVMStorageOptions(scope)
 }
+
+func MissingContents(current, desired string) []string {
+	return missingContents(current, desired)
+}
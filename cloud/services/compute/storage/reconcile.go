@@ -2,17 +2,26 @@ package storage
 
 import (
 	"context"
+	"strings"
 
 	"github.com/k8s-proxmox/proxmox-go/api"
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	"github.com/k8s-proxmox/proxmox-go/rest"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 )
 
 const (
 	DefaultBasePath = "/var/lib/vz"
+
+	// content of the storage for cloud-init snippets
+	snippetsContent = "snippets"
+
+	// with API token, cloud-init iso and os image are uploaded to the storage instead.
+	// import content requires proxmox 8.2 or later
+	apiTokenContent = "snippets,iso,import"
 )
 
 func (s *Service) Reconcile(ctx context.Context) error {
@@ -20,8 +29,10 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	log.Info("Reconciling storage")
 
 	if err := s.createOrGetStorage(ctx); err != nil {
+		s.scope.MarkConditionFalse(infrav1.StorageReadyCondition, infrav1.StorageReconcileFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 		return err
 	}
+	s.scope.MarkConditionTrue(infrav1.StorageReadyCondition)
 
 	log.Info("Reconciled storage")
 	return nil
@@ -30,14 +41,20 @@ func (s *Service) Reconcile(ctx context.Context) error {
 func (s *Service) Delete(ctx context.Context) error {
 	log := log.FromContext(ctx)
 	log.Info("Deleteing storage")
-	return s.deleteStorage(ctx)
+	if err := s.deleteStorage(ctx); err != nil {
+		s.scope.MarkConditionFalse(infrav1.StorageReadyCondition, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return err
+	}
+	s.scope.MarkConditionFalse(infrav1.StorageReadyCondition, clusterv1.DeletedReason, clusterv1.ConditionSeverityInfo, "")
+	return nil
 }
 
 // createOrGetStorage gets Proxmox Storage for VMs
 func (s *Service) createOrGetStorage(ctx context.Context) error {
 	log := log.FromContext(ctx)
 	opts := generateVMStorageOptions(s.scope)
-	if err := s.getStorage(ctx, opts.Storage); err != nil {
+	storage, err := s.client.Storage(ctx, opts.Storage)
+	if err != nil {
 		if rest.IsNotFound(err) {
 			log.Info("storage %s not found. it will be created")
 			return s.createStorage(ctx, opts)
@@ -45,14 +62,16 @@ func (s *Service) createOrGetStorage(ctx context.Context) error {
 		return err
 	}
 
-	s.scope.SetStorage(infrav1.Storage{Name: opts.Storage, Path: opts.Path})
-	return nil
-}
-
-func (s *Service) getStorage(ctx context.Context, name string) error {
-	if _, err := s.client.Storage(ctx, name); err != nil {
-		return err
+	// storage created before switching to API token lacks contents for uploads
+	if missing := missingContents(storage.Storage.Content, opts.Content); len(missing) > 0 {
+		log.Info("adding contents to storage", "content", missing)
+		content := strings.Trim(storage.Storage.Content+","+strings.Join(missing, ","), ",")
+		if err := s.client.RESTClient().Put(ctx, "/storage/"+opts.Storage, map[string]string{"content": content}, nil); err != nil {
+			return err
+		}
 	}
+
+	s.scope.SetStorage(infrav1.Storage{Name: opts.Storage, Path: opts.Path})
 	return nil
 }
 
@@ -104,12 +123,40 @@ func (s *Service) deleteStorage(ctx context.Context) error {
 func generateVMStorageOptions(scope Scope) api.StorageCreateOptions {
 	storageSpec := scope.Storage()
 	mkdir := true
+	content := snippetsContent
+	if scope.UploadClient() != nil {
+		content = apiTokenContent
+	}
 	options := api.StorageCreateOptions{
 		Storage:     storageSpec.Name,
 		StorageType: "dir",
-		Content:     "snippets",
+		Content:     content,
 		Mkdir:       &mkdir,
 		Path:        storageSpec.Path,
 	}
 	return options
 }
+
+// missingContents returns contents of desired which current doesn't have
+func missingContents(current, desired string) []string {
+	has := map[string]bool{}
+	for _, c := range strings.Split(current, ",") {
+		has[c] = true
+	}
+	missing := []string{}
+	for _, c := range strings.Split(desired, ",") {
+		if c != "" && !has[c] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// return true if the storage is shared among proxmox nodes
+func IsSharedStorage(ctx context.Context, client *proxmox.Service, node, name string) (bool, error) {
+	storage, err := client.RESTClient().GetNodeStorage(ctx, node, name)
+	if err != nil {
+		return false, err
+	}
+	return storage.Shared == 1, nil
+}
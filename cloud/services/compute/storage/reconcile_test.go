@@ -7,7 +7,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/storage"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/internal/fake"
 )
@@ -80,3 +80,13 @@ var _ = Describe("generateVMStorageOptions", Label("unit", "storage"), func() {
 		})
 	})
 })
+
+var _ = Describe("missingContents", Label("unit", "storage"), func() {
+	It("should return contents for API token which the storage lacks", func() {
+		Expect(storage.MissingContents("snippets", "snippets,iso,import")).To(Equal([]string{"iso", "import"}))
+	})
+
+	It("should return nothing if the storage has all contents", func() {
+		Expect(storage.MissingContents("import,iso,snippets,images", "snippets,iso,import")).To(BeEmpty())
+	})
+})
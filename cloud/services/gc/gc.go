@@ -0,0 +1,192 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
+)
+
+var (
+	// volume id of cloud-init user snippet e.g. local-dir-foo:snippets/foo-md-0-abcde.cappx-cluster-<uid>-user.yml
+	userSnippetRegexp = regexp.MustCompile(`^[^:]+:snippets/(.+)-user\.yml$`)
+
+	// volume id of NoCloud iso uploaded with API token e.g. local-dir-foo:iso/foo-md-0-abcde.cappx-cluster-<uid>-cloudinit.iso
+	cloudInitISORegexp = regexp.MustCompile(`^[^:]+:iso/(.+)-cloudinit\.iso$`)
+)
+
+// VM is a qemu listed in proxmox cluster resources
+type VM struct {
+	Type     string `json:"type"`
+	Node     string `json:"node"`
+	VMID     int    `json:"vmid"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Tags     string `json:"tags"`
+	Template int    `json:"template"`
+}
+
+// Snippet is a cloud-init user snippet, or NoCloud iso with API token, in the storage of the cluster
+type Snippet struct {
+	Node     string
+	VolumeID string
+}
+
+// MachineName returns the name of the ProxmoxMachine of the cluster which the snippet was written for.
+// false is returned unless the file name has the cluster tag, i.e. the snippet is proven to be of the cluster.
+// snippets written before the cluster tag was put in the file name are never returned
+func (s Snippet) MachineName(clusterUID types.UID) (string, bool) {
+	clusterTag := ownership.ClusterTag(clusterUID)
+	if clusterTag == "" {
+		return "", false
+	}
+	for _, r := range []*regexp.Regexp{userSnippetRegexp, cloudInitISORegexp} {
+		if match := r.FindStringSubmatch(s.VolumeID); match != nil {
+			name, ok := strings.CutSuffix(match[1], "."+clusterTag)
+			return name, ok && name != ""
+		}
+	}
+	return "", false
+}
+
+// OrphanedVMs returns qemus tagged with the cluster whose ProxmoxMachines no longer exist.
+// qemus created before cluster tag was introduced are never returned
+func OrphanedVMs(vms []VM, clusterUID types.UID, machineUIDs map[types.UID]bool) []VM {
+	orphans := []VM{}
+	clusterTag := ownership.ClusterTag(clusterUID)
+	for _, vm := range vms {
+		if vm.Template == 1 || !ownership.HasTag(vm.Tags, clusterTag) {
+			continue
+		}
+		uid, ok := ownership.MachineUID(vm.Tags)
+		if !ok || machineUIDs[uid] {
+			continue
+		}
+		orphans = append(orphans, vm)
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].VMID < orphans[j].VMID })
+	return orphans
+}
+
+// OrphanedSnippets returns user snippets of the cluster whose ProxmoxMachines and qemus no longer exist.
+// snippets of existing qemus are left until the qemus are collected
+func OrphanedSnippets(snippets []Snippet, clusterUID types.UID, machineNames map[string]bool, vms []VM) []Snippet {
+	vmNames := map[string]bool{}
+	for _, vm := range vms {
+		vmNames[vm.Name] = true
+	}
+	orphans := []Snippet{}
+	for _, snippet := range snippets {
+		name, ok := snippet.MachineName(clusterUID)
+		if !ok || machineNames[name] || vmNames[name] {
+			continue
+		}
+		orphans = append(orphans, snippet)
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].VolumeID < orphans[j].VolumeID })
+	return orphans
+}
+
+// ListVMs returns all qemus in the proxmox cluster
+func ListVMs(ctx context.Context, client *proxmox.Service) ([]VM, error) {
+	var resources []VM
+	if err := client.RESTClient().Get(ctx, "/cluster/resources?type=vm", &resources); err != nil {
+		return nil, err
+	}
+	vms := []VM{}
+	for _, r := range resources {
+		if r.Type == "qemu" {
+			vms = append(vms, r)
+		}
+	}
+	return vms, nil
+}
+
+// ListSnippets returns user snippets and NoCloud isos in the storage on all online nodes.
+// snippets of shared storage are listed once
+func ListSnippets(ctx context.Context, client *proxmox.Service, storageName string) ([]Snippet, error) {
+	storage, err := client.Storage(ctx, storageName)
+	if err != nil {
+		if rest.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	nodes, err := client.GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	snippets := []Snippet{}
+	for _, node := range nodes {
+		if node.Status != "online" {
+			continue
+		}
+		storage.Node = node.Node
+		contents, err := storage.GetContents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range contents {
+			if (content.Content != "snippets" && content.Content != "iso") || seen[content.VolID] {
+				continue
+			}
+			seen[content.VolID] = true
+			snippets = append(snippets, Snippet{Node: node.Node, VolumeID: content.VolID})
+		}
+	}
+	return snippets, nil
+}
+
+// DeleteVM requests to stop the qemu if it is running, otherwise to delete it,
+// and returns the task without waiting for it. nil task is returned if the qemu is already gone.
+// the qemu is deleted by calling DeleteVM again once the stop task is finished
+func DeleteVM(ctx context.Context, client *proxmox.Service, vm VM) (*infrav1.ProxmoxTask, error) {
+	qemu, err := client.VirtualMachine(ctx, vm.VMID)
+	if err != nil {
+		if rest.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// make sure the vmid is not reused by another qemu since it was listed
+	if qemu.VM.Name != vm.Name {
+		return nil, fmt.Errorf("vmid %d is now used by qemu %s", vm.VMID, qemu.VM.Name)
+	}
+	var upid string
+	operation := infrav1.TaskOperationDelete
+	path := fmt.Sprintf("/nodes/%s/qemu/%d", qemu.Node, vm.VMID)
+	if qemu.VM.Status == api.ProcessStatusRunning {
+		operation = infrav1.TaskOperationStop
+		err = client.RESTClient().Post(ctx, path+"/status/stop", api.VirtualMachineStopOption{}, &upid)
+	} else {
+		err = client.RESTClient().Delete(ctx, path, nil, &upid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upid == "" {
+		return nil, nil
+	}
+	return &infrav1.ProxmoxTask{UPID: upid, Operation: operation, StartTime: metav1.Now()}, nil
+}
+
+// DeleteSnippet deletes the snippet from the storage
+func DeleteSnippet(ctx context.Context, client *proxmox.Service, storageName string, snippet Snippet) error {
+	storage, err := client.Storage(ctx, storageName)
+	if err != nil {
+		return err
+	}
+	storage.Node = snippet.Node
+	return storage.DeleteVolume(ctx, snippet.VolumeID)
+}
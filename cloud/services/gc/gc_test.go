@@ -0,0 +1,145 @@
+package gc_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/ownership"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/gc"
+)
+
+func TestGC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GC Suite")
+}
+
+var _ = Describe("OrphanedVMs", Label("unit", "gc"), func() {
+	clusterUID := types.UID("cluster")
+	vms := []gc.VM{
+		{VMID: 102, Name: "gone", Tags: ownership.Tags(clusterUID, "m2")},
+		{VMID: 100, Name: "alive", Tags: "foo;" + ownership.Tags(clusterUID, "m0")},
+		{VMID: 101, Name: "gone-too", Tags: ownership.Tags(clusterUID, "m1")},
+		{VMID: 103, Name: "other-cluster", Tags: ownership.Tags("another", "m3")},
+		{VMID: 104, Name: "untagged", Tags: ownership.MachineTag("m4")},
+		{VMID: 105, Name: "template", Tags: ownership.Tags(clusterUID, "m5"), Template: 1},
+	}
+
+	It("should return qemus of the cluster without ProxmoxMachine", func() {
+		orphans := gc.OrphanedVMs(vms, clusterUID, map[types.UID]bool{"m0": true})
+		Expect(orphans).To(HaveLen(2))
+		Expect(orphans[0].VMID).To(Equal(101))
+		Expect(orphans[1].VMID).To(Equal(102))
+	})
+})
+
+var _ = Describe("OrphanedSnippets", Label("unit", "gc"), func() {
+	clusterUID := types.UID("cluster")
+	snippets := []gc.Snippet{
+		{Node: "node1", VolumeID: "local-dir-foo:snippets/alive.cappx-cluster-cluster-user.yml"},
+		{Node: "node1", VolumeID: "local-dir-foo:snippets/gone.cappx-cluster-cluster-user.yml"},
+		{Node: "node2", VolumeID: "local-dir-foo:snippets/vm-left.cappx-cluster-cluster-user.yml"},
+		{Node: "node2", VolumeID: "local-dir-foo:snippets/hook.sh"},
+		// snippets of other clusters, hand-made vms and the ones written before the cluster tag was put in the name
+		{Node: "node1", VolumeID: "local-dir-foo:snippets/gone.cappx-cluster-another-user.yml"},
+		{Node: "node1", VolumeID: "local-dir-foo:snippets/gone-user.yml"},
+		{Node: "node1", VolumeID: "local-dir-foo:iso/gone-cloudinit.iso"},
+	}
+
+	It("should return snippets of the cluster without ProxmoxMachine and qemu", func() {
+		orphans := gc.OrphanedSnippets(snippets, clusterUID, map[string]bool{"alive": true}, []gc.VM{{Name: "vm-left"}})
+		Expect(orphans).To(Equal([]gc.Snippet{{Node: "node1", VolumeID: "local-dir-foo:snippets/gone.cappx-cluster-cluster-user.yml"}}))
+	})
+})
+
+var _ = Describe("Snippet.MachineName", Label("unit", "gc"), func() {
+	clusterUID := types.UID("0f8e4a1c-1b2a-4c3d-9e8f-7a6b5c4d3e2f")
+
+	It("should parse machine name of user snippet", func() {
+		name, ok := gc.Snippet{VolumeID: "local-dir-foo:snippets/foo-md-0-abcde.cappx-cluster-0f8e4a1c-1b2a-4c3d-9e8f-7a6b5c4d3e2f-user.yml"}.MachineName(clusterUID)
+		Expect(ok).To(BeTrue())
+		Expect(name).To(Equal("foo-md-0-abcde"))
+	})
+
+	It("should parse machine name of cloud-init iso", func() {
+		name, ok := gc.Snippet{VolumeID: "local-dir-foo:iso/foo-md-0-abcde.cappx-cluster-0f8e4a1c-1b2a-4c3d-9e8f-7a6b5c4d3e2f-cloudinit.iso"}.MachineName(clusterUID)
+		Expect(ok).To(BeTrue())
+		Expect(name).To(Equal("foo-md-0-abcde"))
+	})
+
+	It("should ignore files not proven to be of the cluster", func() {
+		for _, volumeID := range []string{
+			"local-dir-foo:iso/ubuntu-22.04.iso",
+			"local-dir-foo:snippets/foo.yml",
+			"local-dir-foo:snippets/foo-md-0-abcde-user.yml",
+			"local-dir-foo:iso/foo-md-0-abcde-cloudinit.iso",
+			"local-dir-foo:snippets/foo-md-0-abcde.cappx-cluster-another-user.yml",
+		} {
+			_, ok := gc.Snippet{VolumeID: volumeID}.MachineName(clusterUID)
+			Expect(ok).To(BeFalse(), volumeID)
+		}
+		_, ok := gc.Snippet{VolumeID: "local-dir-foo:snippets/foo.cappx-cluster--user.yml"}.MachineName("")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("DeleteVM", Label("unit", "gc"), func() {
+	var server *httptest.Server
+	var client *proxmox.Service
+	var requests []string
+
+	BeforeEach(func() {
+		requests = []string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/api2/json/cluster/resources":
+				fmt.Fprint(w, `{"data":[{"type":"qemu","node":"node1","vmid":100,"name":"running","status":"running"},{"type":"qemu","node":"node1","vmid":101,"name":"stopped","status":"stopped"}]}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/api2/json/nodes":
+				fmt.Fprint(w, `{"data":[{"node":"node1","status":"online"}]}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/api2/json/nodes/node1/qemu":
+				fmt.Fprint(w, `{"data":[{"vmid":100,"name":"running","status":"running"},{"vmid":101,"name":"stopped","status":"stopped"}]}`)
+			case r.Method == http.MethodPost || r.Method == http.MethodDelete:
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				fmt.Fprint(w, `{"data":"UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmstop:100:root@pam:"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		var err error
+		client, err = proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should request to stop running qemu without waiting", func() {
+		t, err := gc.DeleteVM(context.Background(), client, gc.VM{VMID: 100, Name: "running"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Operation).To(Equal(infrav1.TaskOperationStop))
+		Expect(t.UPID).NotTo(BeEmpty())
+		Expect(requests).To(Equal([]string{"POST /api2/json/nodes/node1/qemu/100/status/stop"}))
+	})
+
+	It("should request to delete stopped qemu without waiting", func() {
+		t, err := gc.DeleteVM(context.Background(), client, gc.VM{VMID: 101, Name: "stopped"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Operation).To(Equal(infrav1.TaskOperationDelete))
+		Expect(requests).To(Equal([]string{"DELETE /api2/json/nodes/node1/qemu/101"}))
+	})
+
+	It("should never delete qemu reusing the vmid", func() {
+		_, err := gc.DeleteVM(context.Background(), client, gc.VM{VMID: 101, Name: "orphan"})
+		Expect(err).To(MatchError(ContainSubstring("vmid 101 is now used by qemu stopped")))
+		Expect(requests).To(BeEmpty())
+	})
+})
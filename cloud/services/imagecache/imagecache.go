@@ -0,0 +1,345 @@
+package imagecache
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+)
+
+const (
+	// DirPath is the directory on proxmox nodes which OS images are downloaded into via node shell
+	DirPath = "/etc/cappx/images"
+
+	// default number of unreferenced images kept on each node
+	defaultMaxImages = 3
+)
+
+// file name of the image with md5 or sha256 checksum prefix. see FileName
+var checksumFileNameRegexp = regexp.MustCompile(`^([0-9a-fA-F]{32}|[0-9a-fA-F]{64})\.`)
+
+// Image is an OS image cached on a proxmox node
+type Image struct {
+	Node string
+
+	// file name in DirPath, or in import content with API token
+	Name string
+
+	// volume id of import content. empty for images in DirPath
+	VolumeID string
+
+	// size in bytes
+	Size int64
+
+	// time the image was downloaded or last used.
+	// for import content it is the time the volume was created (see WithLastUsed)
+	ModTime time.Time
+}
+
+// Limits are limits of images cached on a node
+type Limits struct {
+	// number of unreferenced images kept. negative means unlimited
+	MaxImages int
+
+	// total size in bytes of images kept. zero means unlimited
+	MaxSize int64
+}
+
+// FileName returns the file name of the image in DirPath
+func FileName(image infrav1.Image) string {
+	fileName := path.Base(image.URL)
+	if image.Checksum != "" {
+		fileName = image.Checksum + "." + fileName
+	}
+	return fileName
+}
+
+// ImportFileName returns file name of the image in import content.
+// proxmox detects the image format from the extension, so images without
+// known extension e.g. ubuntu cloud images (.img) are regarded as qcow2
+func ImportFileName(image infrav1.Image) string {
+	fileName := FileName(image)
+	switch path.Ext(fileName) {
+	case ".qcow2", ".raw", ".vmdk":
+		return fileName
+	default:
+		return fileName + ".qcow2"
+	}
+}
+
+// ImportVolumeID returns volume id of the image in import content of the storage
+func ImportVolumeID(storageName string, image infrav1.Image) string {
+	return fmt.Sprintf("%s:import/%s", storageName, ImportFileName(image))
+}
+
+// ReferencedNames returns file names which the image may be cached as
+func ReferencedNames(image infrav1.Image) []string {
+	return []string{FileName(image), ImportFileName(image)}
+}
+
+// LimitsFor returns the limits of the node in the policy
+func LimitsFor(policy *infrav1.ImageCachePolicy, node string) Limits {
+	maxImages, maxSize := policy.MaxImages, policy.MaxSize
+	for _, n := range policy.Nodes {
+		if n.Name != node {
+			continue
+		}
+		if n.MaxImages != nil {
+			maxImages = n.MaxImages
+		}
+		if n.MaxSize != nil {
+			maxSize = n.MaxSize
+		}
+	}
+	limits := Limits{MaxImages: defaultMaxImages}
+	if maxImages != nil {
+		limits.MaxImages = int(*maxImages)
+	}
+	if maxSize != nil {
+		limits.MaxSize = maxSize.Value()
+	}
+	return limits
+}
+
+// Evictable returns images which exceed the limits of their nodes.
+// referenced images are always kept. the most recently used images are kept
+// up to MaxImages, then least recently used ones are evicted until the node fits in MaxSize
+func Evictable(images []Image, referenced map[string]bool, limits func(node string) Limits) []Image {
+	byNode := map[string][]Image{}
+	for _, image := range images {
+		byNode[image.Node] = append(byNode[image.Node], image)
+	}
+	evictable := []Image{}
+	for node, nodeImages := range byNode {
+		limit := limits(node)
+		sort.Slice(nodeImages, func(i, j int) bool {
+			if !nodeImages[i].ModTime.Equal(nodeImages[j].ModTime) {
+				return nodeImages[i].ModTime.After(nodeImages[j].ModTime)
+			}
+			return nodeImages[i].Name < nodeImages[j].Name
+		})
+		var total int64
+		kept := []Image{}
+		for _, image := range nodeImages {
+			if referenced[image.Name] {
+				total += image.Size
+				continue
+			}
+			if limit.MaxImages >= 0 && len(kept) >= limit.MaxImages {
+				evictable = append(evictable, image)
+				continue
+			}
+			total += image.Size
+			kept = append(kept, image)
+		}
+		// kept images are sorted from the most recently used
+		for i := len(kept) - 1; i >= 0 && limit.MaxSize > 0 && total > limit.MaxSize; i-- {
+			evictable = append(evictable, kept[i])
+			total -= kept[i].Size
+		}
+	}
+	sort.Slice(evictable, func(i, j int) bool {
+		if evictable[i].Node != evictable[j].Node {
+			return evictable[i].Node < evictable[j].Node
+		}
+		return evictable[i].Name < evictable[j].Name
+	})
+	return evictable
+}
+
+// UpdateLastUsed returns the last-use times of import images keyed by volume id,
+// updated with now for the referenced ones. entries of images not listed are dropped
+// only if prune is true, i.e. the images of all the sites have been listed
+func UpdateLastUsed(lastUsed map[string]metav1.Time, images []Image, referenced map[string]bool, now time.Time, prune bool) map[string]metav1.Time {
+	updated := map[string]metav1.Time{}
+	if !prune {
+		for volID, t := range lastUsed {
+			updated[volID] = t
+		}
+	}
+	for _, image := range images {
+		if image.VolumeID == "" {
+			continue
+		}
+		if referenced[image.Name] {
+			updated[image.VolumeID] = metav1.NewTime(now)
+		} else if t, ok := lastUsed[image.VolumeID]; ok {
+			updated[image.VolumeID] = t
+		}
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	return updated
+}
+
+// Downloaded returns the images downloaded by CAPPX. all images in DirPath are.
+// import content may have images uploaded by users, so only import images whose names
+// have checksum prefix or which have been found referenced (i.e. recorded in lastUsed) are returned
+func Downloaded(images []Image, lastUsed map[string]metav1.Time) []Image {
+	result := []Image{}
+	for _, image := range images {
+		if image.VolumeID != "" && !checksumFileNameRegexp.MatchString(image.Name) {
+			if _, ok := lastUsed[image.VolumeID]; !ok {
+				continue
+			}
+		}
+		result = append(result, image)
+	}
+	return result
+}
+
+// WithLastUsed returns the images whose ModTime is replaced with the last-use time if it is more recent.
+// volume ctime of import content never changes even when the image is used,
+// so the last-use times recorded by UpdateLastUsed are used to find least recently used ones
+func WithLastUsed(images []Image, lastUsed map[string]metav1.Time) []Image {
+	result := make([]Image, 0, len(images))
+	for _, image := range images {
+		if t, ok := lastUsed[image.VolumeID]; ok && image.VolumeID != "" && t.After(image.ModTime) {
+			image.ModTime = t.Time
+		}
+		result = append(result, image)
+	}
+	return result
+}
+
+// ListShellImages returns images in DirPath of all online nodes
+func ListShellImages(ctx context.Context, client *proxmox.Service) ([]Image, error) {
+	nodes, err := client.GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	images := []Image{}
+	for _, node := range nodes {
+		if node.Status != "online" {
+			continue
+		}
+		nodeImages, err := listNodeImages(ctx, client, node.Node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images on node %s: %w", node.Node, err)
+		}
+		images = append(images, nodeImages...)
+	}
+	return images, nil
+}
+
+func listNodeImages(ctx context.Context, client *proxmox.Service, node string) ([]Image, error) {
+	vnc, err := client.NewNodeVNCWebSocketConnection(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	defer vnc.Close()
+	cmd := fmt.Sprintf("test ! -d %s || find %s -maxdepth 1 -type f -printf '%%T@ %%s %%f\\n'", DirPath, DirPath)
+	out, _, err := vnc.Exec(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%s : %w", out, err)
+	}
+	return ParseFindOutput(node, out), nil
+}
+
+// ParseFindOutput parses lines of "<mtime in unix seconds> <size> <file name>" printed by find.
+// lines which are not in the format e.g. shell prompts are ignored
+func ParseFindOutput(node, out string) []Image {
+	images := []Image{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) != 3 || fields[2] == "" {
+			continue
+		}
+		mtime, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		images = append(images, Image{
+			Node:    node,
+			Name:    fields[2],
+			Size:    size,
+			ModTime: time.Unix(int64(mtime), 0),
+		})
+	}
+	return images
+}
+
+// ListImportImages returns images in import content of the storage on all online nodes.
+// images of shared storage are listed once
+func ListImportImages(ctx context.Context, client *proxmox.Service, storageName string) ([]Image, error) {
+	storage, err := client.Storage(ctx, storageName)
+	if err != nil {
+		if rest.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	nodes, err := client.GetNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	images := []Image{}
+	for _, node := range nodes {
+		if node.Status != "online" {
+			continue
+		}
+		storage.Node = node.Node
+		contents, err := storage.GetContents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range contents {
+			if content.Content != "import" || seen[content.VolID] {
+				continue
+			}
+			seen[content.VolID] = true
+			ctime, _ := content.CTime.Int64()
+			images = append(images, Image{
+				Node:     node.Node,
+				Name:     path.Base(content.VolID),
+				VolumeID: content.VolID,
+				Size:     int64(content.Size),
+				ModTime:  time.Unix(ctime, 0),
+			})
+		}
+	}
+	return images, nil
+}
+
+// DeleteShellImage deletes the image from DirPath of its node
+func DeleteShellImage(ctx context.Context, client *proxmox.Service, image Image) error {
+	if image.Name == "" || strings.ContainsAny(image.Name, "/'") {
+		return fmt.Errorf("invalid image file name %q", image.Name)
+	}
+	vnc, err := client.NewNodeVNCWebSocketConnection(ctx, image.Node)
+	if err != nil {
+		return err
+	}
+	defer vnc.Close()
+	out, _, err := vnc.Exec(ctx, fmt.Sprintf("rm -f '%s/%s'", DirPath, image.Name))
+	if err != nil {
+		return fmt.Errorf("%s : %w", out, err)
+	}
+	return nil
+}
+
+// DeleteImportImage deletes the image from import content of the storage
+func DeleteImportImage(ctx context.Context, client *proxmox.Service, storageName string, image Image) error {
+	storage, err := client.Storage(ctx, storageName)
+	if err != nil {
+		return err
+	}
+	storage.Node = image.Node
+	return storage.DeleteVolume(ctx, image.VolumeID)
+}
@@ -0,0 +1,182 @@
+package imagecache_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/imagecache"
+)
+
+func TestImageCache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ImageCache Suite")
+}
+
+var _ = Describe("ImportFileName", Label("unit", "imagecache"), func() {
+	It("should keep known image extension", func() {
+		image := infrav1.Image{URL: "https://cloud.debian.org/images/cloud/bookworm/latest/debian-12-genericcloud-amd64.qcow2"}
+		Expect(imagecache.ImportFileName(image)).To(Equal("debian-12-genericcloud-amd64.qcow2"))
+	})
+
+	It("should regard unknown extension as qcow2", func() {
+		image := infrav1.Image{
+			URL:      "https://cloud-images.ubuntu.com/releases/jammy/release/ubuntu-22.04-server-cloudimg-amd64.img",
+			Checksum: "abcdef",
+		}
+		Expect(imagecache.ImportFileName(image)).To(Equal("abcdef.ubuntu-22.04-server-cloudimg-amd64.img.qcow2"))
+	})
+})
+
+var _ = Describe("LimitsFor", Label("unit", "imagecache"), func() {
+	policy := &infrav1.ImageCachePolicy{
+		MaxImages: ptr.To[int32](2),
+		MaxSize:   ptr.To(resource.MustParse("10Gi")),
+		Nodes: []infrav1.NodeImageCacheLimits{
+			{Name: "node2", MaxImages: ptr.To[int32](0)},
+		},
+	}
+
+	It("should return the limits of the policy", func() {
+		Expect(imagecache.LimitsFor(policy, "node1")).To(Equal(imagecache.Limits{MaxImages: 2, MaxSize: 10 << 30}))
+	})
+
+	It("should override the limits of the node", func() {
+		Expect(imagecache.LimitsFor(policy, "node2")).To(Equal(imagecache.Limits{MaxImages: 0, MaxSize: 10 << 30}))
+	})
+
+	It("should keep 3 images by default", func() {
+		Expect(imagecache.LimitsFor(&infrav1.ImageCachePolicy{}, "node1")).To(Equal(imagecache.Limits{MaxImages: 3}))
+	})
+})
+
+var _ = Describe("Evictable", Label("unit", "imagecache"), func() {
+	now := time.Now()
+	images := []imagecache.Image{
+		{Node: "node1", Name: "a.img", Size: 4, ModTime: now.Add(-4 * time.Hour)},
+		{Node: "node1", Name: "b.img", Size: 3, ModTime: now.Add(-3 * time.Hour)},
+		{Node: "node1", Name: "c.img", Size: 2, ModTime: now.Add(-2 * time.Hour)},
+		{Node: "node1", Name: "d.img", Size: 1, ModTime: now.Add(-1 * time.Hour)},
+		{Node: "node2", Name: "a.img", Size: 4, ModTime: now.Add(-4 * time.Hour)},
+	}
+	names := func(images []imagecache.Image) []string {
+		result := []string{}
+		for _, image := range images {
+			result = append(result, image.Node+"/"+image.Name)
+		}
+		return result
+	}
+
+	It("should keep the most recently used images and referenced ones", func() {
+		limits := func(string) imagecache.Limits { return imagecache.Limits{MaxImages: 1} }
+		evictable := imagecache.Evictable(images, map[string]bool{"a.img": true}, limits)
+		Expect(names(evictable)).To(Equal([]string{"node1/b.img", "node1/c.img"}))
+	})
+
+	It("should evict least recently used images exceeding the size", func() {
+		limits := func(string) imagecache.Limits { return imagecache.Limits{MaxImages: -1, MaxSize: 4} }
+		evictable := imagecache.Evictable(images, map[string]bool{"b.img": true}, limits)
+		Expect(names(evictable)).To(Equal([]string{"node1/a.img", "node1/c.img"}))
+	})
+
+	It("should never evict referenced images", func() {
+		limits := func(string) imagecache.Limits { return imagecache.Limits{MaxImages: 0, MaxSize: 1} }
+		evictable := imagecache.Evictable(images, map[string]bool{"a.img": true, "b.img": true}, limits)
+		Expect(names(evictable)).To(Equal([]string{"node1/c.img", "node1/d.img"}))
+	})
+
+	It("should apply the limits per node", func() {
+		limits := func(node string) imagecache.Limits {
+			if node == "node2" {
+				return imagecache.Limits{MaxImages: 0}
+			}
+			return imagecache.Limits{MaxImages: -1}
+		}
+		Expect(names(imagecache.Evictable(images, nil, limits))).To(Equal([]string{"node2/a.img"}))
+	})
+})
+
+var _ = Describe("LastUsed", Label("unit", "imagecache"), func() {
+	now := time.Unix(1700000000, 0)
+	// import images keep the time they were downloaded
+	images := []imagecache.Image{
+		{Node: "node1", Name: "old.qcow2", VolumeID: "local:import/old.qcow2", Size: 1, ModTime: now.Add(-3 * time.Hour)},
+		{Node: "node1", Name: "new.qcow2", VolumeID: "local:import/new.qcow2", Size: 1, ModTime: now.Add(-1 * time.Hour)},
+		{Node: "node1", Name: "raw.img", Size: 1, ModTime: now.Add(-2 * time.Hour)},
+	}
+
+	It("should record referenced import images as used now", func() {
+		lastUsed := imagecache.UpdateLastUsed(nil, images, map[string]bool{"old.qcow2": true, "raw.img": true}, now, true)
+		Expect(lastUsed).To(Equal(map[string]metav1.Time{"local:import/old.qcow2": metav1.NewTime(now)}))
+	})
+
+	It("should keep last-use times of unreferenced images and drop the ones of images not listed", func() {
+		lastUsed := map[string]metav1.Time{
+			"local:import/old.qcow2":  metav1.NewTime(now.Add(-time.Minute)),
+			"local:import/gone.qcow2": metav1.NewTime(now.Add(-time.Minute)),
+		}
+		Expect(imagecache.UpdateLastUsed(lastUsed, images, nil, now, true)).To(Equal(map[string]metav1.Time{
+			"local:import/old.qcow2": metav1.NewTime(now.Add(-time.Minute)),
+		}))
+		Expect(imagecache.UpdateLastUsed(lastUsed, images, nil, now, false)).To(Equal(lastUsed))
+	})
+
+	It("should keep the most recently used import image rather than the most recently downloaded one", func() {
+		lastUsed := imagecache.UpdateLastUsed(nil, images, map[string]bool{"old.qcow2": true}, now, true)
+		// old.qcow2 is not referenced any more
+		limits := func(string) imagecache.Limits { return imagecache.Limits{MaxImages: 1} }
+		evictable := imagecache.Evictable(imagecache.WithLastUsed(images, lastUsed), nil, limits)
+		names := []string{}
+		for _, image := range evictable {
+			names = append(names, image.Name)
+		}
+		Expect(names).To(Equal([]string{"new.qcow2", "raw.img"}))
+	})
+})
+
+var _ = Describe("Downloaded", Label("unit", "imagecache"), func() {
+	now := time.Unix(1700000000, 0)
+	checksum := "5f2b0cd5c1c5c3d1e8b8f9b2a2c8e1f0a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	images := []imagecache.Image{
+		{Node: "node1", Name: checksum + ".debian-12.qcow2", VolumeID: "local:import/" + checksum + ".debian-12.qcow2", Size: 1, ModTime: now.Add(-5 * time.Hour)},
+		{Node: "node1", Name: "ubuntu-22.04.img.qcow2", VolumeID: "local:import/ubuntu-22.04.img.qcow2", Size: 1, ModTime: now.Add(-4 * time.Hour)},
+		// uploaded by users
+		{Node: "node1", Name: "appliance.ova", VolumeID: "local:import/appliance.ova", Size: 1, ModTime: now.Add(-6 * time.Hour)},
+		{Node: "node1", Name: "windows.qcow2", VolumeID: "local:import/windows.qcow2", Size: 1, ModTime: now.Add(-7 * time.Hour)},
+		{Node: "node1", Name: "raw.img", Size: 1, ModTime: now.Add(-3 * time.Hour)},
+	}
+	lastUsed := map[string]metav1.Time{"local:import/ubuntu-22.04.img.qcow2": metav1.NewTime(now.Add(-time.Hour))}
+
+	It("should return import images with checksum or found referenced and images in DirPath", func() {
+		names := []string{}
+		for _, image := range imagecache.Downloaded(images, lastUsed) {
+			names = append(names, image.Name)
+		}
+		Expect(names).To(Equal([]string{checksum + ".debian-12.qcow2", "ubuntu-22.04.img.qcow2", "raw.img"}))
+	})
+
+	It("should never evict import volumes uploaded by users", func() {
+		limits := func(string) imagecache.Limits { return imagecache.Limits{MaxImages: 0} }
+		evictable := imagecache.Evictable(imagecache.WithLastUsed(imagecache.Downloaded(images, lastUsed), lastUsed), nil, limits)
+		names := []string{}
+		for _, image := range evictable {
+			names = append(names, image.Name)
+		}
+		Expect(names).To(ConsistOf(checksum+".debian-12.qcow2", "ubuntu-22.04.img.qcow2", "raw.img"))
+	})
+})
+
+var _ = Describe("ParseFindOutput", Label("unit", "imagecache"), func() {
+	It("should parse images and ignore other lines", func() {
+		out := "root@node1:~# find /etc/cappx/images\n1700000000.1234567890 1024 abc.ubuntu 22.04.img\r\n\nfoo bar\n"
+		Expect(imagecache.ParseFindOutput("node1", out)).To(Equal([]imagecache.Image{
+			{Node: "node1", Name: "abc.ubuntu 22.04.img", Size: 1024, ModTime: time.Unix(1700000000, 0)},
+		}))
+	})
+})
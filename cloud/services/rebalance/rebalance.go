@@ -0,0 +1,77 @@
+package rebalance
+
+import (
+	"sort"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+)
+
+// Candidate is a qemu which should be moved to another node
+type Candidate struct {
+	// name of the ProxmoxMachine
+	Name       string
+	VMID       int
+	Node       string
+	TargetNode string
+	// score improvement (in percent) of target node against current node
+	Improvement float64
+}
+
+// return the node having the highest score if its score exceeds
+// the current node's score by more than threshold percent.
+// scores must be computed without the qemu on its current node (see framework.ContextWithMovingQEMU),
+// so the current node is not in scores only if it is infeasible for the qemu itself
+// (e.g. under maintenance). then any other node is better.
+func Evaluate(currentNode string, scores map[string]framework.NodeScore, threshold int) (string, float64, bool) {
+	best := framework.NodeScore{Score: -1}
+	for _, score := range scores {
+		if score.Name == currentNode {
+			continue
+		}
+		if score.Score > best.Score || (score.Score == best.Score && score.Name < best.Name) {
+			best = score
+		}
+	}
+	if best.Name == "" {
+		return "", 0, false
+	}
+	current, ok := scores[currentNode]
+	if !ok {
+		return best.Name, 100, true
+	}
+	if current.Score <= 0 {
+		if best.Score > 0 {
+			return best.Name, 100, true
+		}
+		return "", 0, false
+	}
+	improvement := float64(best.Score-current.Score) / float64(current.Score) * 100
+	if improvement <= float64(threshold) {
+		return "", 0, false
+	}
+	return best.Name, improvement, true
+}
+
+// return at most budget candidates, the ones having bigger improvement first.
+// at most one candidate is moved to the same target node at once
+// so that all the qemus don't rush into one node
+func SelectCandidates(candidates []Candidate, budget int) []Candidate {
+	sorted := make([]Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Improvement > sorted[j].Improvement
+	})
+	selected := []Candidate{}
+	targets := map[string]bool{}
+	for _, c := range sorted {
+		if len(selected) >= budget {
+			break
+		}
+		if targets[c.TargetNode] {
+			continue
+		}
+		targets[c.TargetNode] = true
+		selected = append(selected, c)
+	}
+	return selected
+}
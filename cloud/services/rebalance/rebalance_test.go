@@ -0,0 +1,138 @@
+package rebalance_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/allocation"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/plugins/overcommit"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/rebalance"
+)
+
+func TestRebalance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rebalance Suite")
+}
+
+func scoreMap(scores ...framework.NodeScore) map[string]framework.NodeScore {
+	m := map[string]framework.NodeScore{}
+	for _, s := range scores {
+		m[s.Name] = s
+	}
+	return m
+}
+
+var _ = Describe("Evaluate", Label("unit", "rebalance"), func() {
+	Context("best node exceeds threshold", func() {
+		It("should return best node", func() {
+			scores := scoreMap(framework.NodeScore{Name: "a", Score: 10}, framework.NodeScore{Name: "b", Score: 15}, framework.NodeScore{Name: "c", Score: 13})
+			target, improvement, ok := rebalance.Evaluate("a", scores, 20)
+			Expect(ok).To(BeTrue())
+			Expect(target).To(Equal("b"))
+			Expect(improvement).To(BeNumerically("==", 50))
+		})
+	})
+
+	Context("best node does not exceed threshold", func() {
+		It("should not return node", func() {
+			scores := scoreMap(framework.NodeScore{Name: "a", Score: 10}, framework.NodeScore{Name: "b", Score: 11})
+			_, _, ok := rebalance.Evaluate("a", scores, 20)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("current node is the best", func() {
+		It("should not return node", func() {
+			scores := scoreMap(framework.NodeScore{Name: "a", Score: 100}, framework.NodeScore{Name: "b", Score: 11})
+			_, _, ok := rebalance.Evaluate("a", scores, 0)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("current node is filtered out", func() {
+		It("should return best node", func() {
+			scores := scoreMap(framework.NodeScore{Name: "b", Score: 1})
+			target, _, ok := rebalance.Evaluate("a", scores, 20)
+			Expect(ok).To(BeTrue())
+			Expect(target).To(Equal("b"))
+		})
+	})
+
+	Context("current node is near full with the qemu itself", func() {
+		const gib = 1024 * 1024 * 1024
+		ctx := context.Background()
+		// the qemu of vmid 100 uses 6 of 8 cpus of node a
+		config := api.VirtualMachineCreateOptions{Cores: 6, Memory: 2048}
+		nodeInfos := []*framework.NodeInfo{
+			framework.NewNodeInfo(&api.Node{Node: "a", Status: "online", MaxCpu: 8, MaxMem: 16 * gib}, []*api.VirtualMachine{
+				{VMID: 100, Cpus: 6, MaxMem: 2 * gib, Status: api.ProcessStatusRunning},
+			}, framework.NodeConfig{}, ""),
+			framework.NewNodeInfo(&api.Node{Node: "b", Status: "online", MaxCpu: 8, MaxMem: 16 * gib}, nil, framework.NodeConfig{}, ""),
+		}
+
+		// filter and score nodes like the scheduler does
+		scoreNodes := func(nodeInfos []*framework.NodeInfo) map[string]framework.NodeScore {
+			filter := overcommit.NewCPUOvercommit(map[string]interface{}{overcommit.RatioConfigKey: 1})
+			score := &allocation.LeastAllocated{}
+			scores := map[string]framework.NodeScore{}
+			for _, nodeInfo := range nodeInfos {
+				state := framework.NewCycleState()
+				if !filter.Filter(ctx, &state, config, nodeInfo).IsSuccess() {
+					continue
+				}
+				s, status := score.Score(ctx, &state, config, nodeInfo)
+				Expect(status.IsSuccess()).To(BeTrue())
+				scores[nodeInfo.Node().Node] = framework.NodeScore{Name: nodeInfo.Node().Node, Score: s}
+			}
+			return scores
+		}
+
+		It("would be filtered out if the qemu were counted twice", func() {
+			Expect(scoreNodes(nodeInfos)).NotTo(HaveKey("a"))
+		})
+
+		It("should not return node once the qemu is excluded from its current node", func() {
+			excluded := []*framework.NodeInfo{}
+			for _, nodeInfo := range nodeInfos {
+				excluded = append(excluded, nodeInfo.WithoutQEMU(100))
+			}
+			scores := scoreNodes(excluded)
+			Expect(scores).To(HaveKey("a"))
+			_, _, ok := rebalance.Evaluate("a", scores, 0)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("no other nodes", func() {
+		It("should not return node", func() {
+			scores := scoreMap(framework.NodeScore{Name: "a", Score: 1})
+			_, _, ok := rebalance.Evaluate("a", scores, 20)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("SelectCandidates", Label("unit", "rebalance"), func() {
+	candidates := []rebalance.Candidate{
+		{Name: "m1", TargetNode: "b", Improvement: 30},
+		{Name: "m2", TargetNode: "b", Improvement: 80},
+		{Name: "m3", TargetNode: "c", Improvement: 50},
+		{Name: "m4", TargetNode: "d", Improvement: 40},
+	}
+
+	It("should select candidates within budget and one per target node", func() {
+		selected := rebalance.SelectCandidates(candidates, 2)
+		Expect(selected).To(HaveLen(2))
+		Expect(selected[0].Name).To(Equal("m2"))
+		Expect(selected[1].Name).To(Equal("m3"))
+	})
+
+	It("should select nothing with zero budget", func() {
+		Expect(rebalance.SelectCandidates(candidates, 0)).To(BeEmpty())
+	})
+})
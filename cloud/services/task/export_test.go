@@ -0,0 +1,5 @@
+package task
+
+func Tail(lines []string, n int) []string {
+	return tail(lines, n)
+}
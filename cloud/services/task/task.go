@@ -0,0 +1,107 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	"github.com/k8s-proxmox/proxmox-go/rest"
+)
+
+const (
+	StatusRunning = "running"
+	ExitStatusOK  = "OK"
+
+	// number of task log lines fetched at once
+	maxLogLines = 1000
+)
+
+// ErrInvalidUPID is returned when the task cannot be looked up by the UPID,
+// e.g. the UPID is malformed or its node no longer exists
+var ErrInvalidUPID = errors.New("invalid UPID")
+
+type taskStatus struct {
+	Status     string `json:"status"`
+	ExitStatus string `json:"exitstatus,omitempty"`
+}
+
+type logLine struct {
+	N int    `json:"n"`
+	T string `json:"t"`
+}
+
+// return true if the task is finished without blocking.
+// error is returned if the task is finished with non-OK exit status
+func IsFinished(ctx context.Context, client *proxmox.Service, upid string) (bool, error) {
+	node, err := NodeFromUPID(upid)
+	if err != nil {
+		return false, err
+	}
+	var status taskStatus
+	if err := client.RESTClient().Get(ctx, fmt.Sprintf("/nodes/%s/tasks/%s/status", node, upid), &status); err != nil {
+		// proxmox fails to proxy the request to unknown node rather than returning not found
+		if !rest.IsNotFound(err) {
+			if _, nodeErr := client.GetNode(ctx, node); rest.IsNotFound(nodeErr) {
+				return false, fmt.Errorf("%w: node %s not found", ErrInvalidUPID, node)
+			}
+		}
+		return false, err
+	}
+	if status.Status == StatusRunning {
+		return false, nil
+	}
+	if status.ExitStatus != ExitStatusOK {
+		return true, fmt.Errorf("task %s failed: %s", upid, status.ExitStatus)
+	}
+	return true, nil
+}
+
+// IsNotFound returns true if the task is not found or cannot be looked up by its UPID.
+// such task is never finished, so the caller should check the actual state instead
+func IsNotFound(err error) bool {
+	return rest.IsNotFound(err) || errors.Is(err, ErrInvalidUPID)
+}
+
+// return the last n lines of the task log.
+// log api returns the lines from start and the rest client drops the total number
+// of lines, so the log is paged through from the beginning keeping the last n lines
+func LogTail(ctx context.Context, client *proxmox.Service, upid string, n int) ([]string, error) {
+	node, err := NodeFromUPID(upid)
+	if err != nil {
+		return nil, err
+	}
+	texts := []string{}
+	for start := 0; ; start += maxLogLines {
+		var lines []logLine
+		if err := client.RESTClient().Get(ctx, fmt.Sprintf("/nodes/%s/tasks/%s/log?start=%d&limit=%d", node, upid, start, maxLogLines), &lines); err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			if line.T != "" {
+				texts = append(texts, line.T)
+			}
+		}
+		texts = tail(texts, n)
+		if len(lines) < maxLogLines {
+			return texts, nil
+		}
+	}
+}
+
+func tail(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// UPID format: UPID:{node}:{pid}:{pstart}:{starttime}:{type}:{id}:{user}:
+func NodeFromUPID(upid string) (string, error) {
+	fields := strings.Split(upid, ":")
+	if len(fields) < 3 || fields[0] != "UPID" || fields[1] == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidUPID, upid)
+	}
+	return fields[1], nil
+}
@@ -0,0 +1,127 @@
+package task_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/k8s-proxmox/proxmox-go/proxmox"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/task"
+)
+
+func TestTask(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Task Suite")
+}
+
+var _ = Describe("NodeFromUPID", Label("unit", "task"), func() {
+	It("should return node name", func() {
+		node, err := task.NodeFromUPID("UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmigrate:100:root@pam:")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node).To(Equal("node1"))
+	})
+
+	It("should error for invalid UPID", func() {
+		_, err := task.NodeFromUPID("foo")
+		Expect(err).To(MatchError(task.ErrInvalidUPID))
+	})
+})
+
+var _ = Describe("IsFinished", Label("unit", "task"), func() {
+	var server *httptest.Server
+	var client *proxmox.Service
+
+	// node1 is the only node. proxmox fails to proxy requests to unknown nodes
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api2/json/nodes":
+				fmt.Fprint(w, `{"data":[{"node":"node1","status":"online"}]}`)
+			case "/api2/json/nodes/node1/tasks/UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmstop:100:root@pam:/status":
+				fmt.Fprint(w, `{"data":{"status":"stopped","exitstatus":"OK"}}`)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		var err error
+		client, err = proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should return true for finished task", func() {
+		finished, err := task.IsFinished(context.Background(), client, "UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmstop:100:root@pam:")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(finished).To(BeTrue())
+	})
+
+	It("should regard unparseable UPID as not found", func() {
+		finished, err := task.IsFinished(context.Background(), client, "foo")
+		Expect(finished).To(BeFalse())
+		Expect(task.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should regard UPID of unknown node as not found", func() {
+		finished, err := task.IsFinished(context.Background(), client, "UPID:renamed:0000D0A2:01B5F0A3:65A4B3C2:qmstop:100:root@pam:")
+		Expect(finished).To(BeFalse())
+		Expect(task.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should return other errors as they are", func() {
+		finished, err := task.IsFinished(context.Background(), client, "UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmstart:100:root@pam:")
+		Expect(finished).To(BeFalse())
+		Expect(err).To(HaveOccurred())
+		Expect(task.IsNotFound(err)).To(BeFalse())
+	})
+})
+
+var _ = Describe("tail", Label("unit", "task"), func() {
+	It("should return last n lines", func() {
+		Expect(task.Tail([]string{"a", "b", "c"}, 2)).To(Equal([]string{"b", "c"}))
+	})
+
+	It("should return all lines if less than n", func() {
+		Expect(task.Tail([]string{"a"}, 2)).To(Equal([]string{"a"}))
+	})
+})
+
+var _ = Describe("LogTail", Label("unit", "task"), func() {
+	const upid = "UPID:node1:0000D0A2:01B5F0A3:65A4B3C2:qmcreate:100:root@pam:"
+	var server *httptest.Server
+
+	// serve the log of 2500 lines from start like proxmox does
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const total = 2500
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			lines := []map[string]interface{}{}
+			for i := start; i < total && i < start+limit; i++ {
+				lines = append(lines, map[string]interface{}{"n": i + 1, "t": fmt.Sprintf("line %d", i+1)})
+			}
+			Expect(json.NewEncoder(w).Encode(map[string]interface{}{"data": lines, "total": total})).To(Succeed())
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should return the last n lines of the log", func() {
+		client, err := proxmox.NewServiceWithAPIToken(server.URL+"/api2/json", "root@pam!test", "secret", true)
+		Expect(err).NotTo(HaveOccurred())
+		lines, err := task.LogTail(context.Background(), client, upid, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(Equal([]string{"line 2498", "line 2499", "line 2500"}))
+	})
+})
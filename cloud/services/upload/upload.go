@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/k8s-proxmox/proxmox-go/rest"
+)
+
+// Client uploads files to proxmox storages via the REST API,
+// which proxmox-go doesn't support since it requires multipart form
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewClient returns Client authenticated with API token.
+// the certificate of the endpoint is verified by the base transport
+func NewClient(endpoint, tokenID, secret string, base http.RoundTripper) *Client {
+	return &Client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{
+			Transport: &rest.Transport{Base: base, AuthProvider: rest.NewTokenProvider(tokenID, secret)},
+		},
+	}
+}
+
+// Upload uploads the file to the storage on the node
+// and returns UPID of the task copying the file into the storage
+func (c *Client) Upload(ctx context.Context, node, storage, content, filename string, data []byte) (string, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	if err := form.WriteField("content", content); err != nil {
+		return "", err
+	}
+	file, err := form.CreateFormFile("filename", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		return "", err
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/nodes/%s/storage/%s/upload", c.endpoint, node, storage)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+
+	buf, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return "", err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return "", rest.NewError(rsp.StatusCode, rsp.Status, buf)
+	}
+	var res struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(buf, &res); err != nil {
+		return "", err
+	}
+	return res.Data, nil
+}
@@ -0,0 +1,54 @@
+package upload_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
+)
+
+func TestUpload(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Upload Suite")
+}
+
+var _ = Describe("Upload", Label("unit", "upload"), func() {
+	It("should post multipart form with api token", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/api2/json/nodes/node1/storage/local-dir-foo/upload"))
+			Expect(r.Header.Get("Authorization")).To(Equal("PVEAPIToken=root@pam!cappx=secret"))
+			Expect(r.FormValue("content")).To(Equal("iso"))
+			file, header, err := r.FormFile("filename")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Filename).To(Equal("foo-cloudinit.iso"))
+			data, err := io.ReadAll(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("bar"))
+			_, _ = w.Write([]byte(`{"data":"UPID:node1:00001234:00005678:65A4B3C2:imgcopy::root@pam!cappx:"}`))
+		}))
+		defer server.Close()
+
+		client := upload.NewClient(server.URL+"/api2/json/", "root@pam!cappx", "secret", server.Client().Transport)
+		upid, err := client.Upload(context.Background(), "node1", "local-dir-foo", "iso", "foo-cloudinit.iso", []byte("bar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upid).To(Equal("UPID:node1:00001234:00005678:65A4B3C2:imgcopy::root@pam!cappx:"))
+	})
+
+	It("should return error on non 2xx response", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Permission check failed", http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		client := upload.NewClient(server.URL+"/api2/json", "root@pam!cappx", "secret", server.Client().Transport)
+		_, err := client.Upload(context.Background(), "node1", "local-dir-foo", "iso", "foo-cloudinit.iso", []byte("bar"))
+		Expect(err).To(MatchError(ContainSubstring("403")))
+	})
+})
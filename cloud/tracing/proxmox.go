@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewProxmoxTransport returns the transport tracing proxmox API requests sent through base
+// as children of the span in the request context. the spans have the method, url and
+// status code of the requests. requests without span in the context e.g. made outside of
+// reconciliations are not traced
+func NewProxmoxTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return trace.SpanContextFromContext(r.Context()).IsValid()
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, _ *http.Request) string {
+			return "proxmox.Request"
+		}),
+	)
+}
@@ -0,0 +1,136 @@
+// Package tracing exports OpenTelemetry traces of reconciliations, scheduling
+// and proxmox API calls via OTLP. tracing is disabled unless the endpoint is given,
+// in which case spans are not recorded at all.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	tracerName  = "github.com/k8s-proxmox/cluster-api-provider-proxmox"
+	serviceName = "cluster-api-provider-proxmox"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Options is how traces are exported
+type Options struct {
+	// host:port of the OTLP receiver. tracing is disabled if empty
+	Endpoint string
+
+	// OTLP protocol, grpc or http
+	Protocol string
+
+	// disable TLS of the OTLP exporter
+	Insecure bool
+
+	// fraction of reconciliations traced
+	SamplingRate float64
+}
+
+// AddFlags adds the tracing flags to the flag set
+func AddFlags(fs *pflag.FlagSet, options *Options) {
+	fs.StringVar(&options.Endpoint, "tracing-endpoint", "",
+		"The host:port of the OTLP receiver traces are exported to. Tracing is disabled if empty.")
+	fs.StringVar(&options.Protocol, "tracing-protocol", ProtocolGRPC,
+		"The OTLP protocol used to export traces, grpc or http.")
+	fs.BoolVar(&options.Insecure, "tracing-insecure", false,
+		"Export traces without TLS.")
+	fs.Float64Var(&options.SamplingRate, "tracing-sampling-rate", 1,
+		"The fraction of reconciliations traced, from 0 to 1.")
+}
+
+// Setup installs the global tracer provider exporting spans as configured.
+// the returned function flushes spans and stops the exporter
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	if options.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if options.SamplingRate < 0 || options.SamplingRate > 1 {
+		return nil, fmt.Errorf("tracing sampling rate must be between 0 and 1: %v", options.SamplingRate)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Protocol {
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing protocol %q", options.Protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SamplingRate))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts the span as a child of the span in the context
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartReconcile starts the root span of the reconciliation of the object.
+// the trace id is added to the logger so that log lines can be correlated with the trace,
+// and proxmox API requests made with the returned context are traced as its children
+// by the transport of NewProxmoxTransport
+func StartReconcile(ctx context.Context, kind string, key types.NamespacedName) (context.Context, trace.Span) {
+	ctx, span := Start(ctx, kind+".Reconcile",
+		attribute.String("k8s.namespace.name", key.Namespace),
+		attribute.String("cappx.object.name", key.Name),
+	)
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	return log.IntoContext(ctx, log.FromContext(ctx).WithValues("traceID", span.SpanContext().TraceID().String())), span
+}
+
+// End records the error on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,103 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+var _ = Describe("Setup", Label("unit", "tracing"), func() {
+	It("should disable tracing without endpoint", func() {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shutdown(context.Background())).To(Succeed())
+	})
+
+	It("should reject invalid options", func() {
+		_, err := tracing.Setup(context.Background(), tracing.Options{Endpoint: "localhost:4317", Protocol: "udp", SamplingRate: 1})
+		Expect(err).To(HaveOccurred())
+		_, err = tracing.Setup(context.Background(), tracing.Options{Endpoint: "localhost:4317", Protocol: tracing.ProtocolGRPC, SamplingRate: 2})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("StartReconcile", Label("unit", "tracing"), func() {
+	var recorder *tracetest.SpanRecorder
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		otel.SetTracerProvider(provider)
+		DeferCleanup(func() {
+			Expect(provider.Shutdown(context.Background())).To(Succeed())
+		})
+	})
+
+	It("should record the reconciliation and its children", func() {
+		ctx, span := tracing.StartReconcile(context.Background(), "ProxmoxMachine", types.NamespacedName{Namespace: "default", Name: "foo"})
+		_, child := tracing.Start(ctx, "scheduler.Filter")
+		tracing.End(child, nil)
+		tracing.End(span, errors.New("boom"))
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal("scheduler.Filter"))
+		Expect(spans[0].Parent().SpanID()).To(Equal(span.SpanContext().SpanID()))
+		Expect(spans[1].Name()).To(Equal("ProxmoxMachine.Reconcile"))
+		Expect(spans[1].Status().Code).To(Equal(codes.Error))
+	})
+
+	It("should trace proxmox API requests with the method and status", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		client := &http.Client{Transport: tracing.NewProxmoxTransport(http.DefaultTransport)}
+		get := func(ctx context.Context) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api2/json/version", nil)
+			Expect(err).NotTo(HaveOccurred())
+			rsp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+		}
+
+		// requests made outside of reconciliations are not traced
+		get(context.Background())
+		Expect(recorder.Ended()).To(BeEmpty())
+
+		ctx, span := tracing.StartReconcile(context.Background(), "ProxmoxCluster", types.NamespacedName{Namespace: "default", Name: "foo"})
+		get(ctx)
+		tracing.End(span, nil)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal("proxmox.Request"))
+		Expect(spans[0].Parent().SpanID()).To(Equal(span.SpanContext().SpanID()))
+		Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, attr := range spans[0].Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		Expect(attrs).To(HaveKeyWithValue(attribute.Key("http.method"), attribute.StringValue(http.MethodGet)))
+		Expect(attrs).To(HaveKeyWithValue(attribute.Key("http.url"), attribute.StringValue(server.URL+"/api2/json/version")))
+		Expect(attrs).To(HaveKeyWithValue(attribute.Key("http.status_code"), attribute.IntValue(http.StatusUnauthorized)))
+	})
+})
@@ -0,0 +1,181 @@
+// Package trust verifies TLS certificates of proxmox endpoints.
+//
+// each endpoint gets its own transport whose TLS config verifies the certificate
+// against the CA bundle and fingerprints given for the endpoint, or the system roots
+// if neither is given. the certificate is always verified for the host of the endpoint,
+// so a CA given for one endpoint is never trusted for another one.
+package trust
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// map[string]*http.Transport keyed by endpoint host, config digest and insecure,
+// so that connections are reused across reconciliations
+var transports sync.Map
+
+// Config is how the certificate of an endpoint is verified
+type Config struct {
+	// RootCAs verifies the certificate chain. system roots are used if nil
+	RootCAs *x509.CertPool
+
+	// Fingerprints pins SHA-256 fingerprints of the leaf certificate.
+	// the chain is verified only if RootCAs is given when fingerprints are pinned
+	Fingerprints [][]byte
+
+	// digest of the CA bundle and fingerprints which the config was made from
+	digest string
+}
+
+// NewConfig returns Config from PEM encoded CA certificates and
+// SHA-256 fingerprints e.g. "AB:CD:...". both can be empty
+func NewConfig(caBundle []byte, fingerprints []string) (*Config, error) {
+	h := sha256.New()
+	h.Write(caBundle)
+	config := &Config{}
+	if len(bytes.TrimSpace(caBundle)) > 0 {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("no valid PEM certificate found in CA bundle")
+		}
+	}
+	for _, fp := range fingerprints {
+		b, err := ParseFingerprint(fp)
+		if err != nil {
+			return nil, err
+		}
+		config.Fingerprints = append(config.Fingerprints, b)
+		h.Write(b)
+	}
+	config.digest = hex.EncodeToString(h.Sum(nil))
+	return config, nil
+}
+
+// ParseFingerprint parses hex encoded SHA-256 fingerprint.
+// bytes can be separated by colons as shown in proxmox web UI
+func ParseFingerprint(fingerprint string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	if err != nil || len(b) != sha256.Size {
+		return nil, errors.Errorf("invalid SHA-256 fingerprint %q", fingerprint)
+	}
+	return b, nil
+}
+
+// Transport returns the transport verifying the certificate of the endpoint with the config.
+// the certificate is not verified if insecure is true, and verified against
+// the system roots if config is nil. transports are shared by endpoints having the same host and config
+func Transport(endpoint string, config *Config, insecure bool) (*http.Transport, error) {
+	host, err := hostOf(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var digest string
+	if config != nil {
+		digest = config.digest
+	}
+	key := fmt.Sprintf("%s\n%s\n%t", host, digest, insecure)
+	if v, ok := transports.Load(key); ok {
+		return v.(*http.Transport), nil
+	}
+	actual, _ := transports.LoadOrStore(key, newTransport(host, config, insecure))
+	return actual.(*http.Transport), nil
+}
+
+// newTransport returns a clone of http.DefaultTransport with the TLS config for the host.
+// it is *http.Transport since proxmox-go reads its TLS config for websockets
+func newTransport(host string, config *Config, insecure bool) *http.Transport {
+	var transport *http.Transport
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = t.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	switch {
+	case insecure:
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+	case config != nil:
+		transport.TLSClientConfig = &tls.Config{
+			// the verification is done by VerifyConnection instead, which checks
+			// the certificate for the host of the endpoint even if it is an IP address
+			InsecureSkipVerify: true, // #nosec G402
+			VerifyConnection: func(state tls.ConnectionState) error {
+				return config.verify(state, host)
+			},
+		}
+	default:
+		transport.TLSClientConfig = &tls.Config{ServerName: host}
+	}
+	return transport
+}
+
+func (c *Config) verify(state tls.ConnectionState, host string) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no certificate presented by server")
+	}
+	leaf := state.PeerCertificates[0]
+	if len(c.Fingerprints) > 0 {
+		if !c.pinned(leaf) {
+			return errors.Errorf("certificate fingerprint %s of %s is not pinned", Fingerprint(leaf), host)
+		}
+		if c.RootCAs == nil {
+			return nil
+		}
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         c.RootCAs,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(opts)
+	return err
+}
+
+func (c *Config) pinned(cert *x509.Certificate) bool {
+	sum := sha256.Sum256(cert.Raw)
+	for _, fp := range c.Fingerprints {
+		if bytes.Equal(fp, sum[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint returns colon separated SHA-256 fingerprint of the certificate
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	s := strings.ToUpper(hex.EncodeToString(sum[:]))
+	pairs := make([]string, 0, len(sum))
+	for i := 0; i < len(s); i += 2 {
+		pairs = append(pairs, s[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+// hostOf returns the host, which is the server name of TLS connections, of the endpoint
+func hostOf(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Errorf("failed to parse endpoint %q: %v", endpoint, err)
+	}
+	if u.Hostname() == "" {
+		return "", errors.Errorf("no host in endpoint %q", endpoint)
+	}
+	return u.Hostname(), nil
+}
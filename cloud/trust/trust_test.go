@@ -0,0 +1,136 @@
+package trust_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/trust"
+)
+
+func TestTrust(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Trust Suite")
+}
+
+var _ = Describe("ParseFingerprint", Label("unit", "trust"), func() {
+	fp := "E3:B0:C4:42:98:FC:1C:14:9A:FB:F4:C8:99:6F:B9:24:27:AE:41:E4:64:9B:93:4C:A4:95:99:1B:78:52:B8:55"
+
+	It("should parse colon separated fingerprint", func() {
+		b, err := trust.ParseFingerprint(fp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b).To(HaveLen(32))
+	})
+
+	It("should parse lower case fingerprint without colons", func() {
+		b, err := trust.ParseFingerprint("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b).To(HaveLen(32))
+	})
+
+	It("should reject SHA-1 fingerprint", func() {
+		_, err := trust.ParseFingerprint("DA:39:A3:EE:5E:6B:4B:0D:32:55:BF:EF:95:60:18:90:AF:D8:07:09")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject non hex fingerprint", func() {
+		_, err := trust.ParseFingerprint("foo")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("NewConfig", Label("unit", "trust"), func() {
+	It("should reject CA bundle without certificates", func() {
+		_, err := trust.NewConfig([]byte("not a certificate"), nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should accept empty CA bundle", func() {
+		config, err := trust.NewConfig(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.RootCAs).To(BeNil())
+	})
+})
+
+var _ = Describe("Transport", Label("unit", "trust"), func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		DeferCleanup(server.Close)
+	})
+
+	// get requests the test server with the transport of the endpoint
+	get := func(endpoint string, config *trust.Config) error {
+		transport, err := trust.Transport(endpoint, config, false)
+		if err != nil {
+			return err
+		}
+		rsp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			rsp.Body.Close()
+		}
+		return err
+	}
+	caConfig := func() *trust.Config {
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		config, err := trust.NewConfig(caBundle, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return config
+	}
+
+	It("should reject certificate not signed by system roots", func() {
+		config, err := trust.NewConfig(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(server.URL, config)).To(HaveOccurred())
+	})
+
+	It("should accept certificate signed by the CA of the endpoint", func() {
+		Expect(get(server.URL, caConfig())).To(Succeed())
+	})
+
+	It("should reject certificate which is not valid for the host of the endpoint", func() {
+		// e.g. a certificate for another IP address signed by the CA of another cluster
+		Expect(get("https://192.0.2.10:8006/api2/json", caConfig())).To(MatchError(ContainSubstring("192.0.2.10")))
+	})
+
+	It("should accept pinned certificate", func() {
+		config, err := trust.NewConfig(nil, []string{trust.Fingerprint(server.Certificate())})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(server.URL, config)).To(Succeed())
+	})
+
+	It("should reject certificate which is not pinned", func() {
+		config, err := trust.NewConfig(nil, []string{"E3:B0:C4:42:98:FC:1C:14:9A:FB:F4:C8:99:6F:B9:24:27:AE:41:E4:64:9B:93:4C:A4:95:99:1B:78:52:B8:55"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(server.URL, config)).To(MatchError(ContainSubstring("is not pinned")))
+	})
+
+	It("should skip verification if insecure", func() {
+		transport, err := trust.Transport(server.URL, nil, true)
+		Expect(err).NotTo(HaveOccurred())
+		rsp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+	})
+
+	It("should not modify http.DefaultTransport", func() {
+		defaultTransport := http.DefaultTransport
+		_, err := trust.Transport(server.URL, caConfig(), false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(http.DefaultTransport).To(BeIdenticalTo(defaultTransport))
+	})
+
+	It("should share the transport of the same host and config", func() {
+		config := caConfig()
+		a, err := trust.Transport(server.URL+"/api2/json", config, false)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := trust.Transport(server.URL, config, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a).To(BeIdenticalTo(b))
+	})
+})
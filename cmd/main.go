@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -34,11 +36,16 @@ import (
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/flags"
+	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrastructurev1beta1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrastructurev1beta2 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
 	controller "github.com/k8s-proxmox/cluster-api-provider-proxmox/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -52,13 +59,19 @@ var (
 	enableLeaderElection bool
 	probeAddr            string
 	pluginConfig         string
+	decisionAnnotation   bool
+	webhookPort          int
+	webhookCertDir       string
+	identityNamespace    string
 	logOptions           = logs.NewOptions()
+	tracingOptions       = tracing.Options{}
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(infrastructurev1beta1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1beta2.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
@@ -75,12 +88,27 @@ func main() {
 	// }
 	pflag.Parse()
 
-	_, metricsOptions, err := flags.GetManagerOptions(managerOptions)
+	tlsOptions, metricsOptions, err := flags.GetManagerOptions(managerOptions)
 	if err != nil {
 		setupLog.Error(err, "Unable to start manager: invalid flags")
 	}
 
 	ctrl.SetLogger(klog.Background())
+	ctx := ctrl.SetupSignalHandler()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		// flush spans of the last reconciliations
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			setupLog.Error(err, "failed to shut down tracing")
+		}
+	}()
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -88,6 +116,11 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "36404136.cluster.x-k8s.io",
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+			TLSOpts: tlsOptions,
+		}),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -104,6 +137,9 @@ func main() {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+	// events recorded via cluster-api record package, e.g. scheduling decisions
+	// on ProxmoxMachine, are sent to the API server with the recorder of the manager
+	record.InitFromRecorder(mgr.GetEventRecorderFor("proxmox-controller"))
 	schedManager, err := scheduler.NewManager(
 		scheduler.SchedulerParams{
 			Logger:           klog.Background(),
@@ -114,11 +150,20 @@ func main() {
 		setupLog.Error(err, "failed to start qemu-scheudler manager")
 		os.Exit(1)
 	}
+	if err := mgr.Add(schedManager); err != nil {
+		setupLog.Error(err, "unable to set up plugin config reload of qemu-scheduler")
+		os.Exit(1)
+	}
+
+	if identityNamespace != "" {
+		scope.SetIdentitySecretNamespace(identityNamespace)
+	}
 
 	if err = (&controller.ProxmoxMachineReconciler{
-		Client:           mgr.GetClient(),
-		Scheme:           mgr.GetScheme(),
-		SchedulerManager: schedManager,
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		SchedulerManager:         schedManager,
+		RecordSchedulingDecision: decisionAnnotation,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ProxmoxMachine")
 		os.Exit(1)
@@ -130,6 +175,40 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ProxmoxCluster")
 		os.Exit(1)
 	}
+	if err = (&controller.RebalancerReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		SchedulerManager: schedManager,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Rebalancer")
+		os.Exit(1)
+	}
+	if err = (&controller.GarbageCollectorReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GarbageCollector")
+		os.Exit(1)
+	}
+	if err = (&controller.ImageCacheReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ImageCache")
+		os.Exit(1)
+	}
+	if err = (&infrastructurev1beta2.ProxmoxCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxCluster")
+		os.Exit(1)
+	}
+	if err = (&infrastructurev1beta2.ProxmoxMachine{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachine")
+		os.Exit(1)
+	}
+	if err = (&infrastructurev1beta2.ProxmoxMachineTemplate{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachineTemplate")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -142,7 +221,7 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -156,6 +235,14 @@ func InitFlags(fs *pflag.FlagSet) {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	fs.StringVar(&pluginConfig, "scheduler-plugin-config", "", "The config file path for qemu-scheduler plugins")
-
+	fs.BoolVar(&decisionAnnotation, "scheduler-decision-annotation", false,
+		"Persist the last scheduling decision of each ProxmoxMachine in its annotation.")
+	fs.IntVar(&webhookPort, "webhook-port", 9443, "Webhook Server port")
+	fs.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs/",
+		"Webhook cert dir, only used when webhook-port is specified.")
+	fs.StringVar(&identityNamespace, "identity-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of secrets referenced by ProxmoxClusterIdentities. Defaults to the namespace of the controller.")
+
+	tracing.AddFlags(fs, &tracingOptions)
 	flags.AddManagerOptions(fs, &managerOptions)
 }
@@ -0,0 +1,320 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	capiannotations "sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/gc"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/task"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+)
+
+// GarbageCollectorReconciler periodically looks for qemus and cloud-init snippets
+// of a ProxmoxCluster having garbage collection policy whose ProxmoxMachines no longer exist
+type GarbageCollectorReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch
+
+func (r *GarbageCollectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "GarbageCollector", req.NamespacedName)
+	defer func() { tracing.End(span, reterr) }()
+	log := log.FromContext(ctx)
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	if err := r.Get(ctx, req.NamespacedName, proxmoxCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	policy := proxmoxCluster.Spec.GarbageCollection
+	if policy == nil || !proxmoxCluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	interval := policy.Interval.Duration
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	if !proxmoxCluster.Status.Ready {
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	// collect at most once per interval unless qemus are being deleted
+	tasksInFlight := hasGarbageCollectionTasks(proxmoxCluster.Status.OrphanedResources)
+	if last := proxmoxCluster.Status.LastGarbageCollectionTime; last != nil && !tasksInFlight {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, proxmoxCluster.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		return ctrl.Result{}, nil
+	}
+	if capiannotations.IsPaused(cluster, proxmoxCluster) {
+		log.Info("ProxmoxCluster or linked Cluster is marked as paused. Won't collect garbage")
+		return ctrl.Result{}, nil
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:         r.Client,
+		Cluster:        cluster,
+		ProxmoxCluster: proxmoxCluster,
+	})
+	if err != nil {
+		return ctrl.Result{}, errors.Errorf("failed to create scope: %+v", err)
+	}
+	defer func() {
+		if err := clusterScope.Close(); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	if err := r.collect(ctx, clusterScope, policy); err != nil {
+		log.Error(err, "Garbage collection error")
+		record.Warnf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Garbage collection error - %v", err)
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+	// stop and delete tasks are polled without waiting for the interval
+	if hasGarbageCollectionTasks(proxmoxCluster.Status.OrphanedResources) {
+		return ctrl.Result{RequeueAfter: taskPollInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+func hasGarbageCollectionTasks(orphans []infrav1.OrphanedResource) bool {
+	for _, orphan := range orphans {
+		if orphan.Task != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *GarbageCollectorReconciler) collect(ctx context.Context, clusterScope *scope.ClusterScope, policy *infrav1.GarbageCollectionPolicy) error {
+	log := log.FromContext(ctx)
+	log.Info("Collecting orphaned qemus and snippets")
+	proxmoxCluster := clusterScope.ProxmoxCluster
+	proxmoxCluster.Status.LastGarbageCollectionTime = ptr.To(metav1.Now())
+
+	// list proxmox resources before ProxmoxMachines so that
+	// resources created in the meantime are never taken as orphans
+	primary, err := listSiteResources(ctx, clusterScope)
+	if err != nil {
+		return err
+	}
+	sites := []siteResources{primary}
+	for _, name := range clusterScope.SiteFailureDomains() {
+		fdScope, err := clusterScope.ForFailureDomain(ctx, name)
+		if err == nil {
+			var site siteResources
+			if site, err = listSiteResources(ctx, fdScope); err == nil {
+				sites = append(sites, site)
+				continue
+			}
+		}
+		// unavailable failure domain is collected next time
+		log.Error(err, "failed to list resources of failure domain", "failureDomain", name)
+		record.Warnf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Skipped failure domain %s - %v", name, err)
+	}
+	proxmoxMachines := &infrav1.ProxmoxMachineList{}
+	if err := r.List(ctx, proxmoxMachines,
+		client.InNamespace(clusterScope.Namespace()),
+		client.MatchingLabels{clusterv1.ClusterNameLabel: clusterScope.Name()},
+	); err != nil {
+		return err
+	}
+	machineUIDs := map[types.UID]bool{}
+	machineNames := map[string]bool{}
+	for _, pm := range proxmoxMachines.Items {
+		machineUIDs[pm.UID] = true
+		machineNames[pm.Name] = true
+	}
+
+	deleteMode := policy.Mode == infrav1.GarbageCollectionModeDelete
+	// tasks stopping or deleting qemus found by the previous collection
+	tasks := map[string]*infrav1.ProxmoxTask{}
+	for _, orphan := range proxmoxCluster.Status.OrphanedResources {
+		if orphan.Kind == infrav1.OrphanedResourceKindQEMU && orphan.VMID != nil && orphan.Task != nil {
+			tasks[orphanedQEMUKey(orphan.FailureDomain, *orphan.VMID, orphan.Name)] = orphan.Task
+		}
+	}
+	orphans := []infrav1.OrphanedResource{}
+	for _, site := range sites {
+		orphans = append(orphans, r.collectSite(ctx, site, machineUIDs, machineNames, tasks, deleteMode)...)
+	}
+	proxmoxCluster.Status.OrphanedResources = orphans
+	return nil
+}
+
+// qemus and snippets on the Proxmox-VE cluster of spec.serverRef or a failure domain
+type siteResources struct {
+	scope    *scope.ClusterScope
+	vms      []gc.VM
+	snippets []gc.Snippet
+}
+
+func listSiteResources(ctx context.Context, siteScope *scope.ClusterScope) (siteResources, error) {
+	vms, err := gc.ListVMs(ctx, siteScope.CloudClient())
+	if err != nil {
+		return siteResources{}, err
+	}
+	snippets, err := gc.ListSnippets(ctx, siteScope.CloudClient(), siteScope.Storage().Name)
+	if err != nil {
+		return siteResources{}, err
+	}
+	return siteResources{scope: siteScope, vms: vms, snippets: snippets}, nil
+}
+
+// collectSite reports and deletes (in Delete mode) orphaned resources of the site
+// and returns the ones which are not deleted yet
+func (r *GarbageCollectorReconciler) collectSite(ctx context.Context, site siteResources, machineUIDs map[types.UID]bool, machineNames map[string]bool, tasks map[string]*infrav1.ProxmoxTask, deleteMode bool) []infrav1.OrphanedResource {
+	failureDomain := site.scope.FailureDomain()
+	log := log.FromContext(ctx).WithValues("failureDomain", failureDomain)
+	proxmoxCluster := site.scope.ProxmoxCluster
+	storageName := site.scope.Storage().Name
+
+	orphans := []infrav1.OrphanedResource{}
+	for _, vm := range gc.OrphanedVMs(site.vms, proxmoxCluster.UID, machineUIDs) {
+		orphan := infrav1.OrphanedResource{
+			Kind:          infrav1.OrphanedResourceKindQEMU,
+			Node:          vm.Node,
+			Name:          vm.Name,
+			VMID:          ptr.To(vm.VMID),
+			FailureDomain: failureDomain,
+		}
+		if deleteMode {
+			t, err := r.deleteVM(ctx, site, vm, tasks[orphanedQEMUKey(failureDomain, vm.VMID, vm.Name)])
+			if err != nil {
+				log.Error(err, "failed to delete orphaned qemu", "vmid", vm.VMID)
+				record.Warnf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Failed to delete orphaned qemu %s (vmid %d) - %v", vm.Name, vm.VMID, err)
+			}
+			orphan.Task = t
+		} else {
+			record.Eventf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Found orphaned qemu %s (vmid %d) on %s", vm.Name, vm.VMID, vm.Node)
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	// snippets of the qemus being deleted are collected once the qemus are gone
+	for _, snippet := range gc.OrphanedSnippets(site.snippets, proxmoxCluster.UID, machineNames, site.vms) {
+		name, _ := snippet.MachineName(proxmoxCluster.UID)
+		if deleteMode {
+			log.Info("Deleting orphaned snippet", "node", snippet.Node, "volume", snippet.VolumeID)
+			err := gc.DeleteSnippet(ctx, site.scope.CloudClient(), storageName, snippet)
+			if err == nil {
+				record.Eventf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Deleted orphaned snippet %s", snippet.VolumeID)
+				continue
+			}
+			log.Error(err, "failed to delete orphaned snippet", "volume", snippet.VolumeID)
+			record.Warnf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Failed to delete orphaned snippet %s - %v", snippet.VolumeID, err)
+		} else {
+			record.Eventf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Found orphaned snippet %s", snippet.VolumeID)
+		}
+		orphans = append(orphans, infrav1.OrphanedResource{
+			Kind:          infrav1.OrphanedResourceKindSnippet,
+			Node:          snippet.Node,
+			Name:          name,
+			VolumeID:      snippet.VolumeID,
+			FailureDomain: failureDomain,
+		})
+	}
+	return orphans
+}
+
+// deleteVM polls the task stopping or deleting the orphaned qemu without blocking,
+// then requests the next step and returns its task. the task in flight is returned while it is running
+func (r *GarbageCollectorReconciler) deleteVM(ctx context.Context, site siteResources, vm gc.VM, previous *infrav1.ProxmoxTask) (*infrav1.ProxmoxTask, error) {
+	log := log.FromContext(ctx).WithValues("failureDomain", site.scope.FailureDomain(), "vmid", vm.VMID)
+	proxmoxCluster := site.scope.ProxmoxCluster
+	cloudClient := site.scope.CloudClient()
+
+	if previous != nil {
+		finished, err := task.IsFinished(ctx, cloudClient, previous.UPID)
+		switch {
+		case task.IsNotFound(err):
+			// actual state of the qemu is checked again below
+			log.Info("task not found. forgetting it", "operation", previous.Operation, "upid", previous.UPID, "reason", err.Error())
+		case !finished && err != nil:
+			return previous, err
+		case !finished:
+			log.Info("waiting for task to be finished", "operation", previous.Operation, "upid", previous.UPID)
+			return previous, nil
+		case err != nil:
+			// retried with the actual state of the qemu
+			log.Error(err, "task failed", "operation", previous.Operation, "upid", previous.UPID)
+			record.Warnf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Failed to %s orphaned qemu %s (vmid %d) - %v", previous.Operation, vm.Name, vm.VMID, err)
+		}
+	}
+
+	t, err := gc.DeleteVM(ctx, cloudClient, vm)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	switch t.Operation {
+	case infrav1.TaskOperationStop:
+		log.Info("Stopping orphaned qemu", "node", vm.Node, "name", vm.Name, "upid", t.UPID)
+		record.Eventf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Stopping orphaned qemu %s (vmid %d) on %s", vm.Name, vm.VMID, vm.Node)
+	default:
+		log.Info("Deleting orphaned qemu", "node", vm.Node, "name", vm.Name, "upid", t.UPID)
+		record.Eventf(proxmoxCluster, "ProxmoxClusterGarbageCollection", "Deleting orphaned qemu %s (vmid %d) on %s", vm.Name, vm.VMID, vm.Node)
+	}
+	return t, nil
+}
+
+// orphaned qemus are identified by the name as well since vmids are reused
+func orphanedQEMUKey(failureDomain string, vmid int, name string) string {
+	return fmt.Sprintf("%s/%d/%s", failureDomain, vmid, name)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GarbageCollectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("proxmoxcluster-garbagecollector").
+		For(&infrav1.ProxmoxCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util"
+	capiannotations "sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/imagecache"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+)
+
+// ImageCacheReconciler periodically evicts OS images cached on proxmox nodes
+// of a ProxmoxCluster having image cache policy
+type ImageCacheReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,verbs=get;list;watch
+
+func (r *ImageCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "ImageCache", req.NamespacedName)
+	defer func() { tracing.End(span, reterr) }()
+	log := log.FromContext(ctx)
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	if err := r.Get(ctx, req.NamespacedName, proxmoxCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	policy := proxmoxCluster.Spec.ImageCache
+	if policy == nil || !proxmoxCluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	interval := policy.Interval.Duration
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	if !proxmoxCluster.Status.Ready {
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	// evict at most once per interval
+	if last := proxmoxCluster.Status.LastImageEvictionTime; last != nil {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, proxmoxCluster.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		return ctrl.Result{}, nil
+	}
+	if capiannotations.IsPaused(cluster, proxmoxCluster) {
+		log.Info("ProxmoxCluster or linked Cluster is marked as paused. Won't evict images")
+		return ctrl.Result{}, nil
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:         r.Client,
+		Cluster:        cluster,
+		ProxmoxCluster: proxmoxCluster,
+	})
+	if err != nil {
+		return ctrl.Result{}, errors.Errorf("failed to create scope: %+v", err)
+	}
+	defer func() {
+		if err := clusterScope.Close(); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	if err := r.evict(ctx, clusterScope, policy); err != nil {
+		log.Error(err, "Image eviction error")
+		record.Warnf(proxmoxCluster, "ProxmoxClusterImageCache", "Image eviction error - %v", err)
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+func (r *ImageCacheReconciler) evict(ctx context.Context, clusterScope *scope.ClusterScope, policy *infrav1.ImageCachePolicy) error {
+	log := log.FromContext(ctx)
+	log.Info("Evicting cached images")
+	proxmoxCluster := clusterScope.ProxmoxCluster
+	proxmoxCluster.Status.LastImageEvictionTime = ptr.To(metav1.Now())
+
+	// list images before ProxmoxMachines so that images
+	// downloaded in the meantime are never taken as unreferenced
+	primary, err := listSiteImages(ctx, clusterScope)
+	if err != nil {
+		return err
+	}
+	sites := []siteImages{primary}
+	listedAll := true
+	for _, name := range clusterScope.SiteFailureDomains() {
+		fdScope, err := clusterScope.ForFailureDomain(ctx, name)
+		if err == nil {
+			var site siteImages
+			if site, err = listSiteImages(ctx, fdScope); err == nil {
+				sites = append(sites, site)
+				continue
+			}
+		}
+		// unavailable failure domain is evicted next time
+		listedAll = false
+		log.Error(err, "failed to list images of failure domain", "failureDomain", name)
+		record.Warnf(proxmoxCluster, "ProxmoxClusterImageCache", "Skipped failure domain %s - %v", name, err)
+	}
+
+	// images are shared by all the clusters on the nodes,
+	// so the ones referenced from any namespace are kept
+	referenced, err := r.referencedImages(ctx)
+	if err != nil {
+		return err
+	}
+	// import content keeps the time it was downloaded, so the time
+	// images are found referenced is recorded as their last-use time
+	images := []imagecache.Image{}
+	for _, site := range sites {
+		images = append(images, site.images...)
+	}
+	lastUsed := imagecache.UpdateLastUsed(proxmoxCluster.Status.ImportImagesLastUsed, images, referenced, time.Now(), listedAll)
+	proxmoxCluster.Status.ImportImagesLastUsed = lastUsed
+
+	limits := func(node string) imagecache.Limits { return imagecache.LimitsFor(policy, node) }
+	for _, site := range sites {
+		// import content may have images uploaded by users, which are never evicted
+		candidates := imagecache.WithLastUsed(imagecache.Downloaded(site.images, lastUsed), lastUsed)
+		r.evictSite(ctx, site, imagecache.Evictable(candidates, referenced, limits))
+	}
+	return nil
+}
+
+// referencedImages returns file names of images used by ProxmoxMachines and ProxmoxMachineTemplates
+func (r *ImageCacheReconciler) referencedImages(ctx context.Context) (map[string]bool, error) {
+	referenced := map[string]bool{}
+	proxmoxMachines := &infrav1.ProxmoxMachineList{}
+	if err := r.List(ctx, proxmoxMachines); err != nil {
+		return nil, err
+	}
+	for _, pm := range proxmoxMachines.Items {
+		for _, name := range imagecache.ReferencedNames(pm.Spec.Image) {
+			referenced[name] = true
+		}
+	}
+	templates := &infrav1.ProxmoxMachineTemplateList{}
+	if err := r.List(ctx, templates); err != nil {
+		return nil, err
+	}
+	for _, template := range templates.Items {
+		for _, name := range imagecache.ReferencedNames(template.Spec.Template.Spec.Image) {
+			referenced[name] = true
+		}
+	}
+	return referenced, nil
+}
+
+// images cached on the Proxmox-VE cluster of spec.serverRef or a failure domain
+type siteImages struct {
+	scope  *scope.ClusterScope
+	images []imagecache.Image
+}
+
+func listSiteImages(ctx context.Context, siteScope *scope.ClusterScope) (siteImages, error) {
+	var images []imagecache.Image
+	var err error
+	// API token cannot open node shell. images are downloaded into import content instead
+	if siteScope.UploadClient() != nil {
+		images, err = imagecache.ListImportImages(ctx, siteScope.CloudClient(), siteScope.Storage().Name)
+	} else {
+		images, err = imagecache.ListShellImages(ctx, siteScope.CloudClient())
+	}
+	if err != nil {
+		return siteImages{}, err
+	}
+	return siteImages{scope: siteScope, images: images}, nil
+}
+
+// evictSite deletes the images from the nodes of the site
+func (r *ImageCacheReconciler) evictSite(ctx context.Context, site siteImages, images []imagecache.Image) {
+	log := log.FromContext(ctx).WithValues("failureDomain", site.scope.FailureDomain())
+	proxmoxCluster := site.scope.ProxmoxCluster
+	cloudClient := site.scope.CloudClient()
+	for _, image := range images {
+		log.Info("Evicting cached image", "node", image.Node, "image", image.Name, "size", image.Size)
+		var err error
+		if image.VolumeID != "" {
+			err = imagecache.DeleteImportImage(ctx, cloudClient, site.scope.Storage().Name, image)
+		} else {
+			err = imagecache.DeleteShellImage(ctx, cloudClient, image)
+		}
+		if err != nil {
+			metrics.ImageCacheEvictions.WithLabelValues(metrics.ResultFailure).Inc()
+			log.Error(err, "failed to evict cached image", "node", image.Node, "image", image.Name)
+			record.Warnf(proxmoxCluster, "ProxmoxClusterImageCache", "Failed to evict cached image %s on %s - %v", image.Name, image.Node, err)
+			continue
+		}
+		metrics.ImageCacheEvictions.WithLabelValues(metrics.ResultSuccess).Inc()
+		size := resource.NewQuantity(image.Size, resource.BinarySI)
+		record.Eventf(proxmoxCluster, "ProxmoxClusterImageCache", "Evicted cached image %s (%s) on %s", image.Name, size.String(), image.Node)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImageCacheReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("proxmoxcluster-imagecache").
+		For(&infrav1.ProxmoxCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -19,23 +19,32 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	capiannotations "sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud"
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/storage"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
 )
 
 // ProxmoxClusterReconciler reconciles a ProxmoxCluster object
@@ -49,8 +58,13 @@ type ProxmoxClusterReconciler struct {
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusteridentities,verbs=get;list;watch
 
 func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "ProxmoxCluster", req.NamespacedName)
+	defer func() { tracing.End(span, reterr) }()
 	log := log.FromContext(ctx)
 
 	proxmoxCluster := &infrav1.ProxmoxCluster{}
@@ -80,6 +94,13 @@ func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
+	// patch helper is created before the scope so that
+	// the failure in creating proxmox client can be reported
+	patchHelper, err := patch.NewHelper(proxmoxCluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create the scope
 	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
 		Client:         r.Client,
@@ -87,6 +108,10 @@ func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		ProxmoxCluster: proxmoxCluster,
 	})
 	if err != nil {
+		conditions.MarkFalse(proxmoxCluster, infrav1.ProxmoxReachableCondition, infrav1.ProxmoxClientFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+		if err := patchHelper.Patch(ctx, proxmoxCluster, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{infrav1.ProxmoxReachableCondition}}); err != nil {
+			log.Error(err, "failed to patch ProxmoxCluster")
+		}
 		return ctrl.Result{}, errors.Errorf("failed to create scope: %+v", err)
 	}
 
@@ -114,6 +139,26 @@ func (r *ProxmoxClusterReconciler) reconcile(ctx context.Context, clusterScope *
 		return ctrl.Result{}, err
 	}
 
+	if _, err := clusterScope.CloudClient().RESTClient().GetVersion(ctx); err != nil {
+		if infraerrors.IsUnauthorized(err) {
+			// drop the client so that next reconciliation reads the secret and logs in again
+			log.Error(err, "Proxmox API rejected the credentials")
+			scope.InvalidateProxmoxServices(clusterScope.ProxmoxCluster)
+			clusterScope.MarkConditionFalse(infrav1.ProxmoxReachableCondition, infrav1.ProxmoxAuthenticationFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+			record.Warnf(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Proxmox API rejected the credentials - %v", err)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		log.Error(err, "Proxmox API is not reachable")
+		// check the failover endpoints on next reconciliation
+		scope.ResetActiveEndpoint(clusterScope.ProxmoxCluster)
+		clusterScope.SetActiveEndpoint("")
+		clusterScope.MarkConditionFalse(infrav1.ProxmoxReachableCondition, infrav1.ProxmoxUnreachableReason, clusterv1.ConditionSeverityError, "%v", err)
+		record.Warnf(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Proxmox API is not reachable - %v", err)
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	clusterScope.SetActiveEndpoint(clusterScope.ProxmoxServices.Endpoint)
+	clusterScope.MarkConditionTrue(infrav1.ProxmoxReachableCondition)
+
 	reconcilers := []cloud.Reconciler{
 		storage.NewService(clusterScope),
 	}
@@ -126,6 +171,11 @@ func (r *ProxmoxClusterReconciler) reconcile(ctx context.Context, clusterScope *
 		}
 	}
 
+	// unavailable failure domains don't block the cluster but are checked again later
+	clusterScope.SetFailureDomains()
+	failureDomainsReady := r.reconcileFailureDomains(ctx, clusterScope)
+	r.reconcileCertificateVerification(ctx, clusterScope)
+
 	controlPlaneEndpoint := clusterScope.ControlPlaneEndpoint()
 	if controlPlaneEndpoint.Host == "" {
 		log.Info("ProxmoxCluster does not have control-plane endpoint yet. Reconciling")
@@ -137,9 +187,82 @@ func (r *ProxmoxClusterReconciler) reconcile(ctx context.Context, clusterScope *
 	record.Eventf(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Got control-plane endpoint - %s", controlPlaneEndpoint.Host)
 	clusterScope.SetReady()
 	record.Event(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Reconciled")
+	if !failureDomainsReady {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// reconcileFailureDomains checks the Proxmox-VE clusters of the failure domains
+// and reconciles their storages. it returns false if some of them are not available
+func (r *ProxmoxClusterReconciler) reconcileFailureDomains(ctx context.Context, clusterScope *scope.ClusterScope) bool {
+	log := log.FromContext(ctx)
+
+	names := clusterScope.SiteFailureDomains()
+	if len(names) == 0 {
+		conditions.Delete(clusterScope.ProxmoxCluster, infrav1.FailureDomainsReadyCondition)
+		return true
+	}
+	failures := []string{}
+	for _, name := range names {
+		if err := r.reconcileFailureDomain(ctx, clusterScope, name); err != nil {
+			log.Error(err, "Failure domain is not available", "failureDomain", name)
+			record.Warnf(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Failure domain %s is not available - %v", name, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		clusterScope.MarkConditionFalse(infrav1.FailureDomainsReadyCondition, infrav1.FailureDomainUnavailableReason, clusterv1.ConditionSeverityWarning, "%s", strings.Join(failures, "; "))
+		return false
+	}
+	clusterScope.MarkConditionTrue(infrav1.FailureDomainsReadyCondition)
+	return true
+}
+
+// reconcileCertificateVerification reports the endpoints whose certificates are not verified
+// so that users know verification is off, which is the default for backward compatibility
+func (r *ProxmoxClusterReconciler) reconcileCertificateVerification(ctx context.Context, clusterScope *scope.ClusterScope) {
+	reasons := []string{}
+	if reason := clusterScope.ProxmoxServices.InsecureReason; reason != "" {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", clusterScope.ProxmoxServices.Endpoint, reason))
+	}
+	for _, name := range clusterScope.SiteFailureDomains() {
+		// unavailable failure domains are reported by FailureDomainsReady
+		fdScope, err := clusterScope.ForFailureDomain(ctx, name)
+		if err != nil {
+			continue
+		}
+		if reason := fdScope.ProxmoxServices.InsecureReason; reason != "" {
+			reasons = append(reasons, fmt.Sprintf("%s (failure domain %s): %s", fdScope.ProxmoxServices.Endpoint, name, reason))
+		}
+	}
+	if len(reasons) == 0 {
+		clusterScope.MarkConditionTrue(infrav1.CertificateVerifiedCondition)
+		return
+	}
+	message := strings.Join(reasons, "; ")
+	if !conditions.IsFalse(clusterScope.ProxmoxCluster, infrav1.CertificateVerifiedCondition) {
+		record.Warnf(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Certificates of proxmox endpoints are not verified - %s", message)
+	}
+	clusterScope.MarkConditionFalse(infrav1.CertificateVerifiedCondition, infrav1.CertificateVerificationDisabledReason, clusterv1.ConditionSeverityWarning, "%s", message)
+}
+
+func (r *ProxmoxClusterReconciler) reconcileFailureDomain(ctx context.Context, clusterScope *scope.ClusterScope, name string) error {
+	fdScope, err := clusterScope.ForFailureDomain(ctx, name)
+	if err != nil {
+		return err
+	}
+	if _, err := fdScope.CloudClient().RESTClient().GetVersion(ctx); err != nil {
+		if infraerrors.IsUnauthorized(err) {
+			scope.InvalidateProxmoxServices(clusterScope.ProxmoxCluster)
+		} else {
+			scope.ResetActiveEndpoint(clusterScope.ProxmoxCluster)
+		}
+		return err
+	}
+	return storage.NewService(fdScope).Reconcile(ctx)
+}
+
 func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *scope.ClusterScope) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 	log.Info("Reconciling Delete ProxmoxCluster")
@@ -148,6 +271,14 @@ func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 		storage.NewService(clusterScope),
 	}
 
+	for _, name := range clusterScope.SiteFailureDomains() {
+		fdScope, err := clusterScope.ForFailureDomain(ctx, name)
+		if err != nil {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+		}
+		reconcilers = append(reconcilers, storage.NewService(fdScope))
+	}
+
 	for _, r := range reconcilers {
 		if err := r.Delete(ctx); err != nil {
 			log.Error(err, "Reconcile error")
@@ -158,6 +289,7 @@ func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 
 	log.Info("Reconciled ProxmoxCluster")
 	controllerutil.RemoveFinalizer(clusterScope.ProxmoxCluster, infrav1.ClusterFinalizer)
+	scope.InvalidateProxmoxServices(clusterScope.ProxmoxCluster)
 	record.Event(clusterScope.ProxmoxCluster, "ProxmoxClusterReconcile", "Reconciled")
 	return ctrl.Result{}, nil
 }
@@ -166,5 +298,105 @@ func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 func (r *ProxmoxClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1.ProxmoxCluster{}).
+		// reconcile ProxmoxClusters when the credentials or CA certificates are rotated
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.referencingProxmoxClusters)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.referencingProxmoxClusters)).
+		Watches(&infrav1.ProxmoxClusterIdentity{}, handler.EnqueueRequestsFromMapFunc(r.referencingProxmoxClusters)).
 		Complete(r)
 }
+
+// referencingProxmoxClusters returns requests for ProxmoxClusters whose serverRef refers to
+// the secret, configmap or ProxmoxClusterIdentity, including the secret of the identity
+func (r *ProxmoxClusterReconciler) referencingProxmoxClusters(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := log.FromContext(ctx)
+
+	// names of ProxmoxClusterIdentities the object belongs to
+	identities := map[string]bool{}
+	switch obj.(type) {
+	case *infrav1.ProxmoxClusterIdentity:
+		identities[obj.GetName()] = true
+	case *corev1.Secret:
+		if obj.GetNamespace() == scope.IdentitySecretNamespace() {
+			var identityList infrav1.ProxmoxClusterIdentityList
+			if err := r.List(ctx, &identityList); err != nil {
+				log.Error(err, "failed to list ProxmoxClusterIdentities")
+				return nil
+			}
+			for _, identity := range identityList.Items {
+				if identity.Spec.SecretName == obj.GetName() {
+					identities[identity.Name] = true
+				}
+			}
+		}
+	}
+
+	var proxmoxClusters infrav1.ProxmoxClusterList
+	if err := r.List(ctx, &proxmoxClusters); err != nil {
+		log.Error(err, "failed to list ProxmoxClusters")
+		return nil
+	}
+	key := client.ObjectKeyFromObject(obj)
+	var requests []reconcile.Request
+	for i := range proxmoxClusters.Items {
+		proxmoxCluster := &proxmoxClusters.Items[i]
+		var refers bool
+		switch obj.(type) {
+		case *corev1.Secret:
+			refers = refersToSecret(proxmoxCluster, key)
+		case *corev1.ConfigMap:
+			refers = refersToConfigMap(proxmoxCluster, key)
+		}
+		for _, serverRef := range serverRefs(proxmoxCluster) {
+			if ref := serverRef.IdentityRef; ref != nil && identities[ref.Name] {
+				refers = true
+			}
+		}
+		if refers {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(proxmoxCluster)})
+		}
+	}
+	return requests
+}
+
+// serverRefs returns spec.serverRef and serverRefs of the failure domains
+func serverRefs(proxmoxCluster *infrav1.ProxmoxCluster) []infrav1.ServerRef {
+	refs := []infrav1.ServerRef{proxmoxCluster.Spec.ServerRef}
+	for _, fd := range proxmoxCluster.Spec.FailureDomains {
+		if fd.ServerRef != nil {
+			refs = append(refs, *fd.ServerRef)
+		}
+	}
+	return refs
+}
+
+func refersToSecret(proxmoxCluster *infrav1.ProxmoxCluster, key client.ObjectKey) bool {
+	for _, serverRef := range serverRefs(proxmoxCluster) {
+		ref := serverRef.SecretRef
+		if ref != nil && ref.Name == key.Name && refNamespace(ref.Namespace, proxmoxCluster) == key.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func refersToConfigMap(proxmoxCluster *infrav1.ProxmoxCluster, key client.ObjectKey) bool {
+	for _, serverRef := range serverRefs(proxmoxCluster) {
+		tls := serverRef.TLS
+		if tls == nil || tls.CAConfigMapRef == nil {
+			continue
+		}
+		ref := tls.CAConfigMapRef
+		if ref.Name == key.Name && refNamespace(ref.Namespace, proxmoxCluster) == key.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// references without namespace point to the namespace of the ProxmoxCluster
+func refNamespace(namespace string, proxmoxCluster *infrav1.ProxmoxCluster) string {
+	if namespace == "" {
+		return proxmoxCluster.Namespace
+	}
+	return namespace
+}
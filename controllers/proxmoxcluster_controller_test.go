@@ -9,7 +9,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 )
 
 var _ = Describe("ProxmoxClusterReconciler", Label("unit", "controllers"), func() {
@@ -53,3 +53,36 @@ var _ = Describe("ProxmoxClusterReconciler", Label("unit", "controllers"), func(
 		})
 	})
 })
+
+var _ = Describe("ProxmoxCluster references", Label("unit", "controllers"), func() {
+	proxmoxCluster := &infrav1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: infrav1.ProxmoxClusterSpec{
+			ServerRef: infrav1.ServerRef{
+				SecretRef: &infrav1.ObjectReference{Name: "foo"},
+				TLS: &infrav1.ServerTLSConfig{
+					CAConfigMapRef: &infrav1.ConfigMapKeyReference{ObjectReference: infrav1.ObjectReference{Name: "pve-ca", Namespace: "capi-system"}},
+				},
+			},
+			FailureDomains: []infrav1.FailureDomain{
+				{Name: "site-a"},
+				{Name: "site-b", ServerRef: &infrav1.ServerRef{SecretRef: &infrav1.ObjectReference{Name: "site-b"}}},
+			},
+		},
+	}
+
+	It("should match the secret in the namespace of ProxmoxCluster", func() {
+		Expect(refersToSecret(proxmoxCluster, client.ObjectKey{Namespace: "default", Name: "foo"})).To(BeTrue())
+		Expect(refersToSecret(proxmoxCluster, client.ObjectKey{Namespace: "other", Name: "foo"})).To(BeFalse())
+		Expect(refersToSecret(proxmoxCluster, client.ObjectKey{Namespace: "default", Name: "bar"})).To(BeFalse())
+	})
+
+	It("should match the secret of failure domain", func() {
+		Expect(refersToSecret(proxmoxCluster, client.ObjectKey{Namespace: "default", Name: "site-b"})).To(BeTrue())
+	})
+
+	It("should match the CA configmap", func() {
+		Expect(refersToConfigMap(proxmoxCluster, client.ObjectKey{Namespace: "capi-system", Name: "pve-ca"})).To(BeTrue())
+		Expect(refersToConfigMap(proxmoxCluster, client.ObjectKey{Namespace: "default", Name: "pve-ca"})).To(BeFalse())
+	})
+})
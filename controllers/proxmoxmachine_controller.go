@@ -23,20 +23,36 @@ import (
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud"
+	infraerrors "github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/errors"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/metrics"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
 	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/instance"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+)
+
+const (
+	// backoff of retrying transient reconcile errors
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = 5 * time.Minute
+
+	// interval of polling proxmox task in flight
+	taskPollInterval = 5 * time.Second
 )
 
 // ProxmoxMachineReconciler reconciles a ProxmoxMachine object
@@ -44,6 +60,9 @@ type ProxmoxMachineReconciler struct {
 	client.Client
 	Scheme           *runtime.Scheme
 	SchedulerManager *scheduler.Manager
+
+	// persist the last scheduling decision in the annotation of ProxmoxMachine
+	RecordSchedulingDecision bool
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch;create;update;patch;delete
@@ -56,6 +75,8 @@ type ProxmoxMachineReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
 func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "ProxmoxMachine", req.NamespacedName)
+	defer func() { tracing.End(span, reterr) }()
 	log := log.FromContext(ctx)
 
 	proxmoxMachine := &infrav1.ProxmoxMachine{}
@@ -111,6 +132,13 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	// machines in a failure domain are created on the Proxmox-VE cluster of the failure domain
+	failureDomain := machineFailureDomain(machine, proxmoxMachine)
+	clusterScope, err = clusterScope.ForFailureDomain(ctx, failureDomain)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create the machine scope
 	machineScope, err := scope.NewMachineScope(scope.MachineScopeParams{
 		Client:           r.Client,
@@ -118,6 +146,8 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		ProxmoxMachine:   proxmoxMachine,
 		ClusterGetter:    clusterScope,
 		SchedulerManager: r.SchedulerManager,
+
+		RecordSchedulingDecision: r.RecordSchedulingDecision,
 	})
 	if err != nil {
 		return ctrl.Result{}, errors.Errorf("failed to create scope: %+v", err)
@@ -130,6 +160,19 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}()
 
+	// remember the failure domain so that the vm is always looked up on the same Proxmox-VE cluster
+	if proxmoxMachine.Spec.FailureDomain == nil && failureDomain != "" {
+		proxmoxMachine.Spec.FailureDomain = ptr.To(failureDomain)
+	}
+
+	// drop the client rejected by proxmox so that the retry logs in again
+	// with the current credentials e.g. when the ticket has been invalidated on proxmox side
+	defer func() {
+		if infraerrors.IsUnauthorized(reterr) {
+			scope.InvalidateProxmoxServices(proxmoxCluster)
+		}
+	}()
+
 	// Handle deleted machines
 	if !proxmoxMachine.ObjectMeta.DeletionTimestamp.IsZero() {
 		return r.reconcileDelete(ctx, machineScope)
@@ -139,6 +182,15 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return r.reconcile(ctx, machineScope)
 }
 
+// machineFailureDomain returns the failure domain of the ProxmoxMachine,
+// or the one Cluster API placed the Machine in
+func machineFailureDomain(machine *clusterv1.Machine, proxmoxMachine *infrav1.ProxmoxMachine) string {
+	if fd := proxmoxMachine.Spec.FailureDomain; fd != nil {
+		return *fd
+	}
+	return ptr.Deref(machine.Spec.FailureDomain, "")
+}
+
 func (r *ProxmoxMachineReconciler) reconcile(ctx context.Context, machineScope *scope.MachineScope) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 	log.Info("Reconciling ProxmoxMachine")
@@ -151,37 +203,70 @@ func (r *ProxmoxMachineReconciler) reconcile(ctx context.Context, machineScope *
 		return ctrl.Result{}, err
 	}
 
+	// terminal failure is resolved only by recreating the machine (e.g. by MachineHealthCheck)
+	if machineScope.HasFailed() {
+		log.Info("ProxmoxMachine has failed. Won't reconcile", "reason", *machineScope.ProxmoxMachine.Status.FailureReason)
+		return ctrl.Result{}, nil
+	}
+
+	if machineScope.GetProviderID() == "" && machineScope.Machine.Spec.Bootstrap.DataSecretName == nil {
+		log.Info("Bootstrap data secret is not available yet")
+		machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
 	reconcilers := []cloud.Reconciler{
 		instance.NewService(machineScope),
 	}
 
 	for _, r := range reconcilers {
 		if err := r.Reconcile(ctx); err != nil {
+			if terminal, ok := infraerrors.AsTerminal(err); ok {
+				log.Error(err, "Terminal reconcile error")
+				record.Warnf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "Terminal reconcile error - %v", err)
+				machineScope.SetFailureReason(terminal.Reason)
+				machineScope.SetFailureMessage(err)
+				machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.InstanceProvisioningFailedReason, clusterv1.ConditionSeverityError, "%v", err)
+				return ctrl.Result{}, nil
+			}
+			// transient errors are retried with exponential backoff by the rate limiter
 			log.Error(err, "Reconcile error")
 			record.Warnf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "Reconcile error - %v", err)
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+			return ctrl.Result{}, err
 		}
 	}
 
+	// poll the proxmox task in flight without blocking the worker
+	if task := machineScope.GetTask(); task != nil {
+		log.Info("Waiting for proxmox task", "operation", task.Operation, "upid", task.UPID)
+		return ctrl.Result{RequeueAfter: taskPollInterval}, nil
+	}
+
 	instanceState := *machineScope.GetInstanceStatus()
 	switch instanceState {
 	case infrav1.InstanceStatusRunning:
 		log.Info("ProxmoxMachine instance is running", "bios-uuid", *machineScope.GetBiosUUID())
 		record.Eventf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "ProxmoxMachine instance is running - bios-uuid: %s", *machineScope.GetBiosUUID())
 		record.Event(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "Reconciled")
+		if !machineScope.ProxmoxMachine.Status.Ready {
+			metrics.MachineTimeToRunning.Observe(time.Since(machineScope.ProxmoxMachine.CreationTimestamp.Time).Seconds())
+		}
 		machineScope.SetReady()
 		return ctrl.Result{}, nil
 	case infrav1.InstanceStatusStopped:
 		log.Info("ProxmoxMachine instance is stopped", "instance-id", *machineScope.GetBiosUUID())
 		record.Eventf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "ProxmoxMachine instance is stopped - bios-uuid: %s", *machineScope.GetBiosUUID())
+		machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.InstanceStoppedReason, clusterv1.ConditionSeverityWarning, "")
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	case infrav1.InstanceStatusPaused:
 		log.Info("ProxmoxMachine instance is paused", "instance-id", *machineScope.GetBiosUUID())
 		record.Eventf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "ProxmoxMachine instance is paused - bios-uuid: %s", *machineScope.GetBiosUUID())
+		machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.InstancePausedReason, clusterv1.ConditionSeverityWarning, "")
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	default:
 		machineScope.SetFailureReason(capierrors.UpdateMachineError)
 		machineScope.SetFailureMessage(errors.Errorf("ProxmoxMachine instance state %s is unexpected", instanceState))
+		machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, infrav1.InstanceProvisioningFailedReason, clusterv1.ConditionSeverityError, "ProxmoxMachine instance state %s is unexpected", instanceState)
 		return ctrl.Result{Requeue: true}, nil
 	}
 }
@@ -197,11 +282,17 @@ func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineS
 	for _, r := range reconcilers {
 		if err := r.Delete(ctx); err != nil {
 			log.Error(err, "Reconcile error")
+			machineScope.MarkConditionFalse(infrav1.InstanceProvisionedCondition, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 			record.Warnf(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "Reconcile error - %v", err)
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+			return ctrl.Result{}, err
 		}
 	}
 
+	if task := machineScope.GetTask(); task != nil {
+		log.Info("Waiting for proxmox task", "operation", task.Operation, "upid", task.UPID)
+		return ctrl.Result{RequeueAfter: taskPollInterval}, nil
+	}
+
 	controllerutil.RemoveFinalizer(machineScope.ProxmoxMachine, infrav1.MachineFinalizer)
 	record.Event(machineScope.ProxmoxMachine, "ProxmoxMachineReconcile", "Reconciled")
 	log.Info("Reconciled ProxmoxMachine")
@@ -212,5 +303,8 @@ func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineS
 func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1.ProxmoxMachine{}).
+		WithOptions(controller.Options{
+			RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(retryBaseDelay, retryMaxDelay),
+		}).
 		Complete(r)
 }
@@ -6,10 +6,12 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
 )
 
 var _ = Describe("ProxmoxMachineReconciler", Label("unit", "controllers"), func() {
@@ -36,3 +38,17 @@ var _ = Describe("ProxmoxMachineReconciler", Label("unit", "controllers"), func(
 		})
 	})
 })
+
+var _ = Describe("machineFailureDomain", Label("unit", "controllers"), func() {
+	It("should prefer the failure domain of ProxmoxMachine", func() {
+		machine := &clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: ptr.To("site-a")}}
+		proxmoxMachine := &infrav1.ProxmoxMachine{Spec: infrav1.ProxmoxMachineSpec{FailureDomain: ptr.To("site-b")}}
+		Expect(machineFailureDomain(machine, proxmoxMachine)).To(Equal("site-b"))
+	})
+
+	It("should fall back to the failure domain of Machine", func() {
+		machine := &clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: ptr.To("site-a")}}
+		Expect(machineFailureDomain(machine, &infrav1.ProxmoxMachine{})).To(Equal("site-a"))
+		Expect(machineFailureDomain(&clusterv1.Machine{}, &infrav1.ProxmoxMachine{})).To(BeEmpty())
+	})
+})
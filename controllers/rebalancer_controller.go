@@ -0,0 +1,365 @@
+/*
+Copyright 2023 Teppei Sudo.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/k8s-proxmox/proxmox-go/api"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	capiannotations "sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scheduler/framework"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/scope"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/compute/storage"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/rebalance"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/tracing"
+)
+
+// RebalancerReconciler periodically moves ProxmoxMachines of a ProxmoxCluster
+// having rebalance policy from heavily loaded proxmox nodes to less loaded ones
+type RebalancerReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	SchedulerManager *scheduler.Manager
+}
+
+// rebalance target
+type rebalanceMachine struct {
+	proxmoxMachine *infrav1.ProxmoxMachine
+	machine        *clusterv1.Machine
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinehealthchecks,verbs=get;list;watch
+
+func (r *RebalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx, span := tracing.StartReconcile(ctx, "Rebalancer", req.NamespacedName)
+	defer func() { tracing.End(span, reterr) }()
+	log := log.FromContext(ctx)
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	if err := r.Get(ctx, req.NamespacedName, proxmoxCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	policy := proxmoxCluster.Spec.Rebalance
+	if policy == nil || !proxmoxCluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	interval := policy.Interval.Duration
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	if !proxmoxCluster.Status.Ready {
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	// evaluate at most once per interval
+	if last := proxmoxCluster.Status.LastRebalanceTime; last != nil {
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, proxmoxCluster.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		return ctrl.Result{}, nil
+	}
+	if capiannotations.IsPaused(cluster, proxmoxCluster) {
+		log.Info("ProxmoxCluster or linked Cluster is marked as paused. Won't rebalance")
+		return ctrl.Result{}, nil
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:         r.Client,
+		Cluster:        cluster,
+		ProxmoxCluster: proxmoxCluster,
+	})
+	if err != nil {
+		return ctrl.Result{}, errors.Errorf("failed to create scope: %+v", err)
+	}
+	defer func() {
+		if err := clusterScope.Close(); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	if err := r.rebalance(ctx, clusterScope, policy); err != nil {
+		log.Error(err, "Rebalance error")
+		record.Warnf(proxmoxCluster, "ProxmoxClusterRebalance", "Rebalance error - %v", err)
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+func (r *RebalancerReconciler) rebalance(ctx context.Context, clusterScope *scope.ClusterScope, policy *infrav1.RebalancePolicy) error {
+	log := log.FromContext(ctx)
+	log.Info("Rebalancing ProxmoxMachines")
+	clusterScope.ProxmoxCluster.Status.LastRebalanceTime = ptr.To(metav1.Now())
+
+	machines, err := r.getRebalanceMachines(ctx, clusterScope)
+	if err != nil {
+		return err
+	}
+
+	// count in-flight disruptions against the budget
+	budget := policy.MaxUnavailable
+	if budget <= 0 {
+		budget = 1
+	}
+	targets := []rebalanceMachine{}
+	for _, m := range machines {
+		if r.isUnavailable(m) {
+			budget--
+			continue
+		}
+		targets = append(targets, m)
+	}
+	if budget <= 0 {
+		log.Info("Disruption budget is exhausted. Skip rebalancing")
+		return nil
+	}
+
+	// machines are moved only within the Proxmox-VE cluster of their failure domain
+	siteScopes := map[string]*scope.ClusterScope{}
+	candidates := []rebalance.Candidate{}
+	byName := map[string]rebalanceMachine{}
+	for _, m := range targets {
+		pm := m.proxmoxMachine
+		failureDomain := ptr.Deref(pm.Spec.FailureDomain, "")
+		siteScope, ok := siteScopes[failureDomain]
+		if !ok {
+			siteScope, err = clusterScope.ForFailureDomain(ctx, failureDomain)
+			if err != nil {
+				log.Error(err, "failed to get scope of failure domain", "failureDomain", failureDomain)
+				continue
+			}
+			siteScopes[failureDomain] = siteScope
+		}
+		sched := r.SchedulerManager.GetOrCreateScheduler(siteScope.CloudClient())
+		// the qemu is scored as if it were not on any node yet, so that its
+		// current node is not penalized for the qemu's own resources
+		schedCtx := framework.ContextWithMovingQEMU(schedulerContext(ctx, pm, clusterScope.ProxmoxCluster), *pm.Spec.VMID)
+		scores, err := sched.ScoreNodes(schedCtx, rebalanceVMOptions(pm))
+		if err != nil {
+			log.Error(err, "failed to score nodes", "proxmoxmachine", pm.Name)
+			continue
+		}
+		target, improvement, ok := rebalance.Evaluate(pm.Spec.Node, scores, policy.Threshold)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, rebalance.Candidate{
+			Name:        pm.Name,
+			VMID:        *pm.Spec.VMID,
+			Node:        pm.Spec.Node,
+			TargetNode:  target,
+			Improvement: improvement,
+		})
+		byName[pm.Name] = m
+	}
+
+	for _, c := range rebalance.SelectCandidates(candidates, budget) {
+		m := byName[c.Name]
+		siteScope := siteScopes[ptr.Deref(m.proxmoxMachine.Spec.FailureDomain, "")]
+		if err := r.move(ctx, siteScope, m, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// return running ProxmoxMachines of the cluster with their owner Machine
+func (r *RebalancerReconciler) getRebalanceMachines(ctx context.Context, clusterScope *scope.ClusterScope) ([]rebalanceMachine, error) {
+	proxmoxMachines := &infrav1.ProxmoxMachineList{}
+	if err := r.List(ctx, proxmoxMachines,
+		client.InNamespace(clusterScope.Namespace()),
+		client.MatchingLabels{clusterv1.ClusterNameLabel: clusterScope.Name()},
+	); err != nil {
+		return nil, err
+	}
+	machines := []rebalanceMachine{}
+	for i := range proxmoxMachines.Items {
+		pm := &proxmoxMachines.Items[i]
+		machine, err := util.GetOwnerMachine(ctx, r.Client, pm.ObjectMeta)
+		if err != nil {
+			return nil, err
+		}
+		if machine == nil {
+			continue
+		}
+		machines = append(machines, rebalanceMachine{proxmoxMachine: pm, machine: machine})
+	}
+	return machines, nil
+}
+
+// return true if the machine is being disrupted (migrating, remediating, deleting or not ready)
+func (r *RebalancerReconciler) isUnavailable(m rebalanceMachine) bool {
+	pm := m.proxmoxMachine
+	if !pm.DeletionTimestamp.IsZero() || !m.machine.DeletionTimestamp.IsZero() {
+		return true
+	}
+	if _, ok := m.machine.Annotations[clusterv1.RemediateMachineAnnotation]; ok {
+		return true
+	}
+	if isMigrating(pm) {
+		return true
+	}
+	if !pm.Status.Ready || pm.Spec.VMID == nil || pm.Spec.Node == "" {
+		return true
+	}
+	if pm.Status.InstanceStatus == nil || *pm.Status.InstanceStatus != infrav1.InstanceStatusRunning {
+		return true
+	}
+	return false
+}
+
+// return true if the vm is being migrated or its migration is requested
+func isMigrating(pm *infrav1.ProxmoxMachine) bool {
+	if c := conditions.Get(pm, infrav1.MigratedCondition); c != nil && c.Reason == infrav1.MigratingReason {
+		return true
+	}
+	return pm.Status.Node != "" && pm.Spec.Node != pm.Status.Node
+}
+
+// request live migration of the vm if its storage is shared. otherwise mark the Machine for remediation
+// so that it is recreated on another node. the remediation is done by the MachineHealthCheck covering
+// the Machine, so the machine is not moved without it
+func (r *RebalancerReconciler) move(ctx context.Context, clusterScope *scope.ClusterScope, m rebalanceMachine, c rebalance.Candidate) error {
+	log := log.FromContext(ctx).WithValues("proxmoxmachine", c.Name, "node", c.Node, "target", c.TargetNode)
+	pm := m.proxmoxMachine
+
+	shared, err := storage.IsSharedStorage(ctx, clusterScope.CloudClient(), c.Node, pm.Spec.Storage)
+	if err != nil {
+		return err
+	}
+
+	if shared {
+		// ProxmoxMachine controller migrates the vm to the new spec.node
+		log.Info("Requesting migration of ProxmoxMachine")
+		patch := client.MergeFrom(pm.DeepCopy())
+		pm.Spec.Node = c.TargetNode
+		if err := r.Patch(ctx, pm, patch); err != nil {
+			return err
+		}
+		record.Eventf(pm, "ProxmoxMachineMigration", "Requested migration from %s to %s (improvement %.0f%%)", c.Node, c.TargetNode, c.Improvement)
+		return nil
+	}
+
+	machine := m.machine
+	covered, err := isCoveredByMachineHealthCheck(ctx, r.Client, machine)
+	if err != nil {
+		return err
+	}
+	if !covered {
+		log.Info("Storage is not shared and no MachineHealthCheck covers Machine. Skip moving ProxmoxMachine")
+		record.Warnf(pm, "ProxmoxMachineRemediation", "Storage %s is not shared and no MachineHealthCheck covers Machine %s. Skipped moving from %s (better node: %s)", pm.Spec.Storage, machine.Name, c.Node, c.TargetNode)
+		return nil
+	}
+
+	log.Info("Marking Machine for remediation")
+	patch := client.MergeFrom(machine.DeepCopy())
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+	machine.Annotations[clusterv1.RemediateMachineAnnotation] = ""
+	if err := r.Patch(ctx, machine, patch); err != nil {
+		return err
+	}
+	record.Eventf(pm, "ProxmoxMachineRemediation", "Storage %s is not shared. Requested remediation to move from %s (better node: %s)", pm.Spec.Storage, c.Node, c.TargetNode)
+	return nil
+}
+
+// return true if a MachineHealthCheck of the cluster selects the Machine,
+// which is required for the remediate-machine annotation to take effect
+func isCoveredByMachineHealthCheck(ctx context.Context, c client.Client, machine *clusterv1.Machine) (bool, error) {
+	mhcs := &clusterv1.MachineHealthCheckList{}
+	if err := c.List(ctx, mhcs, client.InNamespace(machine.Namespace)); err != nil {
+		return false, err
+	}
+	for _, mhc := range mhcs.Items {
+		if mhc.Spec.ClusterName != machine.Spec.ClusterName || !mhc.DeletionTimestamp.IsZero() {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if !selector.Empty() && selector.Matches(labels.Set(machine.Labels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bind the same scheduling constraints and scheduler profile used on creation
+func schedulerContext(ctx context.Context, pm *infrav1.ProxmoxMachine, cluster *infrav1.ProxmoxCluster) context.Context {
+	schedCtx := framework.ContextWithMap(ctx, pm.Annotations)
+	schedCtx = framework.ContextWithNodePlacement(schedCtx, framework.NodePlacement{
+		NodeSelector: pm.Spec.NodeSelector,
+		NodeAffinity: pm.Spec.NodeAffinity,
+	})
+	profile := pm.Spec.SchedulerProfile
+	if profile == "" {
+		profile = cluster.Spec.SchedulerProfile
+	}
+	return framework.ContextWithProfile(schedCtx, profile)
+}
+
+func rebalanceVMOptions(pm *infrav1.ProxmoxMachine) api.VirtualMachineCreateOptions {
+	return api.VirtualMachineCreateOptions{
+		Name:    pm.Name,
+		Cores:   pm.Spec.Hardware.CPU,
+		Sockets: pm.Spec.Hardware.Sockets,
+		Memory:  pm.Spec.Hardware.Memory,
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RebalancerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("proxmoxcluster-rebalancer").
+		For(&infrav1.ProxmoxCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("isCoveredByMachineHealthCheck", Label("unit", "controllers"), func() {
+	var machine *clusterv1.Machine
+
+	BeforeEach(func() {
+		machine = &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "foo",
+				Labels:    map[string]string{"nodepool": "workers"},
+			},
+			Spec: clusterv1.MachineSpec{ClusterName: "cluster"},
+		}
+	})
+
+	mhc := func(name, clusterName string, matchLabels map[string]string) *clusterv1.MachineHealthCheck {
+		return &clusterv1.MachineHealthCheck{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: clusterv1.MachineHealthCheckSpec{
+				ClusterName: clusterName,
+				Selector:    metav1.LabelSelector{MatchLabels: matchLabels},
+			},
+		}
+	}
+
+	covered := func(objs ...*clusterv1.MachineHealthCheck) bool {
+		scheme := runtime.NewScheme()
+		Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for _, obj := range objs {
+			builder = builder.WithObjects(obj)
+		}
+		ok, err := isCoveredByMachineHealthCheck(context.TODO(), builder.Build(), machine)
+		Expect(err).NotTo(HaveOccurred())
+		return ok
+	}
+
+	It("should be covered by the MachineHealthCheck selecting the machine", func() {
+		Expect(covered(mhc("workers", "cluster", map[string]string{"nodepool": "workers"}))).To(BeTrue())
+	})
+
+	It("should not be covered without MachineHealthCheck", func() {
+		Expect(covered()).To(BeFalse())
+	})
+
+	It("should not be covered by MachineHealthChecks of other machines or clusters", func() {
+		Expect(covered(
+			mhc("control-plane", "cluster", map[string]string{"nodepool": "control-plane"}),
+			mhc("other", "other", map[string]string{"nodepool": "workers"}),
+			mhc("empty", "cluster", nil),
+		)).To(BeFalse())
+	})
+})
@@ -3,8 +3,10 @@ package fake
 import (
 	"github.com/k8s-proxmox/proxmox-go/proxmox"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
-	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta1"
+	infrav1 "github.com/k8s-proxmox/cluster-api-provider-proxmox/api/v1beta2"
+	"github.com/k8s-proxmox/cluster-api-provider-proxmox/cloud/services/upload"
 )
 
 type FakeClusterScope struct {
@@ -13,6 +15,7 @@ type FakeClusterScope struct {
 	namespace            string
 	controlPlaneEndpoint clusterv1.APIEndpoint
 	storage              infrav1.Storage
+	conditions           clusterv1.Conditions
 }
 
 func NewClusterScope(client *proxmox.Service) *FakeClusterScope {
@@ -47,6 +50,10 @@ func (f *FakeClusterScope) CloudClient() *proxmox.Service {
 	return f.cloudClient
 }
 
+func (f *FakeClusterScope) UploadClient() *upload.Client {
+	return nil
+}
+
 func (f *FakeClusterScope) SetControlPlaneEndpoint(endpoint clusterv1.APIEndpoint) {
 	f.controlPlaneEndpoint = endpoint
 }
@@ -58,3 +65,28 @@ func (f *FakeClusterScope) SetStorage(storage infrav1.Storage) {
 func (f *FakeClusterScope) SetName(name string) {
 	f.name = name
 }
+
+func (f *FakeClusterScope) GetCondition(t clusterv1.ConditionType) *clusterv1.Condition {
+	for i := range f.conditions {
+		if f.conditions[i].Type == t {
+			return &f.conditions[i]
+		}
+	}
+	return nil
+}
+
+func (f *FakeClusterScope) MarkConditionTrue(t clusterv1.ConditionType) {
+	f.setCondition(conditions.TrueCondition(t))
+}
+
+func (f *FakeClusterScope) MarkConditionFalse(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	f.setCondition(conditions.FalseCondition(t, reason, severity, messageFormat, messageArgs...))
+}
+
+func (f *FakeClusterScope) setCondition(c *clusterv1.Condition) {
+	if existing := f.GetCondition(c.Type); existing != nil {
+		*existing = *c
+		return
+	}
+	f.conditions = append(f.conditions, *c)
+}